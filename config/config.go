@@ -19,6 +19,17 @@ func init() {
 				ImageDir:     "/tmp/eirka/src/",
 				ThumbnailDir: "/tmp/eirka/thumb/",
 				AvatarDir:    "/tmp/eirka/avatars/",
+				ArchiveDir:   "/tmp/eirka/archive/",
+			},
+			Audit: Audit{
+				BoardLogRetention: 365,
+			},
+			Flood: Flood{
+				Default: FloodThresholds{
+					Minutes:     10,
+					IPPosts:     20,
+					HashThreads: 3,
+				},
 			},
 		}
 		return
@@ -48,6 +59,10 @@ type Config struct {
 	CORS        CORS
 	Database    Database
 	Redis       Redis
+	Audit       Audit
+	Metrics     Metrics
+	Flood       Flood
+	Warnings    Warnings
 }
 
 // Admin sets what the daemon listens on
@@ -80,6 +95,65 @@ type Directories struct {
 	ImageDir     string
 	ThumbnailDir string
 	AvatarDir    string
+	ArchiveDir   string
+}
+
+// Audit sets how many days audit log entries are kept for each log type,
+// zero keeps them forever. ChainKey signs the hash chain of the mod log, it
+// is kept out of the database so the chain can not be rewritten from there
+type Audit struct {
+	BoardLogRetention uint
+	ModLogRetention   uint
+	UserLogRetention  uint
+	ChainKey          string
+}
+
+// Metrics sets where the prometheus metrics are served, without a port they
+// are served by the admin daemon to the allowed addresses or networks and not
+// at all if none are allowed
+type Metrics struct {
+	Host  string
+	Port  uint
+	Allow []string
+}
+
+// Flood sets when posting on a board is flagged, Boards overrides the default
+// thresholds by board id and bans are only applied if BanUser is set
+type Flood struct {
+	BanUser uint
+	Default FloodThresholds
+	Boards  map[uint]FloodThresholds
+}
+
+// FloodThresholds are the limits for the posts of the last Minutes, zero
+// disables a check
+type FloodThresholds struct {
+	Minutes        uint
+	IPPosts        uint
+	HashThreads    uint
+	BanIPPosts     uint
+	BanHashThreads uint
+}
+
+// Thresholds returns the flood thresholds of a board
+func (f Flood) Thresholds(ib uint) FloodThresholds {
+
+	thresholds, ok := f.Boards[ib]
+	if ok {
+		return thresholds
+	}
+
+	return f.Default
+
+}
+
+// Warnings sets when warnings turn into a temporary ban, Limit warnings on a
+// board in the last Days bans the user or ip for BanDays, a zero Limit or
+// BanDays disables it and zero Days counts every warning
+type Warnings struct {
+	Limit   uint
+	Days    uint
+	BanDays uint
 }
 
 // CORS is a list of allowed remote addresses
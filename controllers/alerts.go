@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+
+	e "github.com/eirka/eirka-libs/errors"
+
+	"github.com/eirka/eirka-admin/models"
+)
+
+// AlertsController will get the flood alerts of a board
+func AlertsController(c *gin.Context) {
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("AlertsController.protected")
+		return
+	}
+
+	// Initialize model struct
+	m := &models.AlertsModel{
+		Ib: params[0],
+	}
+
+	// Get the model which outputs JSON
+	err := m.Get()
+	if err == e.ErrNotFound {
+		c.JSON(e.ErrorMessage(e.ErrNotFound))
+		c.Error(err).SetMeta("AlertsController.Get")
+		return
+	} else if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("AlertsController.Get")
+		return
+	}
+
+	// Marshal the structs into JSON
+	output, err := json.Marshal(m.Result)
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("AlertsController.json.Marshal")
+		return
+	}
+
+	c.Data(200, "application/json", output)
+
+}
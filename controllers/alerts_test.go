@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+)
+
+func TestAlertsController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.GET("/alerts/:ib", AlertsController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	flagged := time.Date(2024, 3, 10, 15, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT alert_id,alert_type,alert_value,alert_count,thread_id,post_num,alert_banned,alert_time\s+FROM alerts`).
+		WithArgs(1, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"alert_id", "alert_type", "alert_value", "alert_count", "thread_id", "post_num", "alert_banned", "alert_time"}).
+			AddRow(3, "ip", "10.0.0.1", 25, 2, 40, true, flagged))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/alerts/1")
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.JSONEq(t, `{"alerts":[{"id":3,"type":"ip","value":"10.0.0.1","count":25,"thread":2,"post":40,"banned":true,"time":"2024-03-10T15:00:00Z"}]}`, response.Body.String(), "Response should match")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestAlertsControllerError(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.GET("/alerts/:ib", AlertsController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT alert_id`).
+		WillReturnError(errors.New("database error"))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/alerts/1")
+
+	// Check response code
+	assert.Equal(t, http.StatusInternalServerError, response.Code, "HTTP status code should be 500")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrInternalError), response.Body.String(), "Response should match expected error message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestAlertsControllerNotProtected(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockNonAdminMiddleware([]uint{1}))
+	router.GET("/alerts/:ib", AlertsController)
+
+	// Perform the request
+	response := performRequest(router, "GET", "/alerts/1")
+
+	// Check response code
+	assert.Equal(t, http.StatusInternalServerError, response.Code, "HTTP status code should be 500")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrInternalError), response.Body.String(), "Response should match expected error message")
+}
@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eirka/eirka-libs/audit"
+	e "github.com/eirka/eirka-libs/errors"
+
+	"github.com/eirka/eirka-admin/models"
+)
+
+// AuditChainController will verify the hash chain of the board mod log, the
+// other log types are written by other services and are not chained
+func AuditChainController(c *gin.Context) {
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("AuditChainController.protected")
+		return
+	}
+
+	logtype, err := strconv.ParseUint(c.DefaultQuery("type", strconv.Itoa(int(audit.ModLog))), 10, 8)
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInvalidParam))
+		c.Error(err).SetMeta("AuditChainController.ParseUint")
+		return
+	}
+
+	// Initialize model struct
+	m := &models.AuditChainModel{
+		Ib:   params[0],
+		Type: audit.LogType(logtype),
+	}
+
+	if !m.IsValid() {
+		c.JSON(e.ErrorMessage(e.ErrInvalidParam))
+		c.Error(e.ErrInvalidParam).SetMeta("AuditChainController.IsValid")
+		return
+	}
+
+	// Walk the chain
+	err = m.Verify()
+	if err == e.ErrNotFound {
+		c.JSON(e.ErrorMessage(e.ErrNotFound))
+		c.Error(err).SetMeta("AuditChainController.Verify")
+		return
+	} else if err == models.ErrAuditNotChained {
+		c.JSON(http.StatusBadRequest, gin.H{"error_message": err.Error()})
+		c.Error(err).SetMeta("AuditChainController.Verify")
+		return
+	} else if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("AuditChainController.Verify")
+		return
+	}
+
+	// Marshal the structs into JSON
+	output, err := json.Marshal(m.Result)
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("AuditChainController.json.Marshal")
+		return
+	}
+
+	c.Data(200, "application/json", output)
+
+}
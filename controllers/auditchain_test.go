@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/audit"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+	"github.com/eirka/eirka-libs/redis"
+
+	local "github.com/eirka/eirka-admin/config"
+)
+
+func TestAuditChainController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.GET("/verify", AuditChainController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	local.Settings.Audit.ChainKey = "testkey"
+	defer func() {
+		local.Settings.Audit.ChainKey = ""
+	}()
+
+	redis.NewRedisMock()
+	redis.Cache.Mock.Command("HGETALL", "audit:head:1:2").Expect([]interface{}{})
+
+	// An empty chain is valid
+	mock.ExpectQuery(`SELECT audit_id, audit_hash FROM audit_anchors`).
+		WithArgs(1, audit.ModLog).
+		WillReturnRows(sqlmock.NewRows([]string{"audit_id", "audit_hash"}))
+
+	mock.ExpectQuery(`SELECT audit_id,user_id,ib_id,audit_type,audit_ip,audit_time,audit_action,audit_info,thread_id,post_num,audit_hash
+    FROM audit`).
+		WithArgs(1, audit.ModLog).
+		WillReturnRows(sqlmock.NewRows([]string{"audit_id", "user_id", "ib_id", "audit_type", "audit_ip", "audit_time", "audit_action", "audit_info", "thread_id", "post_num", "audit_hash"}))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/verify")
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.JSONEq(t, `{"valid":true,"entries":0,"head_checked":false}`, response.Body.String(), "Response should report a valid chain")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestAuditChainControllerNotProtected(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockNonAdminMiddleware([]uint{1}))
+	router.GET("/verify", AuditChainController)
+
+	// Perform the request
+	response := performRequest(router, "GET", "/verify")
+
+	// Check response code
+	assert.Equal(t, http.StatusInternalServerError, response.Code, "HTTP status code should be 500")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrInternalError), response.Body.String(), "Response should match expected error message")
+}
+
+func TestAuditChainControllerError(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.GET("/verify", AuditChainController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	local.Settings.Audit.ChainKey = "testkey"
+	defer func() {
+		local.Settings.Audit.ChainKey = ""
+	}()
+
+	redis.NewRedisMock()
+	redis.Cache.Mock.Command("HGETALL", "audit:head:1:2").Expect([]interface{}{})
+
+	mock.ExpectQuery(`SELECT audit_id, audit_hash FROM audit_anchors`).
+		WithArgs(1, audit.ModLog).
+		WillReturnRows(sqlmock.NewRows([]string{"audit_id", "audit_hash"}))
+
+	mock.ExpectQuery(`SELECT audit_id,user_id,ib_id,audit_type,audit_ip,audit_time,audit_action,audit_info,thread_id,post_num,audit_hash
+    FROM audit`).
+		WithArgs(1, audit.ModLog).
+		WillReturnError(errors.New("database error"))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/verify")
+
+	// Check response code
+	assert.Equal(t, http.StatusInternalServerError, response.Code, "HTTP status code should be 500")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrInternalError), response.Body.String(), "Response should match expected error message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestAuditChainControllerBadType(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.GET("/verify", AuditChainController)
+
+	// Perform the request
+	response := performRequest(router, "GET", "/verify?type=9")
+
+	// Check response code
+	assert.Equal(t, http.StatusBadRequest, response.Code, "HTTP status code should be 400")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrInvalidParam), response.Body.String(), "Response should match expected error message")
+}
+
+func TestAuditChainControllerNotChained(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.GET("/verify", AuditChainController)
+
+	local.Settings.Audit.ChainKey = "testkey"
+	defer func() {
+		local.Settings.Audit.ChainKey = ""
+	}()
+
+	// Perform the request
+	response := performRequest(router, "GET", "/verify?type=1")
+
+	// Check response code
+	assert.Equal(t, http.StatusBadRequest, response.Code, "HTTP status code should be 400")
+
+	// Check response body
+	assert.JSONEq(t, `{"error_message":"only the mod log can be verified"}`, response.Body.String(), "Response should match expected error message")
+}
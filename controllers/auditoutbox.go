@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	e "github.com/eirka/eirka-libs/errors"
+
+	u "github.com/eirka/eirka-admin/utils"
+)
+
+// AuditOutboxController will report the amount of undelivered audit entries of a
+// board and the ones the database refused
+func AuditOutboxController(c *gin.Context) {
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("AuditOutboxController.protected")
+		return
+	}
+
+	// count the entries of the board in the outbox
+	undelivered, dead, err := u.AuditOutboxCount(params[0])
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("AuditOutboxController.AuditOutboxCount")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"undelivered": undelivered, "dead": dead})
+
+}
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	e "github.com/eirka/eirka-libs/errors"
+	"github.com/eirka/eirka-libs/redis"
+)
+
+func TestAuditOutboxController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.GET("/outbox", AuditOutboxController)
+
+	// Set up fake Redis connection
+	redis.NewRedisMock()
+
+	// Mock an outbox with entries of two boards
+	redis.Cache.Mock.Command("LRANGE", "audit:outbox", 0, -1).Expect([]interface{}{
+		[]byte(`{"User":2,"Ib":1,"Type":2,"IP":"10.0.0.1","Action":"Post Deleted","Info":"Thread/1"}`),
+		[]byte(`{"User":2,"Ib":2,"Type":2,"IP":"10.0.0.1","Action":"Post Deleted","Info":"Thread/1"}`),
+		[]byte(`{"User":3,"Ib":1,"Type":2,"IP":"10.0.0.2","Action":"Thread Closed","Info":"Thread"}`),
+	})
+
+	// and a refused entry of the board
+	redis.Cache.Mock.Command("LRANGE", "audit:outbox:dead", 0, -1).Expect([]interface{}{
+		[]byte(`{"User":2,"Ib":1,"Type":2,"IP":"10.0.0.1","Action":"Post Deleted","Info":"Thread/9"}`),
+	})
+
+	// Perform the request
+	response := performRequest(router, "GET", "/outbox")
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.JSONEq(t, `{"undelivered":2,"dead":1}`, response.Body.String(), "Response should only count the entries of the board")
+}
+
+func TestAuditOutboxControllerNotProtected(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockNonAdminMiddleware([]uint{1}))
+	router.GET("/outbox", AuditOutboxController)
+
+	// Perform the request
+	response := performRequest(router, "GET", "/outbox")
+
+	// Check response code
+	assert.Equal(t, http.StatusInternalServerError, response.Code, "HTTP status code should be 500")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrInternalError), response.Body.String(), "Response should match expected error message")
+}
+
+func TestAuditOutboxControllerRedisError(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.GET("/outbox", AuditOutboxController)
+
+	// Set up fake Redis connection
+	redis.NewRedisMock()
+
+	// Mock a redis failure
+	redis.Cache.Mock.Command("LRANGE", "audit:outbox", 0, -1).ExpectError(errors.New("redis error"))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/outbox")
+
+	// Check response code
+	assert.Equal(t, http.StatusInternalServerError, response.Code, "HTTP status code should be 500")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrInternalError), response.Body.String(), "Response should match expected error message")
+}
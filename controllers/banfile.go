@@ -10,11 +10,13 @@ import (
 	"github.com/eirka/eirka-libs/user"
 
 	"github.com/eirka/eirka-admin/models"
+	u "github.com/eirka/eirka-admin/utils"
 )
 
-// ban file input
+// ban file input, a rule gives the reason and a custom reason is added to it
 type banFileForm struct {
-	Reason string `json:"reason" binding:"required"`
+	Reason string `json:"reason"`
+	Rule   uint   `json:"rule_id"`
 }
 
 // BanFileController will ban an image file hash
@@ -41,6 +43,13 @@ func BanFileController(c *gin.Context) {
 		return
 	}
 
+	// a ban needs a rule or a reason
+	if bff.Rule == 0 && bff.Reason == "" {
+		c.JSON(e.ErrorMessage(e.ErrInvalidParam))
+		c.Error(e.ErrInvalidParam).SetMeta("BanFileController.Reason")
+		return
+	}
+
 	// Initialize model struct
 	m := &models.BanFileModel{
 		Ib:     params[0],
@@ -50,6 +59,33 @@ func BanFileController(c *gin.Context) {
 		Reason: bff.Reason,
 	}
 
+	// the rule sets the reason, file bans do not expire
+	if bff.Rule != 0 {
+		var rule models.Rule
+
+		rule, err = banRule(m.Ib, bff.Rule)
+		if err == models.ErrRuleNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error_message": err.Error()})
+			c.Error(err).SetMeta("BanFileController.banRule")
+			return
+		} else if err != nil {
+			c.JSON(e.ErrorMessage(e.ErrInternalError))
+			c.Error(err).SetMeta("BanFileController.banRule")
+			return
+		}
+
+		m.Rule = rule.ID
+		m.Reason = rule.Reason(bff.Reason)
+	}
+
+	// Validate input parameters
+	err = m.ValidateInput()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error_message": err.Error()})
+		c.Error(err).SetMeta("BanFileController.ValidateInput")
+		return
+	}
+
 	// Check the record id and get further info
 	err = m.Status()
 	if err == e.ErrNotFound {
@@ -83,10 +119,10 @@ func BanFileController(c *gin.Context) {
 		Info:   m.Reason,
 	}
 
-	// submit audit
-	err = audit.Submit()
+	// submit audit, undelivered entries are kept in the outbox
+	err = u.SubmitPostAudit(audit, m.Thread, m.ID)
 	if err != nil {
-		c.Error(err).SetMeta("BanFileController.audit.Submit")
+		c.Error(err).SetMeta("BanFileController.SubmitAudit")
 	}
 
 }
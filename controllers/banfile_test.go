@@ -41,8 +41,8 @@ func TestBanFileController(t *testing.T) {
 			AddRow("abcdef1234567890"))
 
 	// Mock the Post query - insert into banned_files
-	mock.ExpectExec("INSERT IGNORE INTO banned_files \\(user_id,ib_id,ban_hash,ban_reason\\) VALUES \\(\\?,\\?,\\?,\\?\\)").
-		WithArgs(2, 1, "abcdef1234567890", "test reason").
+	mock.ExpectExec("INSERT IGNORE INTO banned_files \\(user_id,ib_id,ban_hash,ban_reason,rule_id\\) VALUES \\(\\?,\\?,\\?,\\?,\\?\\)").
+		WithArgs(2, 1, "abcdef1234567890", "test reason", 0).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	// Create JSON request
@@ -195,8 +195,8 @@ func TestBanFileControllerPostError(t *testing.T) {
 			AddRow("abcdef1234567890"))
 
 	// Mock the Post query - database error
-	mock.ExpectExec("INSERT IGNORE INTO banned_files \\(user_id,ib_id,ban_hash,ban_reason\\) VALUES \\(\\?,\\?,\\?,\\?\\)").
-		WithArgs(2, 1, "abcdef1234567890", "test reason").
+	mock.ExpectExec("INSERT IGNORE INTO banned_files \\(user_id,ib_id,ban_hash,ban_reason,rule_id\\) VALUES \\(\\?,\\?,\\?,\\?,\\?\\)").
+		WithArgs(2, 1, "abcdef1234567890", "test reason", 0).
 		WillReturnError(fmt.Errorf("database error"))
 
 	// Create JSON request
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -13,9 +14,11 @@ import (
 	u "github.com/eirka/eirka-admin/utils"
 )
 
-// ban Ip input
+// ban Ip input, a rule gives the reason and a custom reason is added to it
 type banIPForm struct {
-	Reason string `json:"reason" binding:"required"`
+	Reason  string `json:"reason"`
+	Rule    uint   `json:"rule_id"`
+	Account bool   `json:"ban_account"`
 }
 
 // BanIPController will ban an ip
@@ -42,6 +45,13 @@ func BanIPController(c *gin.Context) {
 		return
 	}
 
+	// a ban needs a rule or a reason
+	if bif.Rule == 0 && bif.Reason == "" {
+		c.JSON(e.ErrorMessage(e.ErrInvalidParam))
+		c.Error(e.ErrInvalidParam).SetMeta("BanIpController.Reason")
+		return
+	}
+
 	// Initialize model struct
 	m := &models.BanIPModel{
 		Ib:     params[0],
@@ -51,6 +61,34 @@ func BanIPController(c *gin.Context) {
 		Reason: bif.Reason,
 	}
 
+	// the rule sets the reason and how long the ban lasts
+	if bif.Rule != 0 {
+		var rule models.Rule
+
+		rule, err = banRule(m.Ib, bif.Rule)
+		if err == models.ErrRuleNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error_message": err.Error()})
+			c.Error(err).SetMeta("BanIpController.banRule")
+			return
+		} else if err != nil {
+			c.JSON(e.ErrorMessage(e.ErrInternalError))
+			c.Error(err).SetMeta("BanIpController.banRule")
+			return
+		}
+
+		m.Rule = rule.ID
+		m.Reason = rule.Reason(bif.Reason)
+		m.Expires = rule.Expires(time.Now())
+	}
+
+	// Validate input parameters
+	err = m.ValidateInput()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error_message": err.Error()})
+		c.Error(err).SetMeta("BanIpController.ValidateInput")
+		return
+	}
+
 	// Check the record id and get further info
 	err = m.Status()
 	if err == e.ErrNotFound {
@@ -63,6 +101,29 @@ func BanIPController(c *gin.Context) {
 		return
 	}
 
+	if bif.Account {
+		m.Account = &models.BanUserModel{
+			Ib:      m.Ib,
+			Thread:  m.Thread,
+			ID:      m.ID,
+			User:    m.User,
+			Reason:  m.Reason,
+			Expires: m.Expires,
+			Rule:    m.Rule,
+		}
+
+		// Get the account of the post
+		err = m.Account.Status()
+		if err == models.ErrBanNoAccount {
+			// an anonymous post only has the ip
+			m.Account = nil
+		} else if err != nil {
+			c.JSON(e.ErrorMessage(e.ErrInternalError))
+			c.Error(err).SetMeta("BanIpController.Account.Status")
+			return
+		}
+	}
+
 	// add ban to database
 	err = m.Post()
 	if err != nil {
@@ -71,8 +132,10 @@ func BanIPController(c *gin.Context) {
 		return
 	}
 
-	// ban the ip in cloudflare
-	go u.CloudFlareBanIP(m.IP, m.Reason)
+	// ban the ip in cloudflare, temporary bans are only kept here
+	if m.Expires == nil {
+		go u.CloudFlareBanIP(m.IP, m.Reason)
+	}
 
 	// response message
 	c.JSON(http.StatusOK, gin.H{"success_message": audit.AuditBanIP})
@@ -87,10 +150,20 @@ func BanIPController(c *gin.Context) {
 		Info:   m.Reason,
 	}
 
-	// submit audit
-	err = audit.Submit()
+	// submit audit, undelivered entries are kept in the outbox
+	err = u.SubmitPostAudit(audit, m.Thread, m.ID)
 	if err != nil {
-		c.Error(err).SetMeta("BanIpController.audit.Submit")
+		c.Error(err).SetMeta("BanIpController.SubmitAudit")
+	}
+
+	if m.Account != nil {
+		audit.Action = u.AuditBanUser
+		audit.Info = banUserInfo(m.Account)
+
+		err = u.SubmitPostAudit(audit, m.Thread, m.ID)
+		if err != nil {
+			c.Error(err).SetMeta("BanIpController.Account.SubmitAudit")
+		}
 	}
 
 }
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -12,6 +13,7 @@ import (
 	"gopkg.in/DATA-DOG/go-sqlmock.v1"
 
 	"github.com/eirka/eirka-libs/audit"
+	"github.com/eirka/eirka-libs/config"
 	"github.com/eirka/eirka-libs/db"
 	e "github.com/eirka/eirka-libs/errors"
 )
@@ -37,8 +39,8 @@ func TestBanIPController(t *testing.T) {
 			AddRow("10.0.0.1"))
 
 	// Mock the insert query
-	mock.ExpectExec("INSERT IGNORE INTO banned_ips \\(user_id,ib_id,ban_ip,ban_reason\\) VALUES \\(\\?,\\?,\\?,\\?\\)").
-		WithArgs(2, 1, "10.0.0.1", "test reason").
+	mock.ExpectExec("INSERT INTO banned_ips \\(user_id,ib_id,ban_ip,ban_reason,ban_expires,rule_id\\) VALUES \\(\\?,\\?,\\?,\\?,\\?,\\?\\)").
+		WithArgs(2, 1, "10.0.0.1", "test reason", nil, 0).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	// Create JSON request
@@ -57,6 +59,84 @@ func TestBanIPController(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
 }
 
+func TestBanIPControllerAccount(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 1, 1}))
+	router.POST("/banip", BanIPController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT post_ip FROM threads`).
+		WithArgs(1, 1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"post_ip"}).AddRow("10.0.0.1"))
+
+	mock.ExpectQuery(`SELECT posts.user_id, user_name FROM threads`).
+		WithArgs(1, 1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "user_name"}).AddRow(5, "troll"))
+
+	// both bans are made together
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO banned_ips`).
+		WithArgs(2, 1, "10.0.0.1", "test reason", nil, 0).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO banned_users`).
+		WithArgs(2, 1, 5, false, "test reason", nil, 0).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/banip", []byte(`{"reason":"test reason","ban_account":true}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.JSONEq(t, successMessage(audit.AuditBanIP), response.Body.String(), "Response should match expected success message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestBanIPControllerAccountAnonymous(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 1, 1}))
+	router.POST("/banip", BanIPController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT post_ip FROM threads`).
+		WithArgs(1, 1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"post_ip"}).AddRow("10.0.0.1"))
+
+	mock.ExpectQuery(`SELECT posts.user_id, user_name FROM threads`).
+		WithArgs(1, 1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "user_name"}).AddRow(1, "Anonymous"))
+
+	// only the ip is banned
+	mock.ExpectExec(`INSERT INTO banned_ips`).
+		WithArgs(2, 1, "10.0.0.1", "test reason", nil, 0).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/banip", []byte(`{"reason":"test reason","ban_account":true}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
 func TestBanIPControllerNotProtected(t *testing.T) {
 	// Set up Gin
 	gin.SetMode(gin.ReleaseMode)
@@ -97,6 +177,34 @@ func TestBanIPControllerInvalidParam(t *testing.T) {
 	assert.JSONEq(t, errorMessage(e.ErrInvalidParam), response.Body.String(), "Response should match expected error message")
 }
 
+func TestBanIPControllerReasonTooLong(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 1, 1}))
+	router.POST("/banip", BanIPController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	// a reason longer than a comment is refused before anything is looked up
+	jsonRequest := []byte(fmt.Sprintf(`{"reason":"%s"}`, strings.Repeat("a", config.Settings.Limits.CommentMaxLength+1)))
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/banip", jsonRequest)
+
+	// Check response code
+	assert.Equal(t, http.StatusBadRequest, response.Code, "HTTP status code should be 400")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrCommentLong), response.Body.String(), "Response should match expected error message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
 func TestBanIPControllerNotFound(t *testing.T) {
 	// Set up Gin
 	gin.SetMode(gin.ReleaseMode)
@@ -188,8 +296,8 @@ func TestBanIPControllerPostError(t *testing.T) {
 			AddRow("10.0.0.1"))
 
 	// Mock the insert query - database error
-	mock.ExpectExec("INSERT IGNORE INTO banned_ips \\(user_id,ib_id,ban_ip,ban_reason\\) VALUES \\(\\?,\\?,\\?,\\?\\)").
-		WithArgs(2, 1, "10.0.0.1", "test reason").
+	mock.ExpectExec("INSERT INTO banned_ips \\(user_id,ib_id,ban_ip,ban_reason,ban_expires,rule_id\\) VALUES \\(\\?,\\?,\\?,\\?,\\?,\\?\\)").
+		WithArgs(2, 1, "10.0.0.1", "test reason", nil, 0).
 		WillReturnError(errors.New("database error"))
 
 	// Create JSON request
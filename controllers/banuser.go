@@ -0,0 +1,287 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eirka/eirka-libs/audit"
+	e "github.com/eirka/eirka-libs/errors"
+	"github.com/eirka/eirka-libs/user"
+
+	"github.com/eirka/eirka-admin/models"
+	u "github.com/eirka/eirka-admin/utils"
+)
+
+// ban account input, without expires the ban is permanent or lasts as long as
+// the rule says
+type banUserForm struct {
+	Reason  string     `json:"reason"`
+	Rule    uint       `json:"rule_id"`
+	Expires *time.Time `json:"expires"`
+}
+
+// banUserInfo is the audit info of an account ban
+func banUserInfo(m *models.BanUserModel) (info string) {
+
+	info = fmt.Sprintf("%s: %s", m.Name, m.Reason)
+
+	if m.Site {
+		info += " (site wide)"
+	}
+
+	if m.Expires != nil {
+		info += " until " + m.Expires.UTC().Format(time.RFC3339)
+	}
+
+	return
+
+}
+
+// BanUserController returns the handler that bans the account of a post, on
+// the site group the ban applies to every board
+func BanUserController(site bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		var buf banUserForm
+
+		// Get parameters from validate middleware
+		params := c.MustGet("params").([]uint)
+
+		// get userdata from user middleware
+		userdata := c.MustGet("userdata").(user.User)
+
+		if !c.MustGet("protected").(bool) {
+			c.JSON(e.ErrorMessage(e.ErrInternalError))
+			c.Error(e.ErrInternalError).SetMeta("BanUserController.protected")
+			return
+		}
+
+		err = c.Bind(&buf)
+		if err != nil {
+			c.JSON(e.ErrorMessage(e.ErrInvalidParam))
+			c.Error(err).SetMeta("BanUserController.Bind")
+			return
+		}
+
+		// a ban needs a rule or a reason
+		if buf.Rule == 0 && buf.Reason == "" {
+			c.JSON(e.ErrorMessage(e.ErrInvalidParam))
+			c.Error(e.ErrInvalidParam).SetMeta("BanUserController.Reason")
+			return
+		}
+
+		// Initialize model struct
+		m := &models.BanUserModel{
+			Ib:      params[0],
+			Thread:  params[1],
+			ID:      params[2],
+			User:    userdata.ID,
+			Site:    site,
+			Reason:  buf.Reason,
+			Expires: buf.Expires,
+		}
+
+		// the rule sets the reason and how long the ban lasts
+		if buf.Rule != 0 {
+			var rule models.Rule
+
+			rule, err = banRule(m.Ib, buf.Rule)
+			if err == models.ErrRuleNotFound {
+				c.JSON(http.StatusBadRequest, gin.H{"error_message": err.Error()})
+				c.Error(err).SetMeta("BanUserController.banRule")
+				return
+			} else if err != nil {
+				c.JSON(e.ErrorMessage(e.ErrInternalError))
+				c.Error(err).SetMeta("BanUserController.banRule")
+				return
+			}
+
+			m.Rule = rule.ID
+			m.Reason = rule.Reason(buf.Reason)
+			if m.Expires == nil {
+				m.Expires = rule.Expires(time.Now())
+			}
+		}
+
+		// Validate input parameters
+		err = m.ValidateInput()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error_message": err.Error()})
+			c.Error(err).SetMeta("BanUserController.ValidateInput")
+			return
+		}
+
+		// Check the record id and get further info
+		err = m.Status()
+		if err == e.ErrNotFound {
+			c.JSON(e.ErrorMessage(e.ErrNotFound))
+			c.Error(err).SetMeta("BanUserController.Status")
+			return
+		} else if err == models.ErrBanNoAccount {
+			c.JSON(http.StatusBadRequest, gin.H{"error_message": err.Error()})
+			c.Error(err).SetMeta("BanUserController.Status")
+			return
+		} else if err != nil {
+			c.JSON(e.ErrorMessage(e.ErrInternalError))
+			c.Error(err).SetMeta("BanUserController.Status")
+			return
+		}
+
+		// add ban to database
+		err = m.Post()
+		if err != nil {
+			c.JSON(e.ErrorMessage(e.ErrInternalError))
+			c.Error(err).SetMeta("BanUserController.Post")
+			return
+		}
+
+		// response message
+		c.JSON(http.StatusOK, gin.H{"success_message": u.AuditBanUser})
+
+		// audit log
+		audit := audit.Audit{
+			User:   userdata.ID,
+			Ib:     m.Ib,
+			Type:   audit.ModLog,
+			IP:     c.ClientIP(),
+			Action: u.AuditBanUser,
+			Info:   banUserInfo(m),
+		}
+
+		// submit audit, undelivered entries are kept in the outbox
+		err = u.SubmitPostAudit(audit, m.Thread, m.ID)
+		if err != nil {
+			c.Error(err).SetMeta("BanUserController.SubmitAudit")
+		}
+
+	}
+}
+
+// UserBansController returns the handler that lists the account bans of a
+// board, on the site group it lists the site wide bans
+func UserBansController(site bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Get parameters from validate middleware
+		params := c.MustGet("params").([]uint)
+
+		if !c.MustGet("protected").(bool) {
+			c.JSON(e.ErrorMessage(e.ErrInternalError))
+			c.Error(e.ErrInternalError).SetMeta("UserBansController.protected")
+			return
+		}
+
+		// Initialize model struct
+		m := &models.UserBansModel{
+			Site: site,
+		}
+
+		if site {
+			m.Page = params[0]
+		} else {
+			m.Ib = params[0]
+			m.Page = params[1]
+		}
+
+		// Get the model which outputs JSON
+		err := m.Get()
+		if err == e.ErrNotFound {
+			c.JSON(e.ErrorMessage(e.ErrNotFound))
+			c.Error(err).SetMeta("UserBansController.Get")
+			return
+		} else if err != nil {
+			c.JSON(e.ErrorMessage(e.ErrInternalError))
+			c.Error(err).SetMeta("UserBansController.Get")
+			return
+		}
+
+		// Marshal the structs into JSON
+		output, err := json.Marshal(m.Result)
+		if err != nil {
+			c.JSON(e.ErrorMessage(e.ErrInternalError))
+			c.Error(err).SetMeta("UserBansController.Marshal")
+			return
+		}
+
+		c.Data(200, "application/json", output)
+
+	}
+}
+
+// UnbanUserController returns the handler that removes an account ban, site
+// wide bans can only be removed on the site group
+func UnbanUserController(site bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Get parameters from validate middleware
+		params := c.MustGet("params").([]uint)
+
+		// get userdata from user middleware
+		userdata := c.MustGet("userdata").(user.User)
+
+		if !c.MustGet("protected").(bool) {
+			c.JSON(e.ErrorMessage(e.ErrInternalError))
+			c.Error(e.ErrInternalError).SetMeta("UnbanUserController.protected")
+			return
+		}
+
+		// Initialize model struct
+		m := &models.UnbanUserModel{}
+
+		if site {
+			m.ID = params[0]
+		} else {
+			m.Ib = params[0]
+			m.ID = params[1]
+		}
+
+		// Check the record id and get further info
+		err := m.Status()
+		if err == e.ErrNotFound {
+			c.JSON(e.ErrorMessage(e.ErrNotFound))
+			c.Error(err).SetMeta("UnbanUserController.Status")
+			return
+		} else if err != nil {
+			c.JSON(e.ErrorMessage(e.ErrInternalError))
+			c.Error(err).SetMeta("UnbanUserController.Status")
+			return
+		}
+
+		if m.Site && !site {
+			c.JSON(e.ErrorMessage(e.ErrForbidden))
+			c.Error(e.ErrForbidden).SetMeta("UnbanUserController.Site")
+			return
+		}
+
+		err = m.Delete()
+		if err != nil {
+			c.JSON(e.ErrorMessage(e.ErrInternalError))
+			c.Error(err).SetMeta("UnbanUserController.Delete")
+			return
+		}
+
+		// response message
+		c.JSON(http.StatusOK, gin.H{"success_message": u.AuditUnbanUser})
+
+		// audit log, filed under the board the ban was made from
+		audit := audit.Audit{
+			User:   userdata.ID,
+			Ib:     m.Ib,
+			Type:   audit.ModLog,
+			IP:     c.ClientIP(),
+			Action: u.AuditUnbanUser,
+			Info:   m.Name,
+		}
+
+		// submit audit, undelivered entries are kept in the outbox
+		err = u.SubmitAudit(audit)
+		if err != nil {
+			c.Error(err).SetMeta("UnbanUserController.SubmitAudit")
+		}
+
+	}
+}
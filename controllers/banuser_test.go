@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+
+	"github.com/eirka/eirka-admin/models"
+	u "github.com/eirka/eirka-admin/utils"
+)
+
+func TestBanUserController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 2, 3}))
+	router.POST("/banuser", BanUserController(true))
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT posts.user_id, user_name FROM threads`).
+		WithArgs(1, 2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "user_name"}).AddRow(5, "troll"))
+
+	expires := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+
+	mock.ExpectExec(`INSERT INTO banned_users`).
+		WithArgs(2, 1, 5, true, "test reason", &expires, 0).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/banuser",
+		[]byte(`{"reason":"test reason","expires":"`+expires.Format(time.RFC3339)+`"}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.JSONEq(t, successMessage(u.AuditBanUser), response.Body.String(), "Response should match expected success message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestBanUserControllerExpired(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 2, 3}))
+	router.POST("/banuser", BanUserController(false))
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/banuser", []byte(`{"reason":"test reason","expires":"2001-01-01T00:00:00Z"}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusBadRequest, response.Code, "HTTP status code should be 400")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(models.ErrBanExpired), response.Body.String(), "Response should match expected error message")
+}
+
+func TestUnbanUserControllerSiteBan(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 4}))
+	router.DELETE("/banuser", UnbanUserController(false))
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT ib_id, ban_site, user_name FROM banned_users`).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id", "ban_site", "user_name"}).AddRow(1, true, "troll"))
+
+	// Perform the request
+	response := performRequest(router, "DELETE", "/banuser")
+
+	// Check response code
+	assert.Equal(t, http.StatusForbidden, response.Code, "HTTP status code should be 403")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrForbidden), response.Body.String(), "Response should match expected error message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestUnbanUserControllerSite(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{4}))
+	router.DELETE("/banuser", UnbanUserController(true))
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT ib_id, ban_site, user_name FROM banned_users`).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id", "ban_site", "user_name"}).AddRow(1, true, "troll"))
+
+	mock.ExpectExec(`DELETE FROM banned_users`).
+		WithArgs(1, 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// Perform the request
+	response := performRequest(router, "DELETE", "/banuser")
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.JSONEq(t, successMessage(u.AuditUnbanUser), response.Body.String(), "Response should match expected success message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestBanUserInfo(t *testing.T) {
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	m := &models.BanUserModel{Name: "troll", Reason: "spamming"}
+	assert.Equal(t, "troll: spamming", banUserInfo(m), "Info should match")
+
+	m = &models.BanUserModel{Name: "troll", Reason: "spamming", Site: true, Expires: &expires}
+	assert.Equal(t, "troll: spamming (site wide) until 2030-01-02T03:04:05Z", banUserInfo(m), "Info should match")
+}
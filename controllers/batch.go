@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eirka/eirka-libs/audit"
+	e "github.com/eirka/eirka-libs/errors"
+	"github.com/eirka/eirka-libs/redis"
+	"github.com/eirka/eirka-libs/user"
+
+	"github.com/eirka/eirka-admin/models"
+	u "github.com/eirka/eirka-admin/utils"
+)
+
+// batch input, the mode defaults to all or nothing
+type batchForm struct {
+	Mode       string                  `json:"mode"`
+	Operations []models.BatchOperation `json:"operations" binding:"required"`
+}
+
+// BatchController will run several moderation actions in one transaction
+func BatchController(c *gin.Context) {
+	var err error
+	var bf batchForm
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	// get userdata from user middleware
+	userdata := c.MustGet("userdata").(user.User)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("BatchController.protected")
+		return
+	}
+
+	err = c.ShouldBindJSON(&bf)
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInvalidParam))
+		c.Error(err).SetMeta("BatchController.ShouldBindJSON")
+		return
+	}
+
+	if bf.Mode == "" {
+		bf.Mode = models.BatchAllOrNothing
+	}
+
+	// Initialize model struct
+	m := &models.BatchModel{
+		Ib:         params[0],
+		User:       userdata.ID,
+		Mode:       bf.Mode,
+		Operations: bf.Operations,
+	}
+
+	err = m.Run()
+	if err == e.ErrInvalidParam {
+		c.JSON(e.ErrorMessage(e.ErrInvalidParam))
+		c.Error(err).SetMeta("BatchController.Run")
+		return
+	} else if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("BatchController.Run")
+		return
+	}
+
+	output, err := json.Marshal(m.Result)
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("BatchController.json.Marshal")
+		return
+	}
+
+	// nothing was applied so there is nothing to clear or log
+	if !m.Result.Committed {
+		c.Data(http.StatusBadRequest, "application/json", output)
+		return
+	}
+
+	// Delete redis stuff, every key only once
+	keys := batchCacheKeys(m.Ib, m.Result.Results)
+	if len(keys) > 0 {
+		err = redis.Cache.Delete(keys...)
+		if err != nil {
+			c.JSON(e.ErrorMessage(e.ErrInternalError))
+			c.Error(err).SetMeta("BatchController.redis.Cache.Delete")
+			return
+		}
+	}
+
+	for _, result := range m.Result.Results {
+		if result.IP != "" {
+			// ban the ip on cloudflare too
+			go u.CloudFlareBanIP(result.IP, result.Info)
+		}
+	}
+
+	c.Data(http.StatusOK, "application/json", output)
+
+	// audit log, one entry for every action that changed something
+	for _, result := range m.Result.Results {
+		if !result.Success || result.Action == "" {
+			continue
+		}
+
+		entry := audit.Audit{
+			User:   userdata.ID,
+			Ib:     m.Ib,
+			Type:   audit.ModLog,
+			IP:     c.ClientIP(),
+			Action: result.Action,
+			Info:   result.Info,
+		}
+
+		// submit audit, undelivered entries are kept in the outbox
+		err = u.SubmitPostAudit(entry, result.Thread, result.Post)
+		if err != nil {
+			c.Error(err).SetMeta("BatchController.SubmitAudit")
+		}
+	}
+
+}
+
+// batchCacheKeys returns the cache keys the applied actions touched without duplicates
+func batchCacheKeys(ib uint, results []models.BatchResult) (keys []interface{}) {
+
+	seen := make(map[interface{}]bool)
+
+	add := func(list ...interface{}) {
+		for _, key := range list {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	for _, result := range results {
+		if !result.Success || result.Action == "" {
+			continue
+		}
+
+		switch result.Op {
+		case models.BatchDeletePost, models.BatchDeleteThread:
+			add(postCacheKeys(ib, result.Thread)...)
+		case models.BatchSticky, models.BatchClose:
+			add(fmt.Sprintf("%s:%d", "index", ib),
+				fmt.Sprintf("%s:%d", "directory", ib),
+				fmt.Sprintf("%s:%d:%d", "thread", ib, result.Thread))
+		case models.BatchDeleteImageTag:
+			add(fmt.Sprintf("%s:%d", "tags", ib),
+				fmt.Sprintf("%s:%d:%d", "tag", ib, result.Tag),
+				fmt.Sprintf("%s:%d", "image", ib))
+		}
+	}
+
+	return
+
+}
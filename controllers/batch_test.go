@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+	"github.com/eirka/eirka-libs/redis"
+)
+
+// the sticky and close status queries
+const (
+	testBatchStickyQuery = `SELECT thread_title, thread_sticky FROM threads`
+	testBatchCloseQuery  = `SELECT thread_title, thread_closed FROM threads`
+)
+
+func TestBatchController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.POST("/batch", BatchController)
+
+	// Set up fake Redis connection
+	redis.NewRedisMock()
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT batch_0").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(testBatchStickyQuery).
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"title", "sticky"}).AddRow("Thread", false))
+	mock.ExpectPrepare("UPDATE threads SET thread_sticky").
+		ExpectExec().
+		WithArgs(true, 2, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("SAVEPOINT batch_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(testBatchCloseQuery).
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"title", "closed"}).AddRow("Thread", true))
+	mock.ExpectPrepare("UPDATE threads SET thread_closed").
+		ExpectExec().
+		WithArgs(false, 2, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// both actions touch the same thread so the keys are only deleted once
+	redis.Cache.Mock.Command("DEL", "index:1", "directory:1", "thread:1:2")
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/batch", []byte(`{"operations":[{"op":"sticky","thread":2},{"op":"close","thread":2}]}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.JSONEq(t, `{"committed":true,"results":[{"op":"sticky","success":true,"action":"Thread Stickied"},{"op":"close","success":true,"action":"Thread Opened"}]}`,
+		response.Body.String(), "Response should have every result")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestBatchControllerRolledBack(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.POST("/batch", BatchController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT batch_0").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(testBatchStickyQuery).
+		WithArgs(9, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"title", "sticky"}))
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT batch_0").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/batch", []byte(`{"mode":"all","operations":[{"op":"sticky","thread":9},{"op":"close","thread":2}]}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusBadRequest, response.Code, "HTTP status code should be 400")
+
+	// Check response body
+	assert.JSONEq(t, `{"committed":false,"results":[{"op":"sticky","success":false,"error":"request not found"},{"op":"close","success":false,"error":"not run"}]}`,
+		response.Body.String(), "Response should have every result")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestBatchControllerInvalid(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.POST("/batch", BatchController)
+
+	// Perform the request with an unknown mode
+	response := performJSONRequest(router, "POST", "/batch", []byte(`{"mode":"some","operations":[{"op":"sticky","thread":2}]}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusBadRequest, response.Code, "HTTP status code should be 400")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrInvalidParam), response.Body.String(), "Response should match expected error message")
+}
+
+func TestBatchControllerNotProtected(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockNonAdminMiddleware([]uint{1}))
+	router.POST("/batch", BatchController)
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/batch", []byte(`{"operations":[{"op":"sticky","thread":2}]}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusInternalServerError, response.Code, "HTTP status code should be 500")
+}
@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eirka/eirka-libs/audit"
+	e "github.com/eirka/eirka-libs/errors"
+	"github.com/eirka/eirka-libs/redis"
+	"github.com/eirka/eirka-libs/user"
+
+	"github.com/eirka/eirka-admin/models"
+	u "github.com/eirka/eirka-admin/utils"
+)
+
+// bulk delete input, without confirm only the preview is returned
+type bulkDeleteForm struct {
+	searchForm
+	Confirm bool `json:"confirm"`
+}
+
+// BulkDeleteController will delete every undeleted post that matches a search
+func BulkDeleteController(c *gin.Context) {
+	var err error
+	var bdf bulkDeleteForm
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	// get userdata from user middleware
+	userdata := c.MustGet("userdata").(user.User)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("BulkDeleteController.protected")
+		return
+	}
+
+	err = c.ShouldBindJSON(&bdf)
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInvalidParam))
+		c.Error(err).SetMeta("BulkDeleteController.ShouldBindJSON")
+		return
+	}
+
+	// Initialize model struct
+	m := &models.BulkDeleteModel{
+		SearchFilter: bdf.filter(params[0]),
+		User:         userdata.ID,
+	}
+
+	// Validate input parameters
+	err = m.ValidateInput()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error_message": err.Error()})
+		c.Error(err).SetMeta("BulkDeleteController.ValidateInput")
+		return
+	}
+
+	if bdf.Confirm {
+		err = m.Delete()
+	} else {
+		err = m.Preview()
+	}
+	if err == e.ErrNotFound {
+		c.JSON(e.ErrorMessage(e.ErrNotFound))
+		c.Error(err).SetMeta("BulkDeleteController.Delete")
+		return
+	} else if err == models.ErrBulkTooLarge || err == models.ErrSearchRegex {
+		c.JSON(http.StatusBadRequest, gin.H{"error_message": err.Error()})
+		c.Error(err).SetMeta("BulkDeleteController.Delete")
+		return
+	} else if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("BulkDeleteController.Delete")
+		return
+	}
+
+	// the dry run shows what would be deleted
+	if !bdf.Confirm {
+		output, err := json.Marshal(m.Result)
+		if err != nil {
+			c.JSON(e.ErrorMessage(e.ErrInternalError))
+			c.Error(err).SetMeta("BulkDeleteController.json.Marshal")
+			return
+		}
+
+		c.Data(200, "application/json", output)
+		return
+	}
+
+	threads := []uint{}
+	emptied := 0
+
+	for _, thread := range m.Result.Threads {
+		threads = append(threads, thread.ID)
+		if thread.Deleted {
+			emptied++
+		}
+	}
+
+	// Delete redis stuff, the board pages only once
+	err = redis.Cache.Delete(postCacheKeys(m.Ib, threads...)...)
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("BulkDeleteController.redis.Cache.Delete")
+		return
+	}
+
+	// response message
+	c.JSON(http.StatusOK, gin.H{"success_message": u.AuditBulkDelete})
+
+	// audit log, the filter is in the bulk delete record
+	entry := audit.Audit{
+		User:   userdata.ID,
+		Ib:     m.Ib,
+		Type:   audit.ModLog,
+		IP:     c.ClientIP(),
+		Action: u.AuditBulkDelete,
+		Info:   fmt.Sprintf("bulk %d: %d posts in %d threads, %d threads deleted", m.ID, m.Result.Posts, len(threads), emptied),
+	}
+
+	// submit audit, undelivered entries are kept in the outbox
+	err = u.SubmitAudit(entry)
+	if err != nil {
+		c.Error(err).SetMeta("BulkDeleteController.SubmitAudit")
+	}
+
+	// every post and emptied thread gets its own entry like a single delete,
+	// so the history of a post shows it was deleted
+	for _, thread := range m.Result.Threads {
+		for _, post := range thread.Posts {
+			entry.Action = audit.AuditDeletePost
+			entry.Info = fmt.Sprintf("%s/%d", thread.Title, post)
+
+			err = u.SubmitPostAudit(entry, thread.ID, post)
+			if err != nil {
+				c.Error(err).SetMeta("BulkDeleteController.SubmitPostAudit")
+			}
+		}
+
+		if thread.Deleted {
+			entry.Action = audit.AuditDeleteThread
+			entry.Info = thread.Title
+
+			err = u.SubmitPostAudit(entry, thread.ID, 0)
+			if err != nil {
+				c.Error(err).SetMeta("BulkDeleteController.SubmitPostAudit")
+			}
+		}
+	}
+
+}
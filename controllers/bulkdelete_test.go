@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/audit"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+	"github.com/eirka/eirka-libs/redis"
+
+	u "github.com/eirka/eirka-admin/utils"
+)
+
+// the bulk delete match query
+const testBulkMatchQuery = `SELECT posts.post_id, threads.thread_id, thread_title, post_num`
+
+// the bulk delete remaining posts query
+const testBulkRemainingQuery = `SELECT thread_id, COUNT\(post_id\) FROM posts`
+
+// testBulkAuditInsert mocks a chained audit log insert
+func testBulkAuditInsert(mock sqlmock.Sqlmock, action, info string, thread, post uint) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT ib_id FROM imageboards WHERE ib_id = \? FOR UPDATE`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id"}).AddRow(1))
+	mock.ExpectQuery(`SELECT audit_hash FROM audit`).
+		WithArgs(1, audit.ModLog).
+		WillReturnRows(sqlmock.NewRows([]string{"audit_hash"}).AddRow("previous"))
+	mock.ExpectExec(`INSERT INTO audit \(user_id,ib_id,audit_type,audit_ip,audit_time,audit_action,audit_info,thread_id,post_num,audit_hash\)`).
+		WithArgs(2, 1, audit.ModLog, "127.0.0.1", sqlmock.AnyArg(), action, info, thread, post, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+}
+
+func TestBulkDeleteControllerPreview(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.POST("/bulk", BulkDeleteController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(testBulkMatchQuery).
+		WithArgs(1, "%spam%", "%spam%", "10.0.0.1").
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "thread_id", "thread_title", "post_num"}).
+			AddRow(10, 2, "Spam Thread", 1))
+
+	mock.ExpectQuery(testBulkRemainingQuery).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"thread_id", "count"}).AddRow(2, 1))
+
+	// Perform the request without confirming
+	response := performJSONRequest(router, "POST", "/bulk", []byte(`{"q":"spam","ip":"10.0.0.1"}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.JSONEq(t, `{"posts":1,"threads":[{"thread_id":2,"thread_title":"Spam Thread","post_nums":[1],"thread_deleted":true}]}`,
+		response.Body.String(), "Response should be the preview")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestBulkDeleteController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.POST("/bulk", BulkDeleteController)
+
+	// Set up fake Redis connection
+	redis.NewRedisMock()
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(testBulkMatchQuery).
+		WithArgs(1, "%spam%", "%spam%").
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "thread_id", "thread_title", "post_num"}).
+			AddRow(10, 2, "Spam Thread", 1).
+			AddRow(30, 4, "Good Thread", 7))
+	// the spam thread is left without posts
+	mock.ExpectQuery(testBulkRemainingQuery).
+		WithArgs(2, 4).
+		WillReturnRows(sqlmock.NewRows([]string{"thread_id", "count"}).AddRow(2, 1).AddRow(4, 9))
+	mock.ExpectExec(`UPDATE posts SET post_deleted = 1`).
+		WithArgs(10, 30).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`UPDATE threads SET thread_deleted = 1`).
+		WithArgs(1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO bulk_deletes`).
+		WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectCommit()
+
+	// the board pages are only cleared once
+	redis.Cache.Mock.Command("DEL", "index:1", "directory:1", "thread:1:2", "post:1:2", "thread:1:4", "post:1:4",
+		"tags:1", "image:1", "new:1", "popular:1", "favorited:1")
+
+	// the bulk delete and every post and thread it deleted are audited
+	testBulkAuditInsert(mock, u.AuditBulkDelete, "bulk 5: 2 posts in 2 threads, 1 threads deleted", 0, 0)
+	testBulkAuditInsert(mock, audit.AuditDeletePost, "Spam Thread/1", 2, 1)
+	testBulkAuditInsert(mock, audit.AuditDeleteThread, "Spam Thread", 2, 0)
+	testBulkAuditInsert(mock, audit.AuditDeletePost, "Good Thread/7", 4, 7)
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/bulk", []byte(`{"q":"spam","confirm":true}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.JSONEq(t, successMessage(u.AuditBulkDelete), response.Body.String(), "Response should match expected success message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestBulkDeleteControllerNoMatches(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.POST("/bulk", BulkDeleteController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(testBulkMatchQuery).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "thread_id", "thread_title", "post_num"}))
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/bulk", []byte(`{"q":"spam"}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusNotFound, response.Code, "HTTP status code should be 404")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrNotFound), response.Body.String(), "Response should match expected error message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestBulkDeleteControllerBadInput(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.POST("/bulk", BulkDeleteController)
+
+	// Perform the request without a query
+	response := performJSONRequest(router, "POST", "/bulk", []byte(`{"confirm":true}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusBadRequest, response.Code, "HTTP status code should be 400")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrInvalidParam), response.Body.String(), "Response should match expected error message")
+}
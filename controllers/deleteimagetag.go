@@ -12,6 +12,7 @@ import (
 	"github.com/eirka/eirka-libs/user"
 
 	"github.com/eirka/eirka-admin/models"
+	u "github.com/eirka/eirka-admin/utils"
 )
 
 // DeleteImageTagController will delete an image tag
@@ -81,10 +82,10 @@ func DeleteImageTagController(c *gin.Context) {
 		Info:   fmt.Sprintf("%d/%s", m.Image, m.Name),
 	}
 
-	// submit audit
-	err = audit.Submit()
+	// submit audit, undelivered entries are kept in the outbox
+	err = u.SubmitAudit(audit)
 	if err != nil {
-		c.Error(err).SetMeta("DeleteImageTagController.audit.Submit")
+		c.Error(err).SetMeta("DeleteImageTagController.SubmitAudit")
 	}
 
 }
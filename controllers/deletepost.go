@@ -12,6 +12,7 @@ import (
 	"github.com/eirka/eirka-libs/user"
 
 	"github.com/eirka/eirka-admin/models"
+	u "github.com/eirka/eirka-admin/utils"
 )
 
 // DeletePostController will mark a post as deleted in the database
@@ -57,17 +58,7 @@ func DeletePostController(c *gin.Context) {
 	}
 
 	// Delete redis stuff
-	indexKey := fmt.Sprintf("%s:%d", "index", m.Ib)
-	directoryKey := fmt.Sprintf("%s:%d", "directory", m.Ib)
-	threadKey := fmt.Sprintf("%s:%d:%d", "thread", m.Ib, m.Thread)
-	postKey := fmt.Sprintf("%s:%d:%d", "post", m.Ib, m.Thread)
-	tagsKey := fmt.Sprintf("%s:%d", "tags", m.Ib)
-	imageKey := fmt.Sprintf("%s:%d", "image", m.Ib)
-	newKey := fmt.Sprintf("%s:%d", "new", m.Ib)
-	popularKey := fmt.Sprintf("%s:%d", "popular", m.Ib)
-	favoritedKey := fmt.Sprintf("%s:%d", "favorited", m.Ib)
-
-	err = redis.Cache.Delete(indexKey, directoryKey, threadKey, postKey, tagsKey, imageKey, newKey, popularKey, favoritedKey)
+	err = deletePostCache(m.Ib, m.Thread)
 	if err != nil {
 		c.JSON(e.ErrorMessage(e.ErrInternalError))
 		c.Error(err).SetMeta("DeletePostController.redis.Cache.Delete")
@@ -87,10 +78,40 @@ func DeletePostController(c *gin.Context) {
 		Info:   fmt.Sprintf("%s/%d", m.Name, m.ID),
 	}
 
-	// submit audit
-	err = audit.Submit()
+	// submit audit, undelivered entries are kept in the outbox
+	err = u.SubmitPostAudit(audit, m.Thread, m.ID)
 	if err != nil {
-		c.Error(err).SetMeta("DeletePostController.audit.Submit")
+		c.Error(err).SetMeta("DeletePostController.SubmitAudit")
 	}
 
 }
+
+// deletePostCache removes the cached pages that show the posts of a thread
+func deletePostCache(ib, thread uint) error {
+	return redis.Cache.Delete(postCacheKeys(ib, thread)...)
+}
+
+// postCacheKeys returns the cached pages that show the posts of the threads,
+// the board pages are only listed once
+func postCacheKeys(ib uint, threads ...uint) (keys []interface{}) {
+
+	keys = append(keys,
+		fmt.Sprintf("%s:%d", "index", ib),
+		fmt.Sprintf("%s:%d", "directory", ib))
+
+	for _, thread := range threads {
+		keys = append(keys,
+			fmt.Sprintf("%s:%d:%d", "thread", ib, thread),
+			fmt.Sprintf("%s:%d:%d", "post", ib, thread))
+	}
+
+	keys = append(keys,
+		fmt.Sprintf("%s:%d", "tags", ib),
+		fmt.Sprintf("%s:%d", "image", ib),
+		fmt.Sprintf("%s:%d", "new", ib),
+		fmt.Sprintf("%s:%d", "popular", ib),
+		fmt.Sprintf("%s:%d", "favorited", ib))
+
+	return
+
+}
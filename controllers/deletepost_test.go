@@ -34,8 +34,8 @@ func TestDeletePostController(t *testing.T) {
 	// Mock the Status query
 	mock.ExpectQuery(`SELECT thread_title, post_deleted FROM threads
 		INNER JOIN posts on threads.thread_id = posts.thread_id
-		WHERE threads.thread_id = \? AND ib_id = \? LIMIT 1`).
-		WithArgs(1, 1).
+		WHERE threads.thread_id = \? AND ib_id = \? AND post_num = \? LIMIT 1`).
+		WithArgs(1, 1, 1).
 		WillReturnRows(sqlmock.NewRows([]string{"thread_title", "post_deleted"}).
 			AddRow("Test Thread", false))
 
@@ -57,6 +57,19 @@ func TestDeletePostController(t *testing.T) {
 	// Mock Redis cache deletion
 	redis.Cache.Mock.Command("DEL", "index:1", "directory:1", "thread:1:1", "post:1:1", "tags:1", "image:1", "new:1", "popular:1", "favorited:1")
 
+	// Mock the chained audit log insert
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT ib_id FROM imageboards WHERE ib_id = \? FOR UPDATE`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id"}).AddRow(1))
+	mock.ExpectQuery(`SELECT audit_hash FROM audit`).
+		WithArgs(1, audit.ModLog).
+		WillReturnRows(sqlmock.NewRows([]string{"audit_hash"}).AddRow("previous"))
+	mock.ExpectExec(`INSERT INTO audit \(user_id,ib_id,audit_type,audit_ip,audit_time,audit_action,audit_info,thread_id,post_num,audit_hash\)`).
+		WithArgs(2, 1, audit.ModLog, "127.0.0.1", sqlmock.AnyArg(), audit.AuditDeletePost, "Test Thread/1", 1, 1, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
 	// Perform the request
 	response := performRequest(router, "DELETE", "/deletepost")
 
@@ -102,8 +115,8 @@ func TestDeletePostControllerNotFound(t *testing.T) {
 	// Mock the Status query - not found error
 	mock.ExpectQuery(`SELECT thread_title, post_deleted FROM threads
 		INNER JOIN posts on threads.thread_id = posts.thread_id
-		WHERE threads.thread_id = \? AND ib_id = \? LIMIT 1`).
-		WithArgs(1, 1).
+		WHERE threads.thread_id = \? AND ib_id = \? AND post_num = \? LIMIT 1`).
+		WithArgs(1, 1, 1).
 		WillReturnError(e.ErrNotFound)
 
 	// Perform the request
@@ -134,8 +147,8 @@ func TestDeletePostControllerStatusError(t *testing.T) {
 	// Mock the Status query - database error
 	mock.ExpectQuery(`SELECT thread_title, post_deleted FROM threads
 		INNER JOIN posts on threads.thread_id = posts.thread_id
-		WHERE threads.thread_id = \? AND ib_id = \? LIMIT 1`).
-		WithArgs(1, 1).
+		WHERE threads.thread_id = \? AND ib_id = \? AND post_num = \? LIMIT 1`).
+		WithArgs(1, 1, 1).
 		WillReturnError(fmt.Errorf("database error"))
 
 	// Perform the request
@@ -166,8 +179,8 @@ func TestDeletePostControllerDeleteError(t *testing.T) {
 	// Mock the Status query - successful
 	mock.ExpectQuery(`SELECT thread_title, post_deleted FROM threads
 		INNER JOIN posts on threads.thread_id = posts.thread_id
-		WHERE threads.thread_id = \? AND ib_id = \? LIMIT 1`).
-		WithArgs(1, 1).
+		WHERE threads.thread_id = \? AND ib_id = \? AND post_num = \? LIMIT 1`).
+		WithArgs(1, 1, 1).
 		WillReturnRows(sqlmock.NewRows([]string{"thread_title", "post_deleted"}).
 			AddRow("Test Thread", false))
 
@@ -217,8 +230,8 @@ func TestDeletePostControllerRedisError(t *testing.T) {
 	// Mock the Status query
 	mock.ExpectQuery(`SELECT thread_title, post_deleted FROM threads
 		INNER JOIN posts on threads.thread_id = posts.thread_id
-		WHERE threads.thread_id = \? AND ib_id = \? LIMIT 1`).
-		WithArgs(1, 1).
+		WHERE threads.thread_id = \? AND ib_id = \? AND post_num = \? LIMIT 1`).
+		WithArgs(1, 1, 1).
 		WillReturnRows(sqlmock.NewRows([]string{"thread_title", "post_deleted"}).
 			AddRow("Test Thread", false))
 
@@ -272,8 +285,8 @@ func TestDeletePostControllerLastPost(t *testing.T) {
 	// Mock the Status query
 	mock.ExpectQuery(`SELECT thread_title, post_deleted FROM threads
 		INNER JOIN posts on threads.thread_id = posts.thread_id
-		WHERE threads.thread_id = \? AND ib_id = \? LIMIT 1`).
-		WithArgs(1, 1).
+		WHERE threads.thread_id = \? AND ib_id = \? AND post_num = \? LIMIT 1`).
+		WithArgs(1, 1, 1).
 		WillReturnRows(sqlmock.NewRows([]string{"thread_title", "post_deleted"}).
 			AddRow("Test Thread", false))
 
@@ -335,8 +348,8 @@ func TestDeletePostControllerAuditError(t *testing.T) {
 	// Mock the Status query
 	mock.ExpectQuery(`SELECT thread_title, post_deleted FROM threads
 		INNER JOIN posts on threads.thread_id = posts.thread_id
-		WHERE threads.thread_id = \? AND ib_id = \? LIMIT 1`).
-		WithArgs(1, 1).
+		WHERE threads.thread_id = \? AND ib_id = \? AND post_num = \? LIMIT 1`).
+		WithArgs(1, 1, 1).
 		WillReturnRows(sqlmock.NewRows([]string{"thread_title", "post_deleted"}).
 			AddRow("Test Thread", false))
 
@@ -12,6 +12,7 @@ import (
 	"github.com/eirka/eirka-libs/user"
 
 	"github.com/eirka/eirka-admin/models"
+	u "github.com/eirka/eirka-admin/utils"
 )
 
 // DeleteTagController will delete a tag
@@ -80,10 +81,10 @@ func DeleteTagController(c *gin.Context) {
 		Info:   m.Name,
 	}
 
-	// submit audit
-	err = audit.Submit()
+	// submit audit, undelivered entries are kept in the outbox
+	err = u.SubmitAudit(audit)
 	if err != nil {
-		c.Error(err).SetMeta("DeleteTagController.audit.Submit")
+		c.Error(err).SetMeta("DeleteTagController.SubmitAudit")
 	}
 
 }
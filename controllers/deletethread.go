@@ -12,6 +12,7 @@ import (
 	"github.com/eirka/eirka-libs/user"
 
 	"github.com/eirka/eirka-admin/models"
+	u "github.com/eirka/eirka-admin/utils"
 )
 
 // DeleteThreadController will mark a thread as deleted in the database
@@ -86,10 +87,10 @@ func DeleteThreadController(c *gin.Context) {
 		Info:   m.Name,
 	}
 
-	// submit audit
-	err = audit.Submit()
+	// submit audit, undelivered entries are kept in the outbox
+	err = u.SubmitPostAudit(audit, m.ID, 0)
 	if err != nil {
-		c.Error(err).SetMeta("DeleteThreadController.audit.Submit")
+		c.Error(err).SetMeta("DeleteThreadController.SubmitAudit")
 	}
 
 }
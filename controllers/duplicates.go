@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+
+	e "github.com/eirka/eirka-libs/errors"
+
+	"github.com/eirka/eirka-admin/models"
+)
+
+// DuplicatesController will get the most duplicated files of a board
+func DuplicatesController(c *gin.Context) {
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("DuplicatesController.protected")
+		return
+	}
+
+	// Initialize model struct
+	m := &models.DuplicatesModel{
+		Ib: params[0],
+	}
+
+	// Get the model which outputs JSON
+	err := m.Get()
+	if err == e.ErrNotFound {
+		c.JSON(e.ErrorMessage(e.ErrNotFound))
+		c.Error(err).SetMeta("DuplicatesController.Get")
+		return
+	} else if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("DuplicatesController.Get")
+		return
+	}
+
+	// Marshal the structs into JSON
+	output, err := json.Marshal(m.Result)
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("DuplicatesController.json.Marshal")
+		return
+	}
+
+	c.Data(200, "application/json", output)
+
+}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+)
+
+func TestDuplicatesController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.GET("/duplicates", DuplicatesController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT dupes.image_hash, dupes.copies, dupes.threads`).
+		WithArgs(1, 1, 50).
+		WillReturnRows(sqlmock.NewRows([]string{"image_hash", "copies", "threads", "banned", "thread_id", "post_num", "post_time"}))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/duplicates")
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.JSONEq(t, `{"duplicates":[]}`, response.Body.String(), "Response should be empty")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestDuplicatesControllerNotProtected(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockNonAdminMiddleware([]uint{1}))
+	router.GET("/duplicates", DuplicatesController)
+
+	// Perform the request
+	response := performRequest(router, "GET", "/duplicates")
+
+	// Check response code
+	assert.Equal(t, http.StatusInternalServerError, response.Code, "HTTP status code should be 500")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrInternalError), response.Body.String(), "Response should match expected error message")
+}
@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"io"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	e "github.com/eirka/eirka-libs/errors"
+
+	"github.com/eirka/eirka-admin/models"
+	u "github.com/eirka/eirka-admin/utils"
+)
+
+// how often a comment is sent to keep idle streams open
+const eventsKeepAlive = 30 * time.Second
+
+// EventsController will stream the moderation actions of a board as server-sent events
+func EventsController(c *gin.Context) {
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("EventsController.protected")
+		return
+	}
+
+	// subscribe to the events published by every admin instance
+	sub, err := u.SubscribeEvents(params[0])
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("EventsController.SubscribeEvents")
+		return
+	}
+	defer sub.Close()
+
+	// Initialize model struct
+	m := &models.EventModel{
+		Ib: params[0],
+	}
+
+	ticker := time.NewTicker(eventsKeepAlive)
+	defer ticker.Stop()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// stop nginx from buffering the stream
+	c.Header("X-Accel-Buffering", "no")
+
+	// send the headers now, a client would otherwise wait for the first event
+	// to know the stream is open
+	_, err = io.WriteString(c.Writer, ": connected\n\n")
+	if err != nil {
+		return
+	}
+	c.Writer.Flush()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-ticker.C:
+			_, err = io.WriteString(c.Writer, ": keepalive\n\n")
+			if err != nil {
+				return
+			}
+		case entry, ok := <-sub.Entries:
+			if !ok {
+				return
+			}
+
+			log, err := m.Get(entry)
+			if err != nil {
+				c.Error(err).SetMeta("EventsController.Get")
+				continue
+			}
+
+			c.SSEvent("modlog", log)
+		}
+
+		c.Writer.Flush()
+	}
+
+}
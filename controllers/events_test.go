@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/audit"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+	"github.com/eirka/eirka-libs/redis"
+
+	u "github.com/eirka/eirka-admin/utils"
+)
+
+func TestEventsController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.GET("/events", EventsController)
+
+	// Set up fake Redis connection
+	redis.NewRedisMock()
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	// Mock the subscription
+	redis.Cache.Mock.Command("SUBSCRIBE", "events:1").Expect([]interface{}{
+		[]byte("subscribe"),
+		[]byte("events:1"),
+		int64(1),
+	})
+
+	// Mock a published entry, the stream ends when the mock runs out of messages
+	entry, _ := json.Marshal(u.AuditEntry{
+		Audit: audit.Audit{
+			User:   2,
+			Ib:     1,
+			Type:   audit.ModLog,
+			IP:     "10.0.0.1",
+			Action: audit.AuditDeletePost,
+			Info:   "Thread/1",
+		},
+		Time: time.Now(),
+	})
+
+	redis.Cache.Mock.AddSubscriptionMessage([]interface{}{
+		[]byte("message"),
+		[]byte("events:1"),
+		entry,
+	})
+
+	// Mock the user lookup
+	mock.ExpectQuery(`SELECT users.user_id,user_name,(.+)WHERE users.user_id = \?`).
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "user_name", "role"}).
+			AddRow(2, "mod", 3))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/events")
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response headers
+	assert.Contains(t, response.Header().Get("Content-Type"), "text/event-stream", "Content type should be an event stream")
+
+	// Check response body
+	assert.True(t, strings.HasPrefix(response.Body.String(), ": connected\n\n"), "Stream should be opened before the first event")
+	assert.Contains(t, response.Body.String(), "event:modlog", "Response should contain a mod log event")
+	assert.Contains(t, response.Body.String(), `"log_action":"Post Deleted"`, "Event should use the mod log payload")
+	assert.Contains(t, response.Body.String(), `"user_name":"mod"`, "Event should contain the user name")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestEventsControllerNotProtected(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockNonAdminMiddleware([]uint{1}))
+	router.GET("/events", EventsController)
+
+	// Perform the request
+	response := performRequest(router, "GET", "/events")
+
+	// Check response code
+	assert.Equal(t, http.StatusInternalServerError, response.Code, "HTTP status code should be 500")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrInternalError), response.Body.String(), "Response should match expected error message")
+}
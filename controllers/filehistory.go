@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	e "github.com/eirka/eirka-libs/errors"
+
+	"github.com/eirka/eirka-admin/models"
+)
+
+// FileHistoryController will get the posts on every board using the file of a post
+func FileHistoryController(c *gin.Context) {
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("FileHistoryController.protected")
+		return
+	}
+
+	// the hash is looked up from the post like a ban
+	post := &models.BanFileModel{
+		Ib:     params[0],
+		Thread: params[1],
+		ID:     params[2],
+	}
+
+	err := post.Status()
+	if err == e.ErrNotFound {
+		c.JSON(e.ErrorMessage(e.ErrNotFound))
+		c.Error(err).SetMeta("FileHistoryController.Status")
+		return
+	} else if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("FileHistoryController.Status")
+		return
+	}
+
+	// Initialize model struct
+	m := &models.FileHistoryModel{
+		Hash: post.Hash,
+		Page: params[3],
+	}
+
+	fileHistory(c, m)
+
+}
+
+// BoardFileHistoryController will get the posts on every board using a file hash
+// that was posted on the board
+func BoardFileHistoryController(c *gin.Context) {
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("BoardFileHistoryController.protected")
+		return
+	}
+
+	// Initialize model struct
+	m := &models.FileHistoryModel{
+		Hash: c.Query("hash"),
+		Page: params[1],
+	}
+
+	// Validate input parameters
+	err := m.ValidateInput()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error_message": err.Error()})
+		c.Error(err).SetMeta("BoardFileHistoryController.ValidateInput")
+		return
+	}
+
+	err = m.OnBoard(params[0])
+	if err == e.ErrNotFound {
+		c.JSON(e.ErrorMessage(e.ErrNotFound))
+		c.Error(err).SetMeta("BoardFileHistoryController.OnBoard")
+		return
+	} else if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("BoardFileHistoryController.OnBoard")
+		return
+	}
+
+	fileHistory(c, m)
+
+}
+
+// SiteFileHistoryController will get the posts on every board using a file hash
+func SiteFileHistoryController(c *gin.Context) {
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("SiteFileHistoryController.protected")
+		return
+	}
+
+	// Initialize model struct
+	m := &models.FileHistoryModel{
+		Hash: c.Query("hash"),
+		Page: params[0],
+	}
+
+	// Validate input parameters
+	err := m.ValidateInput()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error_message": err.Error()})
+		c.Error(err).SetMeta("SiteFileHistoryController.ValidateInput")
+		return
+	}
+
+	fileHistory(c, m)
+
+}
+
+// fileHistory writes the history of the file
+func fileHistory(c *gin.Context, m *models.FileHistoryModel) {
+
+	// Get the model which outputs JSON
+	err := m.Get()
+	if err == e.ErrNotFound {
+		c.JSON(e.ErrorMessage(e.ErrNotFound))
+		c.Error(err).SetMeta("FileHistoryController.Get")
+		return
+	} else if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("FileHistoryController.Get")
+		return
+	}
+
+	// Marshal the structs into JSON
+	output, err := json.Marshal(m.Result)
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("FileHistoryController.json.Marshal")
+		return
+	}
+
+	c.Data(200, "application/json", output)
+
+}
@@ -0,0 +1,200 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/config"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+)
+
+func TestFileHistoryController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 2, 5, 1}))
+	router.GET("/file", FileHistoryController)
+
+	config.Settings.Limits.PostsPerPage = 10
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT image_hash FROM threads`).
+		WithArgs(1, 2, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"image_hash"}).AddRow("abcdef"))
+
+	mock.ExpectQuery(`SELECT COUNT\(image_id\) FROM images WHERE image_hash = \?`).
+		WithArgs("abcdef").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	mock.ExpectQuery(`SELECT ib_id, threads.thread_id, thread_title, post_num, post_time, post_deleted, image_id`).
+		WithArgs("abcdef", 0, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id", "thread_id", "thread_title", "post_num", "post_time", "post_deleted", "image_id"}).
+			AddRow(1, 2, "Test Thread", 5, nil, false, 11))
+
+	mock.ExpectQuery(`SELECT ib_id, banned_files.user_id, user_name, ban_reason FROM banned_files`).
+		WithArgs("abcdef").
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id", "user_id", "user_name", "ban_reason"}))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/file")
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.Contains(t, response.Body.String(), `"banned":false`, "Response should not be banned")
+	assert.Contains(t, response.Body.String(), `"image_id":11`, "Response should have the post")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestFileHistoryControllerNoImage(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 2, 5, 1}))
+	router.GET("/file", FileHistoryController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT image_hash FROM threads`).
+		WithArgs(1, 2, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"image_hash"}))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/file")
+
+	// Check response code
+	assert.Equal(t, http.StatusNotFound, response.Code, "HTTP status code should be 404")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrNotFound), response.Body.String(), "Response should match expected error message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestSiteFileHistoryControllerBadHash(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.GET("/file", SiteFileHistoryController)
+
+	// Perform the request
+	response := performRequest(router, "GET", "/file?hash=zzz")
+
+	// Check response code
+	assert.Equal(t, http.StatusBadRequest, response.Code, "HTTP status code should be 400")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrInvalidParam), response.Body.String(), "Response should match expected error message")
+}
+
+func TestBoardFileHistoryController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 1}))
+	router.GET("/file", BoardFileHistoryController)
+
+	config.Settings.Limits.PostsPerPage = 10
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT COUNT\(image_id\) FROM images
+    INNER JOIN posts`).
+		WithArgs(1, "abcdef").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	mock.ExpectQuery(`SELECT COUNT\(image_id\) FROM images WHERE image_hash = \?`).
+		WithArgs("abcdef").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	mock.ExpectQuery(`SELECT ib_id, threads.thread_id, thread_title, post_num, post_time, post_deleted, image_id`).
+		WithArgs("abcdef", 0, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id", "thread_id", "thread_title", "post_num", "post_time", "post_deleted", "image_id"}).
+			AddRow(2, 7, "Other Thread", 3, nil, false, 12).
+			AddRow(1, 2, "Test Thread", 5, nil, false, 11))
+
+	mock.ExpectQuery(`SELECT ib_id, banned_files.user_id, user_name, ban_reason FROM banned_files`).
+		WithArgs("abcdef").
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id", "user_id", "user_name", "ban_reason"}))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/file?hash=abcdef")
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.Contains(t, response.Body.String(), `"image_id":12`, "Response should have the post on the other board")
+	assert.Contains(t, response.Body.String(), `"image_id":11`, "Response should have the post on the board")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestBoardFileHistoryControllerNotOnBoard(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 1}))
+	router.GET("/file", BoardFileHistoryController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	// the file was never posted on the board
+	mock.ExpectQuery(`SELECT COUNT\(image_id\) FROM images
+    INNER JOIN posts`).
+		WithArgs(1, "abcdef").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/file?hash=abcdef")
+
+	// Check response code
+	assert.Equal(t, http.StatusNotFound, response.Code, "HTTP status code should be 404")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrNotFound), response.Body.String(), "Response should match expected error message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestBoardFileHistoryControllerBadHash(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 1}))
+	router.GET("/file", BoardFileHistoryController)
+
+	// Perform the request
+	response := performRequest(router, "GET", "/file?hash=zzz")
+
+	// Check response code
+	assert.Equal(t, http.StatusBadRequest, response.Code, "HTTP status code should be 400")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrInvalidParam), response.Body.String(), "Response should match expected error message")
+}
@@ -0,0 +1,355 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eirka/eirka-libs/audit"
+	e "github.com/eirka/eirka-libs/errors"
+	"github.com/eirka/eirka-libs/user"
+
+	"github.com/eirka/eirka-admin/models"
+	u "github.com/eirka/eirka-admin/utils"
+)
+
+// filter input, new filters are enabled unless told otherwise
+type filterForm struct {
+	Pattern     string `json:"pattern" binding:"required"`
+	Mode        string `json:"mode"`
+	Action      string `json:"action" binding:"required"`
+	Replacement string `json:"replacement"`
+	Enabled     *bool  `json:"enabled"`
+}
+
+// filter test input
+type filterTestForm struct {
+	Text string `json:"text" binding:"required"`
+}
+
+// set copies the form into the filter
+func (f filterForm) set(m *models.FilterModel) {
+	m.Pattern = f.Pattern
+	m.Mode = f.Mode
+	m.Action = f.Action
+	m.Replacement = f.Replacement
+	m.Enabled = f.Enabled == nil || *f.Enabled
+}
+
+// filterInfo is the audit info of a filter
+func filterInfo(m *models.FilterModel) string {
+	return fmt.Sprintf("filter %d: %s %s", m.ID, m.Action, m.Pattern)
+}
+
+// FiltersController will list the word filters of a board
+func FiltersController(c *gin.Context) {
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("FiltersController.protected")
+		return
+	}
+
+	// Initialize model struct
+	m := &models.FiltersModel{
+		Ib: params[0],
+	}
+
+	// Get the model which outputs JSON
+	err := m.Get()
+	if err == e.ErrNotFound {
+		c.JSON(e.ErrorMessage(e.ErrNotFound))
+		c.Error(err).SetMeta("FiltersController.Get")
+		return
+	} else if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("FiltersController.Get")
+		return
+	}
+
+	// Marshal the structs into JSON
+	output, err := json.Marshal(m.Result)
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("FiltersController.Marshal")
+		return
+	}
+
+	c.Data(200, "application/json", output)
+
+}
+
+// AddFilterController will add a word filter to a board
+func AddFilterController(c *gin.Context) {
+	var err error
+	var ff filterForm
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	// get userdata from user middleware
+	userdata := c.MustGet("userdata").(user.User)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("AddFilterController.protected")
+		return
+	}
+
+	err = c.ShouldBindJSON(&ff)
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInvalidParam))
+		c.Error(err).SetMeta("AddFilterController.ShouldBindJSON")
+		return
+	}
+
+	// Initialize model struct
+	m := &models.FilterModel{
+		Ib:   params[0],
+		User: userdata.ID,
+	}
+
+	ff.set(m)
+
+	// Validate input parameters
+	err = m.ValidateInput()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error_message": err.Error()})
+		c.Error(err).SetMeta("AddFilterController.ValidateInput")
+		return
+	}
+
+	err = m.Post()
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("AddFilterController.Post")
+		return
+	}
+
+	// response message
+	c.JSON(http.StatusOK, gin.H{"success_message": u.AuditAddFilter})
+
+	// audit log
+	audit := audit.Audit{
+		User:   userdata.ID,
+		Ib:     m.Ib,
+		Type:   audit.ModLog,
+		IP:     c.ClientIP(),
+		Action: u.AuditAddFilter,
+		Info:   filterInfo(m),
+	}
+
+	// submit audit, undelivered entries are kept in the outbox
+	err = u.SubmitAudit(audit)
+	if err != nil {
+		c.Error(err).SetMeta("AddFilterController.SubmitAudit")
+	}
+
+}
+
+// UpdateFilterController will change a word filter
+func UpdateFilterController(c *gin.Context) {
+	var err error
+	var ff filterForm
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	// get userdata from user middleware
+	userdata := c.MustGet("userdata").(user.User)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("UpdateFilterController.protected")
+		return
+	}
+
+	err = c.ShouldBindJSON(&ff)
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInvalidParam))
+		c.Error(err).SetMeta("UpdateFilterController.ShouldBindJSON")
+		return
+	}
+
+	// Initialize model struct
+	m := &models.FilterModel{
+		Ib:     params[0],
+		User:   userdata.ID,
+		Filter: models.Filter{ID: params[1]},
+	}
+
+	// Check the record id
+	err = m.Status()
+	if err == e.ErrNotFound {
+		c.JSON(e.ErrorMessage(e.ErrNotFound))
+		c.Error(err).SetMeta("UpdateFilterController.Status")
+		return
+	} else if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("UpdateFilterController.Status")
+		return
+	}
+
+	ff.set(m)
+
+	// Validate input parameters
+	err = m.ValidateInput()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error_message": err.Error()})
+		c.Error(err).SetMeta("UpdateFilterController.ValidateInput")
+		return
+	}
+
+	err = m.Update()
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("UpdateFilterController.Update")
+		return
+	}
+
+	// response message
+	c.JSON(http.StatusOK, gin.H{"success_message": u.AuditUpdateFilter})
+
+	// audit log
+	audit := audit.Audit{
+		User:   userdata.ID,
+		Ib:     m.Ib,
+		Type:   audit.ModLog,
+		IP:     c.ClientIP(),
+		Action: u.AuditUpdateFilter,
+		Info:   filterInfo(m),
+	}
+
+	// submit audit, undelivered entries are kept in the outbox
+	err = u.SubmitAudit(audit)
+	if err != nil {
+		c.Error(err).SetMeta("UpdateFilterController.SubmitAudit")
+	}
+
+}
+
+// DeleteFilterController will remove a word filter
+func DeleteFilterController(c *gin.Context) {
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	// get userdata from user middleware
+	userdata := c.MustGet("userdata").(user.User)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("DeleteFilterController.protected")
+		return
+	}
+
+	// Initialize model struct
+	m := &models.FilterModel{
+		Ib:     params[0],
+		User:   userdata.ID,
+		Filter: models.Filter{ID: params[1]},
+	}
+
+	// Check the record id and get the filter for the audit log
+	err := m.Status()
+	if err == e.ErrNotFound {
+		c.JSON(e.ErrorMessage(e.ErrNotFound))
+		c.Error(err).SetMeta("DeleteFilterController.Status")
+		return
+	} else if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("DeleteFilterController.Status")
+		return
+	}
+
+	err = m.Delete()
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("DeleteFilterController.Delete")
+		return
+	}
+
+	// response message
+	c.JSON(http.StatusOK, gin.H{"success_message": u.AuditDeleteFilter})
+
+	// audit log
+	audit := audit.Audit{
+		User:   userdata.ID,
+		Ib:     m.Ib,
+		Type:   audit.ModLog,
+		IP:     c.ClientIP(),
+		Action: u.AuditDeleteFilter,
+		Info:   filterInfo(m),
+	}
+
+	// submit audit, undelivered entries are kept in the outbox
+	err = u.SubmitAudit(audit)
+	if err != nil {
+		c.Error(err).SetMeta("DeleteFilterController.SubmitAudit")
+	}
+
+}
+
+// FilterTestController will run sample text through the enabled filters of a board
+func FilterTestController(c *gin.Context) {
+	var err error
+	var ftf filterTestForm
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("FilterTestController.protected")
+		return
+	}
+
+	err = c.ShouldBindJSON(&ftf)
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInvalidParam))
+		c.Error(err).SetMeta("FilterTestController.ShouldBindJSON")
+		return
+	}
+
+	// Initialize model struct
+	m := &models.FilterTestModel{
+		Ib:   params[0],
+		Text: ftf.Text,
+	}
+
+	// Validate input parameters
+	err = m.ValidateInput()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error_message": err.Error()})
+		c.Error(err).SetMeta("FilterTestController.ValidateInput")
+		return
+	}
+
+	// Get the model which outputs JSON
+	err = m.Get()
+	if err == e.ErrNotFound {
+		c.JSON(e.ErrorMessage(e.ErrNotFound))
+		c.Error(err).SetMeta("FilterTestController.Get")
+		return
+	} else if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("FilterTestController.Get")
+		return
+	}
+
+	// Marshal the structs into JSON
+	output, err := json.Marshal(m.Result)
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("FilterTestController.Marshal")
+		return
+	}
+
+	c.Data(200, "application/json", output)
+
+}
@@ -0,0 +1,189 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/config"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+
+	"github.com/eirka/eirka-admin/models"
+	u "github.com/eirka/eirka-admin/utils"
+)
+
+var testFilterColumns = []string{"filter_id", "filter_pattern", "filter_mode", "filter_action", "filter_replacement", "filter_enabled"}
+
+func TestFiltersController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.GET("/filters", FiltersController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT filter_id, filter_pattern`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(testFilterColumns).AddRow(1, "spam", "literal", "block", "", true))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/filters")
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.JSONEq(t, `{"filters":[{"filter_id":1,"filter_pattern":"spam","filter_mode":"literal","filter_action":"block","filter_replacement":"","filter_enabled":true}]}`,
+		response.Body.String(), "Response should list the filters")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestAddFilterController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.POST("/filters", AddFilterController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectExec(`INSERT INTO filters`).
+		WithArgs(1, 2, "sp[a4]m", models.FilterRegex, models.FilterReplace, "ham", true).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/filters", []byte(`{"pattern":"sp[a4]m","mode":"regex","action":"replace","replacement":"ham"}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.JSONEq(t, successMessage(u.AuditAddFilter), response.Body.String(), "Response should match expected success message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestAddFilterControllerBadRegex(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.POST("/filters", AddFilterController)
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/filters", []byte(`{"pattern":"(spam","mode":"regex","action":"block"}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusBadRequest, response.Code, "HTTP status code should be 400")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(models.ErrFilterPattern), response.Body.String(), "Response should match expected error message")
+}
+
+func TestUpdateFilterController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 3}))
+	router.POST("/filter", UpdateFilterController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT filter_pattern, filter_mode, filter_action, filter_replacement, filter_enabled`).
+		WithArgs(1, 3).
+		WillReturnRows(sqlmock.NewRows(testFilterColumns[1:]).AddRow("spam", "literal", "block", "", true))
+
+	mock.ExpectExec(`UPDATE filters SET`).
+		WithArgs(2, "spam", models.FilterLiteral, models.FilterFlag, "", false, 1, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/filter", []byte(`{"pattern":"spam","action":"flag","enabled":false}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.JSONEq(t, successMessage(u.AuditUpdateFilter), response.Body.String(), "Response should match expected success message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestDeleteFilterControllerNotFound(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 3}))
+	router.DELETE("/filter", DeleteFilterController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT filter_pattern, filter_mode, filter_action, filter_replacement, filter_enabled`).
+		WithArgs(1, 3).
+		WillReturnRows(sqlmock.NewRows(testFilterColumns[1:]))
+
+	// Perform the request
+	response := performRequest(router, "DELETE", "/filter")
+
+	// Check response code
+	assert.Equal(t, http.StatusNotFound, response.Code, "HTTP status code should be 404")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrNotFound), response.Body.String(), "Response should match expected error message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestFilterTestController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.POST("/filters/test", FilterTestController)
+
+	config.Settings.Limits.CommentMaxLength = 1000
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT filter_id, filter_pattern`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(testFilterColumns).AddRow(1, "buy now", "literal", "block", "", true))
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/filters/test", []byte(`{"text":"Buy Now!"}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.JSONEq(t, `{"text":"Buy Now!","blocked":true,"flagged":false,"matches":[{"filter_id":1,"filter_action":"block","count":1}]}`,
+		response.Body.String(), "Response should have the filter result")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
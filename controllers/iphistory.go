@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	e "github.com/eirka/eirka-libs/errors"
+
+	"github.com/eirka/eirka-admin/models"
+)
+
+// IPHistoryController will get the posts on every board from the ip of a post
+func IPHistoryController(c *gin.Context) {
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("IPHistoryController.protected")
+		return
+	}
+
+	// the ip is looked up from the post like a ban
+	post := &models.BanIPModel{
+		Ib:     params[0],
+		Thread: params[1],
+		ID:     params[2],
+	}
+
+	err := post.Status()
+	if err == e.ErrNotFound {
+		c.JSON(e.ErrorMessage(e.ErrNotFound))
+		c.Error(err).SetMeta("IPHistoryController.Status")
+		return
+	} else if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("IPHistoryController.Status")
+		return
+	}
+
+	// Initialize model struct
+	m := &models.IPHistoryModel{
+		IP:   post.IP,
+		Page: params[3],
+	}
+
+	ipHistory(c, m)
+
+}
+
+// SiteIPHistoryController will get the posts on every board from an ip
+func SiteIPHistoryController(c *gin.Context) {
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("SiteIPHistoryController.protected")
+		return
+	}
+
+	// Initialize model struct
+	m := &models.IPHistoryModel{
+		IP:   c.Query("ip"),
+		Page: params[0],
+	}
+
+	// Validate input parameters
+	err := m.ValidateInput()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error_message": err.Error()})
+		c.Error(err).SetMeta("SiteIPHistoryController.ValidateInput")
+		return
+	}
+
+	ipHistory(c, m)
+
+}
+
+// ipHistory writes the history of the ip
+func ipHistory(c *gin.Context, m *models.IPHistoryModel) {
+
+	// Get the model which outputs JSON
+	err := m.Get()
+	if err == e.ErrNotFound {
+		c.JSON(e.ErrorMessage(e.ErrNotFound))
+		c.Error(err).SetMeta("IPHistoryController.Get")
+		return
+	} else if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("IPHistoryController.Get")
+		return
+	}
+
+	// Marshal the structs into JSON
+	output, err := json.Marshal(m.Result)
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("IPHistoryController.json.Marshal")
+		return
+	}
+
+	c.Data(200, "application/json", output)
+
+}
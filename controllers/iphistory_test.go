@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/config"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+)
+
+func TestIPHistoryController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 2, 5, 1}))
+	router.GET("/ip", IPHistoryController)
+
+	config.Settings.Limits.PostsPerPage = 10
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT post_ip FROM threads`).
+		WithArgs(1, 2, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"post_ip"}).AddRow("10.0.0.1"))
+
+	mock.ExpectQuery(`SELECT COUNT\(post_id\) FROM posts WHERE post_ip = \?`).
+		WithArgs("10.0.0.1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	mock.ExpectQuery(`SELECT ib_id, threads.thread_id, thread_title, post_num, post_time, post_deleted, image_hash`).
+		WithArgs("10.0.0.1", 0, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id", "thread_id", "thread_title", "post_num", "post_time", "post_deleted", "image_hash"}).
+			AddRow(1, 2, "Test Thread", 5, nil, false, nil))
+
+	mock.ExpectQuery(`SELECT ib_id, banned_ips.user_id, user_name, ban_reason FROM banned_ips`).
+		WithArgs("10.0.0.1").
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id", "user_id", "user_name", "ban_reason"}))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/ip")
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.Contains(t, response.Body.String(), `"bans":[]`, "Response should have no bans")
+	assert.Contains(t, response.Body.String(), `"thread_title":"Test Thread"`, "Response should have the post")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestIPHistoryControllerPostNotFound(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 2, 5, 1}))
+	router.GET("/ip", IPHistoryController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT post_ip FROM threads`).
+		WithArgs(1, 2, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"post_ip"}))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/ip")
+
+	// Check response code
+	assert.Equal(t, http.StatusNotFound, response.Code, "HTTP status code should be 404")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrNotFound), response.Body.String(), "Response should match expected error message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestSiteIPHistoryControllerBadIP(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.GET("/ip", SiteIPHistoryController)
+
+	// Perform the request
+	response := performRequest(router, "GET", "/ip?ip=not-an-ip")
+
+	// Check response code
+	assert.Equal(t, http.StatusBadRequest, response.Code, "HTTP status code should be 400")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrIPParse), response.Body.String(), "Response should match expected error message")
+}
+
+func TestSiteIPHistoryController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.GET("/ip", SiteIPHistoryController)
+
+	config.Settings.Limits.PostsPerPage = 10
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT COUNT\(post_id\) FROM posts WHERE post_ip = \?`).
+		WithArgs("10.0.0.1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	mock.ExpectQuery(`SELECT ib_id, threads.thread_id, thread_title, post_num, post_time, post_deleted, image_hash`).
+		WithArgs("10.0.0.1", 0, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id", "thread_id", "thread_title", "post_num", "post_time", "post_deleted", "image_hash"}))
+
+	mock.ExpectQuery(`SELECT ib_id, banned_ips.user_id, user_name, ban_reason FROM banned_ips`).
+		WithArgs("10.0.0.1").
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id", "user_id", "user_name", "ban_reason"}))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/ip?ip=10.0.0.1")
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
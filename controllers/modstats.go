@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+
+	e "github.com/eirka/eirka-libs/errors"
+
+	"github.com/eirka/eirka-admin/models"
+)
+
+// ModeratorStatsController will get the moderation activity of a board
+func ModeratorStatsController(c *gin.Context) {
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("ModeratorStatsController.protected")
+		return
+	}
+
+	// Initialize model struct
+	m := &models.ModeratorStatsModel{
+		Ib:    params[0],
+		Range: c.DefaultQuery("range", "30d"),
+	}
+
+	if !m.IsValid() {
+		c.JSON(e.ErrorMessage(e.ErrInvalidParam))
+		c.Error(e.ErrInvalidParam).SetMeta("ModeratorStatsController.IsValid")
+		return
+	}
+
+	// Get the model which outputs JSON
+	err := m.Get()
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("ModeratorStatsController.Get")
+		return
+	}
+
+	// Marshal the structs into JSON
+	output, err := json.Marshal(m.Result)
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("ModeratorStatsController.json.Marshal")
+		return
+	}
+
+	c.Data(200, "application/json", output)
+
+}
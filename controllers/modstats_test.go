@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-admin/models"
+	"github.com/eirka/eirka-libs/audit"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+)
+
+func TestModeratorStatsController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.GET("/moderators", ModeratorStatsController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT audit.user_id, user_name, audit_action`).
+		WithArgs(sqlmock.AnyArg(), 1, audit.ModLog, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "user_name", "audit_action", "bucket", "actions"}).
+			AddRow(2, "alice", audit.AuditDeletePost, 29, 3))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/moderators")
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	var result models.ModeratorStatsType
+	err = json.Unmarshal(response.Body.Bytes(), &result)
+	assert.NoError(t, err, "Response should be valid JSON")
+
+	assert.Equal(t, uint(3), result.Total, "Total should match")
+	assert.Equal(t, 30, len(result.Labels), "Should default to 30 days")
+	if assert.Equal(t, 1, len(result.Series), "Should have one series") {
+		assert.Equal(t, uint(3), result.Series[0].Data[29], "Today should match")
+	}
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestModeratorStatsControllerInvalidRange(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.GET("/moderators", ModeratorStatsController)
+
+	// Perform the request
+	response := performRequest(router, "GET", "/moderators?range=2y")
+
+	// Check response code
+	assert.Equal(t, http.StatusBadRequest, response.Code, "HTTP status code should be 400")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrInvalidParam), response.Body.String(), "Response should match expected error message")
+}
+
+func TestModeratorStatsControllerNotProtected(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockNonAdminMiddleware([]uint{1}))
+	router.GET("/moderators", ModeratorStatsController)
+
+	// Perform the request
+	response := performRequest(router, "GET", "/moderators")
+
+	// Check response code
+	assert.Equal(t, http.StatusInternalServerError, response.Code, "HTTP status code should be 500")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrInternalError), response.Body.String(), "Response should match expected error message")
+}
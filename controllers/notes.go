@@ -0,0 +1,346 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eirka/eirka-libs/audit"
+	e "github.com/eirka/eirka-libs/errors"
+	"github.com/eirka/eirka-libs/user"
+
+	"github.com/eirka/eirka-admin/models"
+	u "github.com/eirka/eirka-admin/utils"
+)
+
+// note input
+type noteForm struct {
+	Text string `json:"text" binding:"required"`
+}
+
+// noteTarget maps the route params to the target of the kind, ips are
+// addressed by thread and post, users by id and threads by id
+func noteTarget(kind string, params []uint) models.NoteTarget {
+
+	target := models.NoteTarget{
+		Ib:   params[0],
+		Type: kind,
+	}
+
+	switch kind {
+	case models.NoteIP:
+		target.Thread = params[1]
+		target.ID = params[2]
+	case models.NoteUser:
+		target.ID = params[1]
+	case models.NoteThread:
+		target.Thread = params[1]
+	}
+
+	return target
+
+}
+
+// noteInfo is the mod log description of a note, ips are left out
+func noteInfo(m *models.NoteModel) string {
+	if m.Type == models.NoteIP {
+		return fmt.Sprintf("ip note %d", m.ID)
+	}
+	return fmt.Sprintf("%s %s note %d", m.Type, m.Target, m.ID)
+}
+
+// NotesController returns the handler that lists the notes on a target of the kind
+func NotesController(kind string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Get parameters from validate middleware
+		params := c.MustGet("params").([]uint)
+
+		if !c.MustGet("protected").(bool) {
+			c.JSON(e.ErrorMessage(e.ErrInternalError))
+			c.Error(e.ErrInternalError).SetMeta("NotesController.protected")
+			return
+		}
+
+		// Initialize model struct
+		m := &models.NotesModel{
+			NoteTarget: noteTarget(kind, params),
+		}
+
+		// Check the target exists
+		err := m.Status()
+		if err == e.ErrNotFound {
+			c.JSON(e.ErrorMessage(e.ErrNotFound))
+			c.Error(err).SetMeta("NotesController.Status")
+			return
+		} else if err != nil {
+			c.JSON(e.ErrorMessage(e.ErrInternalError))
+			c.Error(err).SetMeta("NotesController.Status")
+			return
+		}
+
+		// Get the model which outputs JSON
+		err = m.Get()
+		if err == e.ErrNotFound {
+			c.JSON(e.ErrorMessage(e.ErrNotFound))
+			c.Error(err).SetMeta("NotesController.Get")
+			return
+		} else if err != nil {
+			c.JSON(e.ErrorMessage(e.ErrInternalError))
+			c.Error(err).SetMeta("NotesController.Get")
+			return
+		}
+
+		// Marshal the structs into JSON
+		output, err := json.Marshal(m.Result)
+		if err != nil {
+			c.JSON(e.ErrorMessage(e.ErrInternalError))
+			c.Error(err).SetMeta("NotesController.Marshal")
+			return
+		}
+
+		c.Data(200, "application/json", output)
+
+	}
+}
+
+// AddNoteController returns the handler that adds a note to a target of the kind
+func AddNoteController(kind string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		var nf noteForm
+
+		// Get parameters from validate middleware
+		params := c.MustGet("params").([]uint)
+
+		// get userdata from user middleware
+		userdata := c.MustGet("userdata").(user.User)
+
+		if !c.MustGet("protected").(bool) {
+			c.JSON(e.ErrorMessage(e.ErrInternalError))
+			c.Error(e.ErrInternalError).SetMeta("AddNoteController.protected")
+			return
+		}
+
+		err = c.ShouldBindJSON(&nf)
+		if err != nil {
+			c.JSON(e.ErrorMessage(e.ErrInvalidParam))
+			c.Error(err).SetMeta("AddNoteController.ShouldBindJSON")
+			return
+		}
+
+		target := noteTarget(kind, params)
+
+		// Check the target exists
+		err = target.Status()
+		if err == e.ErrNotFound {
+			c.JSON(e.ErrorMessage(e.ErrNotFound))
+			c.Error(err).SetMeta("AddNoteController.Status")
+			return
+		} else if err != nil {
+			c.JSON(e.ErrorMessage(e.ErrInternalError))
+			c.Error(err).SetMeta("AddNoteController.Status")
+			return
+		}
+
+		// Initialize model struct
+		m := &models.NoteModel{
+			Ib:     target.Ib,
+			User:   userdata.ID,
+			Type:   target.Type,
+			Target: target.Target,
+			Text:   nf.Text,
+		}
+
+		// Validate input parameters
+		err = m.ValidateInput()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error_message": err.Error()})
+			c.Error(err).SetMeta("AddNoteController.ValidateInput")
+			return
+		}
+
+		err = m.Post()
+		if err != nil {
+			c.JSON(e.ErrorMessage(e.ErrInternalError))
+			c.Error(err).SetMeta("AddNoteController.Post")
+			return
+		}
+
+		// response message
+		c.JSON(http.StatusOK, gin.H{"success_message": u.AuditAddNote})
+
+		// audit log
+		audit := audit.Audit{
+			User:   userdata.ID,
+			Ib:     m.Ib,
+			Type:   audit.ModLog,
+			IP:     c.ClientIP(),
+			Action: u.AuditAddNote,
+			Info:   noteInfo(m),
+		}
+
+		// submit audit, undelivered entries are kept in the outbox
+		err = u.SubmitAudit(audit)
+		if err != nil {
+			c.Error(err).SetMeta("AddNoteController.SubmitAudit")
+		}
+
+	}
+}
+
+// UpdateNoteController will change the text of a note, only its author can
+func UpdateNoteController(c *gin.Context) {
+	var err error
+	var nf noteForm
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	// get userdata from user middleware
+	userdata := c.MustGet("userdata").(user.User)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("UpdateNoteController.protected")
+		return
+	}
+
+	err = c.ShouldBindJSON(&nf)
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInvalidParam))
+		c.Error(err).SetMeta("UpdateNoteController.ShouldBindJSON")
+		return
+	}
+
+	// Initialize model struct
+	m := &models.NoteModel{
+		Ib:   params[0],
+		ID:   params[1],
+		User: userdata.ID,
+		Text: nf.Text,
+	}
+
+	// Check the record id and get further info
+	err = m.Status()
+	if err == e.ErrNotFound {
+		c.JSON(e.ErrorMessage(e.ErrNotFound))
+		c.Error(err).SetMeta("UpdateNoteController.Status")
+		return
+	} else if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("UpdateNoteController.Status")
+		return
+	}
+
+	if m.Author != userdata.ID {
+		c.JSON(e.ErrorMessage(e.ErrForbidden))
+		c.Error(e.ErrForbidden).SetMeta("UpdateNoteController.Author")
+		return
+	}
+
+	// Validate input parameters
+	err = m.ValidateInput()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error_message": err.Error()})
+		c.Error(err).SetMeta("UpdateNoteController.ValidateInput")
+		return
+	}
+
+	err = m.Update()
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("UpdateNoteController.Update")
+		return
+	}
+
+	// response message
+	c.JSON(http.StatusOK, gin.H{"success_message": u.AuditUpdateNote})
+
+	// audit log
+	audit := audit.Audit{
+		User:   userdata.ID,
+		Ib:     m.Ib,
+		Type:   audit.ModLog,
+		IP:     c.ClientIP(),
+		Action: u.AuditUpdateNote,
+		Info:   noteInfo(m),
+	}
+
+	// submit audit, undelivered entries are kept in the outbox
+	err = u.SubmitAudit(audit)
+	if err != nil {
+		c.Error(err).SetMeta("UpdateNoteController.SubmitAudit")
+	}
+
+}
+
+// DeleteNoteController will remove a note, only its author can
+func DeleteNoteController(c *gin.Context) {
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	// get userdata from user middleware
+	userdata := c.MustGet("userdata").(user.User)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("DeleteNoteController.protected")
+		return
+	}
+
+	// Initialize model struct
+	m := &models.NoteModel{
+		Ib:   params[0],
+		ID:   params[1],
+		User: userdata.ID,
+	}
+
+	// Check the record id and get further info
+	err := m.Status()
+	if err == e.ErrNotFound {
+		c.JSON(e.ErrorMessage(e.ErrNotFound))
+		c.Error(err).SetMeta("DeleteNoteController.Status")
+		return
+	} else if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("DeleteNoteController.Status")
+		return
+	}
+
+	if m.Author != userdata.ID {
+		c.JSON(e.ErrorMessage(e.ErrForbidden))
+		c.Error(e.ErrForbidden).SetMeta("DeleteNoteController.Author")
+		return
+	}
+
+	err = m.Delete()
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("DeleteNoteController.Delete")
+		return
+	}
+
+	// response message
+	c.JSON(http.StatusOK, gin.H{"success_message": u.AuditDeleteNote})
+
+	// audit log
+	audit := audit.Audit{
+		User:   userdata.ID,
+		Ib:     m.Ib,
+		Type:   audit.ModLog,
+		IP:     c.ClientIP(),
+		Action: u.AuditDeleteNote,
+		Info:   noteInfo(m),
+	}
+
+	// submit audit, undelivered entries are kept in the outbox
+	err = u.SubmitAudit(audit)
+	if err != nil {
+		c.Error(err).SetMeta("DeleteNoteController.SubmitAudit")
+	}
+
+}
@@ -0,0 +1,240 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/config"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+
+	"github.com/eirka/eirka-admin/models"
+	u "github.com/eirka/eirka-admin/utils"
+)
+
+// the note status query
+const testNoteStatusQuery = `SELECT user_id, note_type, note_target FROM notes`
+
+func TestNotesController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 2, 5}))
+	router.GET("/notes", NotesController(models.NoteIP))
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT post_ip FROM threads`).
+		WithArgs(1, 2, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"post_ip"}).AddRow("10.0.0.1"))
+
+	mock.ExpectQuery(`SELECT note_id, notes.user_id, user_name, note_text, note_time, note_updated`).
+		WithArgs(1, models.NoteIP, "10.0.0.1").
+		WillReturnRows(sqlmock.NewRows([]string{"note_id", "user_id", "user_name", "note_text", "note_time", "note_updated"}).
+			AddRow(3, 2, "mod", "warned twice", nil, nil))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/notes")
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// the ip is never sent
+	assert.NotContains(t, response.Body.String(), "10.0.0.1", "Response should not contain the ip")
+	assert.Contains(t, response.Body.String(), "warned twice", "Response should contain the note")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestNotesControllerNotFound(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 2}))
+	router.GET("/notes", NotesController(models.NoteThread))
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT thread_id FROM threads`).
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"thread_id"}))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/notes")
+
+	// Check response code
+	assert.Equal(t, http.StatusNotFound, response.Code, "HTTP status code should be 404")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrNotFound), response.Body.String(), "Response should match expected error message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestAddNoteController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 7}))
+	router.POST("/notes", AddNoteController(models.NoteUser))
+
+	config.Settings.Limits.CommentMinLength = 3
+	config.Settings.Limits.CommentMaxLength = 1000
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT user_id FROM users`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(7))
+
+	mock.ExpectExec(`INSERT INTO notes`).
+		WithArgs(1, 2, models.NoteUser, "7", "warned twice, next is a ban").
+		WillReturnResult(sqlmock.NewResult(3, 1))
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/notes", []byte(`{"text":"warned twice, next is a ban"}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.JSONEq(t, successMessage(u.AuditAddNote), response.Body.String(), "Response should match expected success message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestAddNoteControllerBadInput(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 2}))
+	router.POST("/notes", AddNoteController(models.NoteThread))
+
+	// Perform the request without text
+	response := performJSONRequest(router, "POST", "/notes", []byte(`{}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusBadRequest, response.Code, "HTTP status code should be 400")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrInvalidParam), response.Body.String(), "Response should match expected error message")
+}
+
+func TestUpdateNoteController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 3}))
+	router.POST("/note", UpdateNoteController)
+
+	config.Settings.Limits.CommentMinLength = 3
+	config.Settings.Limits.CommentMaxLength = 1000
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(testNoteStatusQuery).
+		WithArgs(1, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "note_type", "note_target"}).AddRow(2, models.NoteThread, "4"))
+
+	mock.ExpectExec(`UPDATE notes SET note_text`).
+		WithArgs("banned now", 1, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/note", []byte(`{"text":"banned now"}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.JSONEq(t, successMessage(u.AuditUpdateNote), response.Body.String(), "Response should match expected success message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestUpdateNoteControllerNotAuthor(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 3}))
+	router.POST("/note", UpdateNoteController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(testNoteStatusQuery).
+		WithArgs(1, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "note_type", "note_target"}).AddRow(5, models.NoteThread, "4"))
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/note", []byte(`{"text":"banned now"}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusForbidden, response.Code, "HTTP status code should be 403")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrForbidden), response.Body.String(), "Response should match expected error message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestDeleteNoteController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 3}))
+	router.DELETE("/note", DeleteNoteController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(testNoteStatusQuery).
+		WithArgs(1, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "note_type", "note_target"}).AddRow(2, models.NoteIP, "10.0.0.1"))
+
+	mock.ExpectExec(`DELETE FROM notes`).
+		WithArgs(1, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// Perform the request
+	response := performRequest(router, "DELETE", "/note")
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.JSONEq(t, successMessage(u.AuditDeleteNote), response.Body.String(), "Response should match expected success message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestNoteInfo(t *testing.T) {
+	assert.Equal(t, "ip note 3", noteInfo(&models.NoteModel{ID: 3, Type: models.NoteIP, Target: "10.0.0.1"}), "Ip should be left out")
+	assert.Equal(t, "thread 4 note 3", noteInfo(&models.NoteModel{ID: 3, Type: models.NoteThread, Target: "4"}), "Info should match")
+}
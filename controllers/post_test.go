@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+)
+
+func TestPostController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 2, 5}))
+	router.GET("/post", PostController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT posts.post_id, threads.thread_id, thread_title, post_num`).
+		WithArgs(1, 2, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "thread_id", "thread_title", "post_num", "post_time", "post_deleted",
+			"user_id", "user_name", "post_ip", "image_hash", "ip_banned", "image_banned"}).
+			AddRow(40, 2, "Test Thread", 5, nil, true, 1, "Anonymous", "10.0.0.1", nil, false, false))
+
+	mock.ExpectQuery(`SELECT COUNT\(post_id\) FROM threads`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	mock.ExpectQuery(`SELECT COUNT\(note_id\) FROM threads`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	mock.ExpectQuery(`SELECT audit.user_id, user_name, audit_time, audit_action, audit_info FROM audit`).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "user_name", "audit_time", "audit_action", "audit_info"}))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/post")
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.JSONEq(t, `{"post":{"thread_id":2,"thread_title":"Test Thread","post_num":5,"post_time":null,"post_deleted":true,
+		"user_id":1,"user_name":"Anonymous","post_ip":"10.0.0.1","ip_banned":false,"ip_posts":0,"image_hash":null,
+		"image_banned":false,"note_count":1,"history":[]}}`, response.Body.String(), "Response should match")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestPostControllerNotFound(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 2, 5}))
+	router.GET("/post", PostController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT posts.post_id, threads.thread_id, thread_title, post_num`).
+		WithArgs(1, 2, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id"}))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/post")
+
+	// Check response code
+	assert.Equal(t, http.StatusNotFound, response.Code, "HTTP status code should be 404")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrNotFound), response.Body.String(), "Response should match expected error message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
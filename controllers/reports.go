@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	e "github.com/eirka/eirka-libs/errors"
+	"github.com/eirka/eirka-libs/user"
+
+	"github.com/eirka/eirka-admin/models"
+)
+
+// ReportsController will get the posts with open reports
+func ReportsController(c *gin.Context) {
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("ReportsController.protected")
+		return
+	}
+
+	// Initialize model struct
+	m := &models.ReportsModel{
+		Ib:   params[0],
+		Page: params[1],
+	}
+
+	// Get the model which outputs JSON
+	err := m.Get()
+	if err == e.ErrNotFound {
+		c.JSON(e.ErrorMessage(e.ErrNotFound))
+		c.Error(err).SetMeta("ReportsController.Get")
+		return
+	} else if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("ReportsController.Get")
+		return
+	}
+
+	// Marshal the structs into JSON
+	output, err := json.Marshal(m.Result)
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("ReportsController.json.Marshal")
+		return
+	}
+
+	c.Data(200, "application/json", output)
+
+}
+
+// report input
+type reportForm struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// AddReportController will let any user report a post to the moderators,
+// anonymous users are known by their ip
+func AddReportController(c *gin.Context) {
+	var err error
+	var rf reportForm
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	// get userdata from user middleware
+	userdata := c.MustGet("userdata").(user.User)
+
+	err = c.ShouldBindJSON(&rf)
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInvalidParam))
+		c.Error(err).SetMeta("AddReportController.ShouldBindJSON")
+		return
+	}
+
+	// Initialize model struct
+	m := &models.AddReportModel{
+		Ib:     params[0],
+		Thread: params[1],
+		ID:     params[2],
+		User:   userdata.ID,
+		IP:     c.ClientIP(),
+		Reason: rf.Reason,
+	}
+
+	// Validate input parameters
+	err = m.ValidateInput()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error_message": err.Error()})
+		c.Error(err).SetMeta("AddReportController.ValidateInput")
+		return
+	}
+
+	// Check the record id and get further info
+	err = m.Status()
+	if err == e.ErrNotFound {
+		c.JSON(e.ErrorMessage(e.ErrNotFound))
+		c.Error(err).SetMeta("AddReportController.Status")
+		return
+	} else if err == models.ErrReportBanned {
+		c.JSON(e.ErrorMessage(e.ErrForbidden))
+		c.Error(err).SetMeta("AddReportController.Status")
+		return
+	} else if err == models.ErrReportDuplicate {
+		c.JSON(http.StatusBadRequest, gin.H{"error_message": err.Error()})
+		c.Error(err).SetMeta("AddReportController.Status")
+		return
+	} else if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("AddReportController.Status")
+		return
+	}
+
+	err = m.Post()
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("AddReportController.Post")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success_message": "Post Reported"})
+
+}
@@ -0,0 +1,296 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/config"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+)
+
+func TestReportsController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 1}))
+	router.GET("/reports", ReportsController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	config.Settings.Limits.PostsPerPage = 10
+
+	reported := time.Date(2024, 3, 10, 15, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT COUNT\(DISTINCT reports.post_id\) FROM reports`).
+		WithArgs(1, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	mock.ExpectQuery(`SELECT threads.thread_id, thread_title, post_num`).
+		WithArgs(1, 0, 0, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"thread_id", "thread_title", "post_num", "report_count", "reasons", "first", "last"}).
+			AddRow(2, "Test Thread", 5, 2, "spam", reported, reported))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/reports")
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.JSONEq(t, `{"reports":{"total":1,"limit":0,"per_page":10,"pages":1,"current_page":1,"items":[
+		{"thread_id":2,"thread_title":"Test Thread","post_num":5,"report_count":2,"report_reasons":["spam"],
+		"report_first":"2024-03-10T15:00:00Z","report_last":"2024-03-10T15:00:00Z"}]}}`, response.Body.String(), "Response should match")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestReportsControllerError(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 1}))
+	router.GET("/reports", ReportsController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT COUNT\(DISTINCT reports.post_id\) FROM reports`).
+		WillReturnError(errors.New("database error"))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/reports")
+
+	// Check response code
+	assert.Equal(t, http.StatusInternalServerError, response.Code, "HTTP status code should be 500")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrInternalError), response.Body.String(), "Response should match expected error message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestReportsControllerNotProtected(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockNonAdminMiddleware([]uint{1, 1}))
+	router.GET("/reports", ReportsController)
+
+	// Perform the request
+	response := performRequest(router, "GET", "/reports")
+
+	// Check response code
+	assert.Equal(t, http.StatusInternalServerError, response.Code, "HTTP status code should be 500")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrInternalError), response.Body.String(), "Response should match expected error message")
+}
+
+func TestAddReportController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("params", []uint{1, 2, 3})
+		c.Next()
+	})
+	router.Use(mockUserMiddleware(5))
+	router.POST("/report", AddReportController)
+
+	config.Settings.Limits.CommentMinLength = 3
+	config.Settings.Limits.CommentMaxLength = 100
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM banned_users`).
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"banned"}).AddRow(false))
+
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM banned_ips`).
+		WithArgs(1, "127.0.0.1").
+		WillReturnRows(sqlmock.NewRows([]string{"banned"}).AddRow(false))
+
+	mock.ExpectQuery(`SELECT post_id FROM threads`).
+		WithArgs(1, 2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id"}).AddRow(9))
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM reports`).
+		WithArgs(9, "127.0.0.1", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	mock.ExpectExec(`INSERT INTO reports`).
+		WithArgs(9, "spam", "127.0.0.1", 0).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/report", []byte(`{"reason":"spam"}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.JSONEq(t, successMessage("Post Reported"), response.Body.String(), "Response should match expected success message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestAddReportControllerDuplicate(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("params", []uint{1, 2, 3})
+		c.Next()
+	})
+	router.Use(mockUserMiddleware(1))
+	router.POST("/report", AddReportController)
+
+	config.Settings.Limits.CommentMinLength = 3
+	config.Settings.Limits.CommentMaxLength = 100
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM banned_ips`).
+		WithArgs(1, "127.0.0.1").
+		WillReturnRows(sqlmock.NewRows([]string{"banned"}).AddRow(false))
+
+	mock.ExpectQuery(`SELECT post_id FROM threads`).
+		WithArgs(1, 2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id"}).AddRow(9))
+
+	// the ip already has an open report on the post
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM reports`).
+		WithArgs(9, "127.0.0.1", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/report", []byte(`{"reason":"spam"}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusBadRequest, response.Code, "HTTP status code should be 400")
+
+	// Check response body
+	assert.JSONEq(t, `{"error_message":"post already reported"}`, response.Body.String(), "Response should match expected error message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestAddReportControllerNotFound(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("params", []uint{1, 2, 3})
+		c.Next()
+	})
+	router.Use(mockUserMiddleware(1))
+	router.POST("/report", AddReportController)
+
+	config.Settings.Limits.CommentMinLength = 3
+	config.Settings.Limits.CommentMaxLength = 100
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM banned_ips`).
+		WithArgs(1, "127.0.0.1").
+		WillReturnRows(sqlmock.NewRows([]string{"banned"}).AddRow(false))
+
+	mock.ExpectQuery(`SELECT post_id FROM threads`).
+		WithArgs(1, 2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id"}))
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/report", []byte(`{"reason":"spam"}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusNotFound, response.Code, "HTTP status code should be 404")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrNotFound), response.Body.String(), "Response should match expected error message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestAddReportControllerNoReason(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("params", []uint{1, 2, 3})
+		c.Next()
+	})
+	router.Use(mockUserMiddleware(1))
+	router.POST("/report", AddReportController)
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/report", []byte(`{}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusBadRequest, response.Code, "HTTP status code should be 400")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrInvalidParam), response.Body.String(), "Response should match expected error message")
+}
+
+func TestAddReportControllerBanned(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("params", []uint{1, 2, 3})
+		c.Next()
+	})
+	router.Use(mockUserMiddleware(5))
+	router.POST("/report", AddReportController)
+
+	config.Settings.Limits.CommentMinLength = 3
+	config.Settings.Limits.CommentMaxLength = 100
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	// the account is banned on the board
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM banned_users`).
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"banned"}).AddRow(true))
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/report", []byte(`{"reason":"spam"}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusForbidden, response.Code, "HTTP status code should be 403")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrForbidden), response.Body.String(), "Response should match expected error message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
@@ -9,6 +9,8 @@ import (
 	"github.com/eirka/eirka-libs/audit"
 	e "github.com/eirka/eirka-libs/errors"
 	"github.com/eirka/eirka-libs/user"
+
+	u "github.com/eirka/eirka-admin/utils"
 )
 
 // reset password input
@@ -69,10 +71,10 @@ func ResetPasswordController(c *gin.Context) {
 		Info:   fmt.Sprintf("%d", rpf.UID),
 	}
 
-	// submit audit
-	err = audit.Submit()
+	// submit audit, undelivered entries are kept in the outbox
+	err = u.SubmitAudit(audit)
 	if err != nil {
-		c.Error(err).SetMeta("ResetPasswordController.audit.Submit")
+		c.Error(err).SetMeta("ResetPasswordController.SubmitAudit")
 	}
 
 }
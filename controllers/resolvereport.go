@@ -0,0 +1,300 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eirka/eirka-libs/audit"
+	e "github.com/eirka/eirka-libs/errors"
+	"github.com/eirka/eirka-libs/user"
+
+	"github.com/eirka/eirka-admin/models"
+	u "github.com/eirka/eirka-admin/utils"
+)
+
+// resolve report input, the post can be deleted and its ip banned in the same call
+type resolveReportForm struct {
+	Delete bool   `json:"delete"`
+	Ban    bool   `json:"ban"`
+	Reason string `json:"reason"`
+	Rule   uint   `json:"rule_id"`
+}
+
+// ResolveReportController will close the open reports of a post as handled
+func ResolveReportController(c *gin.Context) {
+	var err error
+	var rrf resolveReportForm
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	// get userdata from user middleware
+	userdata := c.MustGet("userdata").(user.User)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("ResolveReportController.protected")
+		return
+	}
+
+	// the form is optional
+	if c.Request.ContentLength != 0 {
+		err = c.ShouldBindJSON(&rrf)
+		if err != nil {
+			c.JSON(e.ErrorMessage(e.ErrInvalidParam))
+			c.Error(err).SetMeta("ResolveReportController.ShouldBindJSON")
+			return
+		}
+	}
+
+	// a ban needs a rule or a reason
+	if rrf.Ban && rrf.Rule == 0 && rrf.Reason == "" {
+		c.JSON(e.ErrorMessage(e.ErrInvalidParam))
+		c.Error(e.ErrInvalidParam).SetMeta("ResolveReportController.Reason")
+		return
+	}
+
+	// Initialize model struct
+	m := &models.ReportModel{
+		Ib:     params[0],
+		Thread: params[1],
+		ID:     params[2],
+		User:   userdata.ID,
+	}
+
+	// Check the post has open reports
+	err = m.Status()
+	if err == e.ErrNotFound {
+		c.JSON(e.ErrorMessage(e.ErrNotFound))
+		c.Error(err).SetMeta("ResolveReportController.Status")
+		return
+	} else if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("ResolveReportController.Status")
+		return
+	}
+
+	// the rule of the ban is checked before anything is done
+	var rule models.Rule
+
+	if rrf.Ban && rrf.Rule != 0 {
+		rule, err = banRule(m.Ib, rrf.Rule)
+		if err == models.ErrRuleNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error_message": err.Error()})
+			c.Error(err).SetMeta("ResolveReportController.banRule")
+			return
+		} else if err != nil {
+			c.JSON(e.ErrorMessage(e.ErrInternalError))
+			c.Error(err).SetMeta("ResolveReportController.banRule")
+			return
+		}
+	}
+
+	// the ban is checked before anything is done too
+	var bm *models.BanIPModel
+
+	if rrf.Ban {
+		bm = &models.BanIPModel{
+			Ib:     m.Ib,
+			Thread: m.Thread,
+			ID:     m.ID,
+			User:   userdata.ID,
+			Reason: rrf.Reason,
+		}
+
+		// the rule sets the reason and how long the ban lasts
+		if rule.ID != 0 {
+			bm.Rule = rule.ID
+			bm.Reason = rule.Reason(rrf.Reason)
+			bm.Expires = rule.Expires(time.Now())
+		}
+
+		err = bm.ValidateInput()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error_message": err.Error()})
+			c.Error(err).SetMeta("ResolveReportController.BanIPModel.ValidateInput")
+			return
+		}
+	}
+
+	if rrf.Delete {
+		dm := &models.DeletePostModel{
+			Ib:     m.Ib,
+			Thread: m.Thread,
+			ID:     m.ID,
+		}
+
+		err = dm.Status()
+		if err == e.ErrNotFound {
+			c.JSON(e.ErrorMessage(e.ErrNotFound))
+			c.Error(err).SetMeta("ResolveReportController.DeletePostModel.Status")
+			return
+		} else if err != nil {
+			c.JSON(e.ErrorMessage(e.ErrInternalError))
+			c.Error(err).SetMeta("ResolveReportController.DeletePostModel.Status")
+			return
+		}
+
+		// delete toggles so an already deleted post is left alone
+		if !dm.Deleted {
+			err = dm.Delete()
+			if err != nil {
+				c.JSON(e.ErrorMessage(e.ErrInternalError))
+				c.Error(err).SetMeta("ResolveReportController.DeletePostModel.Delete")
+				return
+			}
+
+			// audit the delete as soon as it is done so a later failure does not lose it
+			err = u.SubmitPostAudit(audit.Audit{
+				User:   userdata.ID,
+				Ib:     dm.Ib,
+				Type:   audit.ModLog,
+				IP:     c.ClientIP(),
+				Action: audit.AuditDeletePost,
+				Info:   fmt.Sprintf("%s/%d", dm.Name, dm.ID),
+			}, dm.Thread, dm.ID)
+			if err != nil {
+				c.Error(err).SetMeta("ResolveReportController.DeletePostModel.SubmitAudit")
+			}
+
+			err = deletePostCache(dm.Ib, dm.Thread)
+			if err != nil {
+				c.JSON(e.ErrorMessage(e.ErrInternalError))
+				c.Error(err).SetMeta("ResolveReportController.redis.Cache.Delete")
+				return
+			}
+		}
+	}
+
+	if rrf.Ban {
+		err = bm.Status()
+		if err == e.ErrNotFound {
+			c.JSON(e.ErrorMessage(e.ErrNotFound))
+			c.Error(err).SetMeta("ResolveReportController.BanIPModel.Status")
+			return
+		} else if err != nil {
+			c.JSON(e.ErrorMessage(e.ErrInternalError))
+			c.Error(err).SetMeta("ResolveReportController.BanIPModel.Status")
+			return
+		}
+
+		err = bm.Post()
+		if err != nil {
+			c.JSON(e.ErrorMessage(e.ErrInternalError))
+			c.Error(err).SetMeta("ResolveReportController.BanIPModel.Post")
+			return
+		}
+
+		// ban the ip in cloudflare, temporary bans are only kept here
+		if bm.Expires == nil {
+			go u.CloudFlareBanIP(bm.IP, bm.Reason)
+		}
+
+		// audit the ban as soon as it is done so a later failure does not lose it
+		err = u.SubmitPostAudit(audit.Audit{
+			User:   userdata.ID,
+			Ib:     bm.Ib,
+			Type:   audit.ModLog,
+			IP:     c.ClientIP(),
+			Action: audit.AuditBanIP,
+			Info:   bm.Reason,
+		}, bm.Thread, bm.ID)
+		if err != nil {
+			c.Error(err).SetMeta("ResolveReportController.BanIPModel.SubmitAudit")
+		}
+	}
+
+	// close the reports last so they stay open if an action failed
+	err = m.Resolve()
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("ResolveReportController.Resolve")
+		return
+	}
+
+	// response message
+	c.JSON(http.StatusOK, gin.H{"success_message": u.AuditResolveReport})
+
+	// audit log
+	audit := audit.Audit{
+		User:   userdata.ID,
+		Ib:     m.Ib,
+		Type:   audit.ModLog,
+		IP:     c.ClientIP(),
+		Action: u.AuditResolveReport,
+		Info:   fmt.Sprintf("%s/%d", m.Name, m.ID),
+	}
+
+	// submit audit, undelivered entries are kept in the outbox
+	err = u.SubmitPostAudit(audit, m.Thread, m.ID)
+	if err != nil {
+		c.Error(err).SetMeta("ResolveReportController.SubmitAudit")
+	}
+
+}
+
+// DismissReportController will close the open reports of a post without action
+func DismissReportController(c *gin.Context) {
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	// get userdata from user middleware
+	userdata := c.MustGet("userdata").(user.User)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("DismissReportController.protected")
+		return
+	}
+
+	// Initialize model struct
+	m := &models.ReportModel{
+		Ib:     params[0],
+		Thread: params[1],
+		ID:     params[2],
+		User:   userdata.ID,
+	}
+
+	// Check the post has open reports
+	err := m.Status()
+	if err == e.ErrNotFound {
+		c.JSON(e.ErrorMessage(e.ErrNotFound))
+		c.Error(err).SetMeta("DismissReportController.Status")
+		return
+	} else if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("DismissReportController.Status")
+		return
+	}
+
+	err = m.Dismiss()
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("DismissReportController.Dismiss")
+		return
+	}
+
+	// response message
+	c.JSON(http.StatusOK, gin.H{"success_message": u.AuditDismissReport})
+
+	// audit log
+	audit := audit.Audit{
+		User:   userdata.ID,
+		Ib:     m.Ib,
+		Type:   audit.ModLog,
+		IP:     c.ClientIP(),
+		Action: u.AuditDismissReport,
+		Info:   fmt.Sprintf("%s/%d", m.Name, m.ID),
+	}
+
+	// submit audit, undelivered entries are kept in the outbox
+	err = u.SubmitPostAudit(audit, m.Thread, m.ID)
+	if err != nil {
+		c.Error(err).SetMeta("DismissReportController.SubmitAudit")
+	}
+
+}
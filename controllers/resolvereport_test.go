@@ -0,0 +1,320 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+	"github.com/eirka/eirka-libs/redis"
+
+	u "github.com/eirka/eirka-admin/utils"
+)
+
+// the open report status query
+const testReportStatusQuery = `SELECT thread_title, COUNT\(report_id\) FROM threads`
+
+// the report close query
+const testReportCloseQuery = `UPDATE reports\s+INNER JOIN posts`
+
+func TestResolveReportController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 2, 5}))
+	router.POST("/resolve", ResolveReportController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(testReportStatusQuery).
+		WithArgs(1, 2, 5, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"thread_title", "count"}).AddRow("Test Thread", 2))
+
+	mock.ExpectExec(testReportCloseQuery).
+		WithArgs(1, 2, 1, 2, 5, 0).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	// Perform the request without a form
+	response := performRequest(router, "POST", "/resolve")
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.JSONEq(t, successMessage(u.AuditResolveReport), response.Body.String(), "Response should match expected success message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestResolveReportControllerDeleteAndBan(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 2, 5}))
+	router.POST("/resolve", ResolveReportController)
+
+	// Set up fake Redis connection
+	redis.NewRedisMock()
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(testReportStatusQuery).
+		WithArgs(1, 2, 5, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"thread_title", "count"}).AddRow("Test Thread", 2))
+
+	// the post is deleted with the delete post model
+	mock.ExpectQuery(`SELECT thread_title, post_deleted FROM threads`).
+		WithArgs(2, 1, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"thread_title", "post_deleted"}).AddRow("Test Thread", false))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM posts`).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectPrepare(`UPDATE posts SET post_deleted = \?`).
+		ExpectExec().
+		WithArgs(true, 2, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	redis.Cache.Mock.Command("DEL", "index:1", "directory:1", "thread:1:2", "post:1:2", "tags:1", "image:1", "new:1", "popular:1", "favorited:1")
+
+	// the ip is banned with the ban ip model
+	mock.ExpectQuery(`SELECT post_ip FROM threads`).
+		WithArgs(1, 2, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"post_ip"}).AddRow("10.0.0.1"))
+	mock.ExpectExec(`INSERT INTO banned_ips`).
+		WithArgs(2, 1, "10.0.0.1", "spam", nil, 0).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec(testReportCloseQuery).
+		WithArgs(1, 2, 1, 2, 5, 0).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/resolve", []byte(`{"delete":true,"ban":true,"reason":"spam"}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.JSONEq(t, successMessage(u.AuditResolveReport), response.Body.String(), "Response should match expected success message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestResolveReportControllerDeleteAuditedOnFailure(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 2, 5}))
+	router.POST("/resolve", ResolveReportController)
+
+	// Set up fake Redis connection, the cache delete is not mocked so it fails
+	redis.NewRedisMock()
+	outbox := redis.Cache.Mock.GenericCommand("RPUSH").Expect(int64(1))
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(testReportStatusQuery).
+		WithArgs(1, 2, 5, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"thread_title", "count"}).AddRow("Test Thread", 2))
+
+	mock.ExpectQuery(`SELECT thread_title, post_deleted FROM threads`).
+		WithArgs(2, 1, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"thread_title", "post_deleted"}).AddRow("Test Thread", false))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM posts`).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectPrepare(`UPDATE posts SET post_deleted = \?`).
+		ExpectExec().
+		WithArgs(true, 2, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// the audit log is down so the delete entry goes to the outbox
+	mock.ExpectBegin().WillReturnError(errors.New("audit down"))
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/resolve", []byte(`{"delete":true}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusInternalServerError, response.Code, "HTTP status code should be 500")
+
+	// the committed delete is still audited
+	assert.Equal(t, 1, redis.Cache.Mock.Stats(outbox), "Delete should be kept in the outbox")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestResolveReportControllerDeleteNotFound(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 2, 5}))
+	router.POST("/resolve", ResolveReportController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(testReportStatusQuery).
+		WithArgs(1, 2, 5, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"thread_title", "count"}).AddRow("Test Thread", 2))
+
+	mock.ExpectQuery(`SELECT thread_title, post_deleted FROM threads`).
+		WithArgs(2, 1, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"thread_title", "post_deleted"}))
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/resolve", []byte(`{"delete":true}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusNotFound, response.Code, "HTTP status code should be 404")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrNotFound), response.Body.String(), "Response should match expected error message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestResolveReportControllerBanWithoutReason(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 2, 5}))
+	router.POST("/resolve", ResolveReportController)
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/resolve", []byte(`{"ban":true}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusBadRequest, response.Code, "HTTP status code should be 400")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrInvalidParam), response.Body.String(), "Response should match expected error message")
+}
+
+func TestResolveReportControllerNotFound(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 2, 5}))
+	router.POST("/resolve", ResolveReportController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(testReportStatusQuery).
+		WithArgs(1, 2, 5, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"thread_title", "count"}))
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/resolve", []byte(`{"delete":true}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusNotFound, response.Code, "HTTP status code should be 404")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrNotFound), response.Body.String(), "Response should match expected error message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestResolveReportControllerNotProtected(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockNonAdminMiddleware([]uint{1, 2, 5}))
+	router.POST("/resolve", ResolveReportController)
+
+	// Perform the request
+	response := performRequest(router, "POST", "/resolve")
+
+	// Check response code
+	assert.Equal(t, http.StatusInternalServerError, response.Code, "HTTP status code should be 500")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrInternalError), response.Body.String(), "Response should match expected error message")
+}
+
+func TestDismissReportController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 2, 5}))
+	router.POST("/dismiss", DismissReportController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(testReportStatusQuery).
+		WithArgs(1, 2, 5, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"thread_title", "count"}).AddRow("Test Thread", 2))
+
+	mock.ExpectExec(testReportCloseQuery).
+		WithArgs(2, 2, 1, 2, 5, 0).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	// Perform the request
+	response := performRequest(router, "POST", "/dismiss")
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.JSONEq(t, successMessage(u.AuditDismissReport), response.Body.String(), "Response should match expected success message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestDismissReportControllerNotFound(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 2, 5}))
+	router.POST("/dismiss", DismissReportController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(testReportStatusQuery).
+		WithArgs(1, 2, 5, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"thread_title", "count"}))
+
+	// Perform the request
+	response := performRequest(router, "POST", "/dismiss")
+
+	// Check response code
+	assert.Equal(t, http.StatusNotFound, response.Code, "HTTP status code should be 404")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
@@ -0,0 +1,347 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eirka/eirka-libs/audit"
+	e "github.com/eirka/eirka-libs/errors"
+	"github.com/eirka/eirka-libs/user"
+
+	"github.com/eirka/eirka-admin/models"
+	u "github.com/eirka/eirka-admin/utils"
+)
+
+// rule input, the duration is in days and zero is permanent
+type ruleForm struct {
+	Title    string `json:"title" binding:"required"`
+	Duration uint   `json:"duration"`
+	Message  string `json:"message" binding:"required"`
+}
+
+// set copies the form into the rule
+func (f ruleForm) set(m *models.RuleModel) {
+	m.Title = f.Title
+	m.Duration = f.Duration
+	m.Message = f.Message
+}
+
+// ruleInfo is the audit info of a rule
+func ruleInfo(m *models.RuleModel) string {
+	return fmt.Sprintf("rule %d: %s", m.ID, m.Title)
+}
+
+// banRule loads the rule of the board a ban is made for
+func banRule(ib, id uint) (rule models.Rule, err error) {
+
+	m := &models.RuleModel{
+		Ib:   ib,
+		Rule: models.Rule{ID: id},
+	}
+
+	err = m.Status()
+	if err == e.ErrNotFound {
+		return rule, models.ErrRuleNotFound
+	} else if err != nil {
+		return
+	}
+
+	return m.Rule, nil
+
+}
+
+// RulesController will list the rules of a board
+func RulesController(c *gin.Context) {
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("RulesController.protected")
+		return
+	}
+
+	// Initialize model struct
+	m := &models.RulesModel{
+		Ib: params[0],
+	}
+
+	// Get the model which outputs JSON
+	err := m.Get()
+	if err == e.ErrNotFound {
+		c.JSON(e.ErrorMessage(e.ErrNotFound))
+		c.Error(err).SetMeta("RulesController.Get")
+		return
+	} else if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("RulesController.Get")
+		return
+	}
+
+	// Marshal the structs into JSON
+	output, err := json.Marshal(m.Result)
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("RulesController.Marshal")
+		return
+	}
+
+	c.Data(200, "application/json", output)
+
+}
+
+// AddRuleController will add a rule to a board
+func AddRuleController(c *gin.Context) {
+	var err error
+	var rf ruleForm
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	// get userdata from user middleware
+	userdata := c.MustGet("userdata").(user.User)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("AddRuleController.protected")
+		return
+	}
+
+	err = c.ShouldBindJSON(&rf)
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInvalidParam))
+		c.Error(err).SetMeta("AddRuleController.ShouldBindJSON")
+		return
+	}
+
+	// Initialize model struct
+	m := &models.RuleModel{
+		Ib:   params[0],
+		User: userdata.ID,
+	}
+
+	rf.set(m)
+
+	// Validate input parameters
+	err = m.ValidateInput()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error_message": err.Error()})
+		c.Error(err).SetMeta("AddRuleController.ValidateInput")
+		return
+	}
+
+	err = m.Post()
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("AddRuleController.Post")
+		return
+	}
+
+	// response message
+	c.JSON(http.StatusOK, gin.H{"success_message": u.AuditAddRule})
+
+	// audit log
+	audit := audit.Audit{
+		User:   userdata.ID,
+		Ib:     m.Ib,
+		Type:   audit.ModLog,
+		IP:     c.ClientIP(),
+		Action: u.AuditAddRule,
+		Info:   ruleInfo(m),
+	}
+
+	// submit audit, undelivered entries are kept in the outbox
+	err = u.SubmitAudit(audit)
+	if err != nil {
+		c.Error(err).SetMeta("AddRuleController.SubmitAudit")
+	}
+
+}
+
+// UpdateRuleController will change a rule
+func UpdateRuleController(c *gin.Context) {
+	var err error
+	var rf ruleForm
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	// get userdata from user middleware
+	userdata := c.MustGet("userdata").(user.User)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("UpdateRuleController.protected")
+		return
+	}
+
+	err = c.ShouldBindJSON(&rf)
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInvalidParam))
+		c.Error(err).SetMeta("UpdateRuleController.ShouldBindJSON")
+		return
+	}
+
+	// Initialize model struct
+	m := &models.RuleModel{
+		Ib:   params[0],
+		User: userdata.ID,
+		Rule: models.Rule{ID: params[1]},
+	}
+
+	// Check the record id
+	err = m.Status()
+	if err == e.ErrNotFound {
+		c.JSON(e.ErrorMessage(e.ErrNotFound))
+		c.Error(err).SetMeta("UpdateRuleController.Status")
+		return
+	} else if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("UpdateRuleController.Status")
+		return
+	}
+
+	rf.set(m)
+
+	// Validate input parameters
+	err = m.ValidateInput()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error_message": err.Error()})
+		c.Error(err).SetMeta("UpdateRuleController.ValidateInput")
+		return
+	}
+
+	err = m.Update()
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("UpdateRuleController.Update")
+		return
+	}
+
+	// response message
+	c.JSON(http.StatusOK, gin.H{"success_message": u.AuditUpdateRule})
+
+	// audit log
+	audit := audit.Audit{
+		User:   userdata.ID,
+		Ib:     m.Ib,
+		Type:   audit.ModLog,
+		IP:     c.ClientIP(),
+		Action: u.AuditUpdateRule,
+		Info:   ruleInfo(m),
+	}
+
+	// submit audit, undelivered entries are kept in the outbox
+	err = u.SubmitAudit(audit)
+	if err != nil {
+		c.Error(err).SetMeta("UpdateRuleController.SubmitAudit")
+	}
+
+}
+
+// DeleteRuleController will remove a rule
+func DeleteRuleController(c *gin.Context) {
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	// get userdata from user middleware
+	userdata := c.MustGet("userdata").(user.User)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("DeleteRuleController.protected")
+		return
+	}
+
+	// Initialize model struct
+	m := &models.RuleModel{
+		Ib:   params[0],
+		User: userdata.ID,
+		Rule: models.Rule{ID: params[1]},
+	}
+
+	// Check the record id and get the rule for the audit log
+	err := m.Status()
+	if err == e.ErrNotFound {
+		c.JSON(e.ErrorMessage(e.ErrNotFound))
+		c.Error(err).SetMeta("DeleteRuleController.Status")
+		return
+	} else if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("DeleteRuleController.Status")
+		return
+	}
+
+	err = m.Delete()
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("DeleteRuleController.Delete")
+		return
+	}
+
+	// response message
+	c.JSON(http.StatusOK, gin.H{"success_message": u.AuditDeleteRule})
+
+	// audit log
+	audit := audit.Audit{
+		User:   userdata.ID,
+		Ib:     m.Ib,
+		Type:   audit.ModLog,
+		IP:     c.ClientIP(),
+		Action: u.AuditDeleteRule,
+		Info:   ruleInfo(m),
+	}
+
+	// submit audit, undelivered entries are kept in the outbox
+	err = u.SubmitAudit(audit)
+	if err != nil {
+		c.Error(err).SetMeta("DeleteRuleController.SubmitAudit")
+	}
+
+}
+
+// BanStatsController will count the bans of a board by rule
+func BanStatsController(c *gin.Context) {
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("BanStatsController.protected")
+		return
+	}
+
+	// Initialize model struct
+	m := &models.BanStatsModel{
+		Ib: params[0],
+	}
+
+	// Get the model which outputs JSON
+	err := m.Get()
+	if err == e.ErrNotFound {
+		c.JSON(e.ErrorMessage(e.ErrNotFound))
+		c.Error(err).SetMeta("BanStatsController.Get")
+		return
+	} else if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("BanStatsController.Get")
+		return
+	}
+
+	// Marshal the structs into JSON
+	output, err := json.Marshal(m.Result)
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("BanStatsController.Marshal")
+		return
+	}
+
+	c.Data(200, "application/json", output)
+
+}
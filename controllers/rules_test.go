@@ -0,0 +1,261 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/audit"
+	"github.com/eirka/eirka-libs/config"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+
+	"github.com/eirka/eirka-admin/models"
+	u "github.com/eirka/eirka-admin/utils"
+)
+
+var testRuleColumns = []string{"rule_id", "rule_title", "rule_duration", "rule_message"}
+
+func TestRulesController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.GET("/rules", RulesController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT rule_id, rule_title, rule_duration, rule_message`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(testRuleColumns).AddRow(1, "Spam", 7, "Rule 1: no spam"))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/rules")
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.JSONEq(t, `{"rules":[{"rule_id":1,"rule_title":"Spam","rule_duration":7,"rule_message":"Rule 1: no spam"}]}`,
+		response.Body.String(), "Response should list the rules")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestAddRuleController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.POST("/rules", AddRuleController)
+
+	config.Settings.Limits.TitleMinLength = 3
+	config.Settings.Limits.TitleMaxLength = 40
+	config.Settings.Limits.CommentMinLength = 3
+	config.Settings.Limits.CommentMaxLength = 100
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectExec(`INSERT INTO rules`).
+		WithArgs(1, 2, "Spam", 7, "Rule 1: no spam").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/rules", []byte(`{"title":"Spam","duration":7,"message":"Rule 1: no spam"}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.JSONEq(t, successMessage(u.AuditAddRule), response.Body.String(), "Response should match expected success message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestAddRuleControllerBadInput(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.POST("/rules", AddRuleController)
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/rules", []byte(`{"title":"Spam"}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusBadRequest, response.Code, "HTTP status code should be 400")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrInvalidParam), response.Body.String(), "Response should match expected error message")
+}
+
+func TestDeleteRuleControllerNotFound(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 4}))
+	router.DELETE("/rule", DeleteRuleController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT rule_id, rule_title, rule_duration, rule_message`).
+		WithArgs(1, 4).
+		WillReturnRows(sqlmock.NewRows(testRuleColumns))
+
+	// Perform the request
+	response := performRequest(router, "DELETE", "/rule")
+
+	// Check response code
+	assert.Equal(t, http.StatusNotFound, response.Code, "HTTP status code should be 404")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrNotFound), response.Body.String(), "Response should match expected error message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestRulesControllerNotProtected(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockNonAdminMiddleware([]uint{1}))
+	router.GET("/rules", RulesController)
+
+	// Perform the request
+	response := performRequest(router, "GET", "/rules")
+
+	// Check response code
+	assert.Equal(t, http.StatusInternalServerError, response.Code, "HTTP status code should be 500")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrInternalError), response.Body.String(), "Response should match expected error message")
+}
+
+func TestBanStatsController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.GET("/bans", BanStatsController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT bans.rule_id`).
+		WithArgs(1, 1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"rule_id", "rule_title", "ip_bans", "file_bans", "user_bans", "total"}).
+			AddRow(1, "Spam", 5, 2, 1, 8))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/bans")
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.JSONEq(t, `{"total":8,"rules":[{"rule_id":1,"rule_title":"Spam","ip_bans":5,"file_bans":2,"user_bans":1,"total":8}]}`,
+		response.Body.String(), "Response should group the bans by rule")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestBanIPControllerRule(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 1, 1}))
+	router.POST("/banip", BanIPController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT rule_id, rule_title, rule_duration, rule_message`).
+		WithArgs(1, 4).
+		WillReturnRows(sqlmock.NewRows(testRuleColumns).AddRow(4, "Spam", 7, "Rule 1: no spam"))
+
+	mock.ExpectQuery(`SELECT post_ip FROM threads`).
+		WithArgs(1, 1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"post_ip"}).AddRow("10.0.0.1"))
+
+	mock.ExpectExec(`INSERT INTO banned_ips`).
+		WithArgs(2, 1, "10.0.0.1", "Rule 1: no spam - link spam", sqlmock.AnyArg(), 4).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/banip", []byte(`{"rule_id":4,"reason":"link spam"}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.JSONEq(t, successMessage(audit.AuditBanIP), response.Body.String(), "Response should match expected success message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestBanFileControllerUnknownRule(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 1, 1}))
+	router.POST("/banfile", BanFileController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT rule_id, rule_title, rule_duration, rule_message`).
+		WithArgs(1, 9).
+		WillReturnRows(sqlmock.NewRows(testRuleColumns))
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/banfile", []byte(`{"rule_id":9}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusBadRequest, response.Code, "HTTP status code should be 400")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(models.ErrRuleNotFound), response.Body.String(), "Response should match expected error message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestBanUserControllerNoReason(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 2, 3}))
+	router.POST("/banuser", BanUserController(false))
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/banuser", []byte(`{}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusBadRequest, response.Code, "HTTP status code should be 400")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrInvalidParam), response.Body.String(), "Response should match expected error message")
+}
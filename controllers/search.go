@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	e "github.com/eirka/eirka-libs/errors"
+
+	"github.com/eirka/eirka-admin/models"
+)
+
+// search input, times are RFC3339 and deleted is left out to match both states
+type searchForm struct {
+	Query   string    `json:"q" form:"q"`
+	Mode    string    `json:"mode" form:"mode"`
+	Start   time.Time `json:"start" form:"start" time_format:"2006-01-02T15:04:05Z07:00"`
+	End     time.Time `json:"end" form:"end" time_format:"2006-01-02T15:04:05Z07:00"`
+	IP      string    `json:"ip" form:"ip"`
+	Deleted *bool     `json:"deleted" form:"deleted"`
+}
+
+// filter returns the search filter of the form on the board
+func (sf searchForm) filter(ib uint) models.SearchFilter {
+	return models.SearchFilter{
+		Ib:      ib,
+		Query:   sf.Query,
+		Mode:    sf.Mode,
+		Start:   sf.Start,
+		End:     sf.End,
+		IP:      sf.IP,
+		Deleted: sf.Deleted,
+	}
+}
+
+// SearchController will search the post text and thread titles of a board
+func SearchController(c *gin.Context) {
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("SearchController.protected")
+		return
+	}
+
+	search(c, params[0], params[1])
+
+}
+
+// SiteSearchController will search the post text and thread titles of every board
+func SiteSearchController(c *gin.Context) {
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("SiteSearchController.protected")
+		return
+	}
+
+	search(c, 0, params[0])
+
+}
+
+// search writes the page of posts that match the query, a board of zero is every board
+func search(c *gin.Context, ib, page uint) {
+	var sf searchForm
+
+	err := c.ShouldBindQuery(&sf)
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInvalidParam))
+		c.Error(err).SetMeta("SearchController.ShouldBindQuery")
+		return
+	}
+
+	// Initialize model struct
+	m := &models.SearchModel{
+		SearchFilter: sf.filter(ib),
+		Page:         page,
+	}
+
+	// Validate input parameters
+	err = m.ValidateInput()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error_message": err.Error()})
+		c.Error(err).SetMeta("SearchController.ValidateInput")
+		return
+	}
+
+	// Get the model which outputs JSON
+	err = m.Get()
+	if err == e.ErrNotFound {
+		c.JSON(e.ErrorMessage(e.ErrNotFound))
+		c.Error(err).SetMeta("SearchController.Get")
+		return
+	} else if err == models.ErrSearchRegex {
+		c.JSON(http.StatusBadRequest, gin.H{"error_message": err.Error()})
+		c.Error(err).SetMeta("SearchController.Get")
+		return
+	} else if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("SearchController.Get")
+		return
+	}
+
+	// Marshal the structs into JSON
+	output, err := json.Marshal(m.Result)
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("SearchController.json.Marshal")
+		return
+	}
+
+	c.Data(200, "application/json", output)
+
+}
@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/config"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+)
+
+func TestSearchController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 1}))
+	router.GET("/search", SearchController)
+
+	config.Settings.Limits.PostsPerPage = 10
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	start := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT COUNT\(post_id\) FROM threads`).
+		WithArgs(1, `example\.com`, `example\.com`, start).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	mock.ExpectQuery(`SELECT ib_id, threads.thread_id, thread_title, post_num, post_time, post_deleted, post_ip, post_text`).
+		WithArgs(1, `example\.com`, `example\.com`, start, 0, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id", "thread_id", "thread_title", "post_num", "post_time", "post_deleted", "post_ip", "post_text"}).
+			AddRow(1, 2, "Test Thread", 5, nil, true, "10.0.0.1", "visit example.com"))
+
+	// Perform the request
+	response := performRequest(router, "GET", `/search?q=example%5C.com&mode=regex&start=2024-03-10T00:00:00Z&deleted=true`)
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.Contains(t, response.Body.String(), `"post_text":"visit example.com"`, "Response should have the post")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestSearchControllerBadRegex(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 1}))
+	router.GET("/search", SearchController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	// the pattern is checked by the database
+	mock.ExpectQuery(`SELECT COUNT\(post_id\) FROM threads`).
+		WithArgs(1, "spam(", "spam(").
+		WillReturnError(&mysql.MySQLError{Number: 3691, Message: "Mismatched parenthesis in regular expression."})
+
+	// Perform the request
+	response := performRequest(router, "GET", "/search?q=spam(&mode=regex")
+
+	// Check response code
+	assert.Equal(t, http.StatusBadRequest, response.Code, "HTTP status code should be 400")
+
+	// Check response body
+	assert.JSONEq(t, `{"error_message":"invalid regular expression"}`, response.Body.String(), "Response should match expected error message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestSearchControllerBadTime(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 1}))
+	router.GET("/search", SearchController)
+
+	// Perform the request
+	response := performRequest(router, "GET", "/search?q=spam&start=yesterday")
+
+	// Check response code
+	assert.Equal(t, http.StatusBadRequest, response.Code, "HTTP status code should be 400")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrInvalidParam), response.Body.String(), "Response should match expected error message")
+}
+
+func TestSiteSearchController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.GET("/search", SiteSearchController)
+
+	config.Settings.Limits.PostsPerPage = 10
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT COUNT\(post_id\) FROM threads`).
+		WithArgs("%spam%", "%spam%").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	mock.ExpectQuery(`SELECT ib_id, threads.thread_id, thread_title, post_num, post_time, post_deleted, post_ip, post_text`).
+		WithArgs("%spam%", "%spam%", 0, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id", "thread_id", "thread_title", "post_num", "post_time", "post_deleted", "post_ip", "post_text"}))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/search?q=spam")
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+
+	e "github.com/eirka/eirka-libs/errors"
+	"github.com/eirka/eirka-libs/redis"
+
+	"github.com/eirka/eirka-admin/models"
+)
+
+// the cache key of the site statistics
+const siteStatisticsKey = "stats:site"
+
+// SiteStatisticsController will get the stats of every board and the site totals
+func SiteStatisticsController(c *gin.Context) {
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("SiteStatisticsController.protected")
+		return
+	}
+
+	// serve from the cache if the stats were recently generated
+	output, err := redis.Cache.Get(siteStatisticsKey)
+	if err == nil {
+		c.Data(200, "application/json", output)
+		return
+	}
+
+	// Initialize model struct
+	m := &models.SiteStatisticsModel{}
+
+	// Get the model which outputs JSON
+	err = m.Get()
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("SiteStatisticsController.Get")
+		return
+	}
+
+	// Marshal the structs into JSON
+	output, err = json.Marshal(m.Result)
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("SiteStatisticsController.json.Marshal")
+		return
+	}
+
+	// a cache failure only costs the next request a query
+	err = redis.Cache.SetEx(siteStatisticsKey, statisticsCacheTimeout, output)
+	if err != nil {
+		c.Error(err).SetMeta("SiteStatisticsController.redis.Cache.SetEx")
+	}
+
+	c.Data(200, "application/json", output)
+
+}
@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-admin/models"
+	u "github.com/eirka/eirka-admin/utils"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+	"github.com/eirka/eirka-libs/redis"
+)
+
+func TestSiteStatisticsController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockUserMiddleware(2))
+	router.Use(u.SiteProtect())
+	router.GET("/site/statistics", SiteStatisticsController)
+
+	// Set up fake Redis connection
+	redis.NewRedisMock()
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	// the user is a site admin
+	mock.ExpectQuery(`SELECT role_id FROM user_role_map WHERE user_id = \?`).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"role_id"}).AddRow(u.SiteAdminRole))
+
+	// Mock a cache miss and the cache update
+	redis.Cache.Mock.Command("GET", "stats:site").Expect(nil)
+	setex := redis.Cache.Mock.GenericCommand("SETEX").Expect("OK")
+
+	mock.ExpectQuery(`SELECT ib_id, ib_title`).
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id", "ib_title", "thread_count", "post_count", "image_count"}).
+			AddRow(1, "Pictures", 10, 100, 50))
+
+	mock.ExpectQuery(`GROUP BY ib_id WITH ROLLUP`).
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id", "visitors", "hits"}).
+			AddRow(1, 30, 300).
+			AddRow(nil, 30, 300))
+
+	mock.ExpectQuery(`GROUP BY bucket`).
+		WithArgs(sqlmock.AnyArg(), 3600, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"bucket", "visitors", "hits"}))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/site/statistics")
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	var result models.SiteStatisticsType
+	err = json.Unmarshal(response.Body.Bytes(), &result)
+	assert.NoError(t, err, "Response should be valid JSON")
+
+	assert.Equal(t, uint(30), result.Visitors, "Visitors count should match")
+	assert.Equal(t, 1, len(result.Boards), "Should have one board")
+
+	// The result should be cached
+	assert.Equal(t, 1, redis.Cache.Mock.Stats(setex), "Stats should be cached")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestSiteStatisticsControllerNotSiteAdmin(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockUserMiddleware(2))
+	router.Use(u.SiteProtect())
+	router.GET("/site/statistics", SiteStatisticsController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	// the user is only a board moderator
+	mock.ExpectQuery(`SELECT role_id FROM user_role_map WHERE user_id = \?`).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"role_id"}).AddRow(3))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/site/statistics")
+
+	// Check response code
+	assert.Equal(t, http.StatusForbidden, response.Code, "HTTP status code should be 403")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrForbidden), response.Body.String(), "Response should match expected error message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestSiteStatisticsControllerCached(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{}))
+	router.GET("/site/statistics", SiteStatisticsController)
+
+	// Set up fake Redis connection
+	redis.NewRedisMock()
+
+	// Mock a cache hit
+	redis.Cache.Mock.Command("GET", "stats:site").Expect([]byte(`{"visitors":5}`))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/site/statistics")
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.JSONEq(t, `{"visitors":5}`, response.Body.String(), "Response should come from the cache")
+}
@@ -2,14 +2,19 @@ package controllers
 
 import (
 	"encoding/json"
+	"fmt"
 
 	"github.com/gin-gonic/gin"
 
 	e "github.com/eirka/eirka-libs/errors"
+	"github.com/eirka/eirka-libs/redis"
 
 	"github.com/eirka/eirka-admin/models"
 )
 
+// how many seconds board statistics are cached
+const statisticsCacheTimeout = 60
+
 // StatisticsController will get the visitor stats for a board
 func StatisticsController(c *gin.Context) {
 
@@ -24,11 +29,29 @@ func StatisticsController(c *gin.Context) {
 
 	// Initialize model struct
 	m := &models.StatisticsModel{
-		Ib: params[0],
+		Ib:     params[0],
+		Range:  c.DefaultQuery("range", "24h"),
+		Bucket: c.DefaultQuery("bucket", "hour"),
+	}
+
+	// check the range before it is used in a key
+	if !m.IsValid() {
+		c.JSON(e.ErrorMessage(e.ErrInvalidParam))
+		c.Error(e.ErrInvalidParam).SetMeta("StatisticsController.IsValid")
+		return
+	}
+
+	statsKey := fmt.Sprintf("%s:%d:%s:%s", "stats", m.Ib, m.Range, m.Bucket)
+
+	// serve from the cache if the stats were recently generated
+	output, err := redis.Cache.Get(statsKey)
+	if err == nil {
+		c.Data(200, "application/json", output)
+		return
 	}
 
 	// Get the model which outputs JSON
-	err := m.Get()
+	err = m.Get()
 	if err == e.ErrNotFound {
 		c.JSON(e.ErrorMessage(e.ErrNotFound))
 		c.Error(err).SetMeta("StatisticsController.Get")
@@ -40,13 +63,19 @@ func StatisticsController(c *gin.Context) {
 	}
 
 	// Marshal the structs into JSON
-	output, err := json.Marshal(m.Result)
+	output, err = json.Marshal(m.Result)
 	if err != nil {
 		c.JSON(e.ErrorMessage(e.ErrInternalError))
 		c.Error(err).SetMeta("StatisticsController.json.Marshal")
 		return
 	}
 
+	// a cache failure only costs the next request a query
+	err = redis.Cache.SetEx(statsKey, statisticsCacheTimeout, output)
+	if err != nil {
+		c.Error(err).SetMeta("StatisticsController.redis.Cache.SetEx")
+	}
+
 	c.Data(200, "application/json", output)
 
 }
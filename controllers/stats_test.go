@@ -5,7 +5,6 @@ import (
 	"errors"
 	"net/http"
 	"testing"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -14,8 +13,15 @@ import (
 	"github.com/eirka/eirka-admin/models"
 	"github.com/eirka/eirka-libs/db"
 	e "github.com/eirka/eirka-libs/errors"
+	"github.com/eirka/eirka-libs/redis"
 )
 
+// the board stats query
+const testBoardStatsQuery = `SELECT \(SELECT COUNT\(thread_id\).*FROM imageboards WHERE ib_id = \?`
+
+// the grouped visitor stats query
+const testVisitorStatsQuery = `SELECT FLOOR\(TIMESTAMPDIFF\(SECOND, \?, request_time\) / \?\) AS bucket,.*GROUP BY bucket WITH ROLLUP`
+
 func TestStatisticsController(t *testing.T) {
 	// Set up Gin
 	gin.SetMode(gin.ReleaseMode)
@@ -23,34 +29,30 @@ func TestStatisticsController(t *testing.T) {
 	router.Use(mockAdminMiddleware([]uint{1}))
 	router.GET("/stats", StatisticsController)
 
+	// Set up fake Redis connection
+	redis.NewRedisMock()
+
 	// Set up SQL mock
 	mock, err := db.NewTestDb()
 	assert.NoError(t, err, "An error was not expected")
 	defer db.CloseDb()
 
+	// Mock a cache miss and the cache update
+	redis.Cache.Mock.Command("GET", "stats:1:24h:hour").Expect(nil)
+	setex := redis.Cache.Mock.GenericCommand("SETEX").Expect("OK")
+
 	// Mock the board stats query
-	mock.ExpectQuery(`SELECT \(SELECT COUNT\(thread_id\).*FROM imageboards WHERE ib_id = \?`).
+	mock.ExpectQuery(testBoardStatsQuery).
 		WithArgs(1).
 		WillReturnRows(sqlmock.NewRows([]string{"thread_count", "post_count", "image_count"}).
 			AddRow(10, 100, 50))
 
-	// Mock the visitor stats query
-	mock.ExpectQuery(`SELECT COUNT\(DISTINCT request_ip\) as visitors, COUNT\(request_itemkey\) as hits.*WHERE request_time BETWEEN \(CURDATE\(\) - INTERVAL 1 DAY\) AND now\(\) AND ib_id = \?`).
-		WithArgs(1).
-		WillReturnRows(sqlmock.NewRows([]string{"visitors", "hits"}).
-			AddRow(200, 500))
-
-	// Mock the chart data prepare
-	mock.ExpectPrepare(`SELECT \(now\(\) - interval \? hour\) as time.*AND ib_id = \?`)
-
-	// Mock period data (6 periods at every 4 hours)
-	timestamp := time.Now()
-	for hour := 24; hour >= 4; hour -= 4 {
-		mock.ExpectQuery(`SELECT \(now\(\) - interval \? hour\) as time.*AND ib_id = \?`).
-			WithArgs(hour, hour, hour-4, 1).
-			WillReturnRows(sqlmock.NewRows([]string{"time", "visitors", "hits"}).
-				AddRow(timestamp.Add(-time.Hour*time.Duration(hour)), 20, 50))
-	}
+	// Mock the grouped visitor stats
+	mock.ExpectQuery(testVisitorStatsQuery).
+		WithArgs(sqlmock.AnyArg(), 3600, sqlmock.AnyArg(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"bucket", "visitors", "hits"}).
+			AddRow(23, 200, 500).
+			AddRow(nil, 200, 500))
 
 	// Perform the request
 	response := performRequest(router, "GET", "/stats")
@@ -71,84 +73,170 @@ func TestStatisticsController(t *testing.T) {
 	assert.Equal(t, uint(500), result.Hits, "Hits count should match")
 
 	// Check if we have the right number of data points
-	assert.Equal(t, 6, len(result.Labels), "Should have 6 data points")
+	assert.Equal(t, 24, len(result.Labels), "Should have 24 data points")
 	assert.Equal(t, 2, len(result.Series), "Should have 2 series")
 	assert.Equal(t, "Visitors", result.Series[0].Name, "First series should be visitors")
 	assert.Equal(t, "Hits", result.Series[1].Name, "Second series should be hits")
 
+	// The result should be cached
+	assert.Equal(t, 1, redis.Cache.Mock.Stats(setex), "Stats should be cached")
+
 	// Make sure all expectations were met
 	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
 }
 
-func TestStatisticsControllerNotProtected(t *testing.T) {
+func TestStatisticsControllerCached(t *testing.T) {
 	// Set up Gin
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
-	router.Use(mockNonAdminMiddleware([]uint{1}))
+	router.Use(mockAdminMiddleware([]uint{1}))
 	router.GET("/stats", StatisticsController)
 
+	// Set up fake Redis connection
+	redis.NewRedisMock()
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	// Mock a cache hit, no queries should be run
+	redis.Cache.Mock.Command("GET", "stats:1:7d:day").Expect([]byte(`{"visitors":5}`))
+
 	// Perform the request
-	response := performRequest(router, "GET", "/stats")
+	response := performRequest(router, "GET", "/stats?range=7d&bucket=day")
 
 	// Check response code
-	assert.Equal(t, http.StatusInternalServerError, response.Code, "HTTP status code should be 500")
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
 
 	// Check response body
-	assert.JSONEq(t, errorMessage(e.ErrInternalError), response.Body.String(), "Response should match expected error message")
+	assert.JSONEq(t, `{"visitors":5}`, response.Body.String(), "Response should come from the cache")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
 }
 
-func TestStatisticsControllerGetError(t *testing.T) {
+func TestStatisticsControllerDaily(t *testing.T) {
 	// Set up Gin
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
 	router.Use(mockAdminMiddleware([]uint{1}))
 	router.GET("/stats", StatisticsController)
 
+	// Set up fake Redis connection
+	redis.NewRedisMock()
+
 	// Set up SQL mock
 	mock, err := db.NewTestDb()
 	assert.NoError(t, err, "An error was not expected")
 	defer db.CloseDb()
 
-	// Mock the board stats query with error
-	mock.ExpectQuery(`SELECT \(SELECT COUNT\(thread_id\).*FROM imageboards WHERE ib_id = \?`).
+	// Mock a cache miss and the cache update
+	redis.Cache.Mock.Command("GET", "stats:1:7d:day").Expect(nil)
+	redis.Cache.Mock.GenericCommand("SETEX").Expect("OK")
+
+	// Mock the board stats query
+	mock.ExpectQuery(testBoardStatsQuery).
 		WithArgs(1).
-		WillReturnError(errors.New("database error"))
+		WillReturnRows(sqlmock.NewRows([]string{"thread_count", "post_count", "image_count"}).
+			AddRow(10, 100, 50))
+
+	// Mock the grouped visitor stats
+	mock.ExpectQuery(testVisitorStatsQuery).
+		WithArgs(sqlmock.AnyArg(), 86400, sqlmock.AnyArg(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"bucket", "visitors", "hits"}).
+			AddRow(nil, 0, 0))
 
 	// Perform the request
-	response := performRequest(router, "GET", "/stats")
+	response := performRequest(router, "GET", "/stats?range=7d&bucket=day")
 
 	// Check response code
-	assert.Equal(t, http.StatusInternalServerError, response.Code, "HTTP status code should be 500")
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
 
-	// Check response body
-	assert.JSONEq(t, errorMessage(e.ErrInternalError), response.Body.String(), "Response should match expected error message")
+	// Parse response JSON to verify structure
+	var result models.StatisticsType
+	err = json.Unmarshal(response.Body.Bytes(), &result)
+	assert.NoError(t, err, "Response should be valid JSON")
+
+	// Check if we have the right number of data points
+	assert.Equal(t, 7, len(result.Labels), "Should have 7 data points")
 
 	// Make sure all expectations were met
 	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
 }
 
-func TestStatisticsControllerVisitorStatsError(t *testing.T) {
+func TestStatisticsControllerRollup(t *testing.T) {
 	// Set up Gin
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
 	router.Use(mockAdminMiddleware([]uint{1}))
 	router.GET("/stats", StatisticsController)
 
+	// Set up fake Redis connection
+	redis.NewRedisMock()
+
 	// Set up SQL mock
 	mock, err := db.NewTestDb()
 	assert.NoError(t, err, "An error was not expected")
 	defer db.CloseDb()
 
+	// Mock a cache miss and the cache update
+	redis.Cache.Mock.Command("GET", "stats:1:1y:day").Expect(nil)
+	redis.Cache.Mock.GenericCommand("SETEX").Expect("OK")
+
 	// Mock the board stats query
-	mock.ExpectQuery(`SELECT \(SELECT COUNT\(thread_id\).*FROM imageboards WHERE ib_id = \?`).
+	mock.ExpectQuery(testBoardStatsQuery).
 		WithArgs(1).
 		WillReturnRows(sqlmock.NewRows([]string{"thread_count", "post_count", "image_count"}).
 			AddRow(10, 100, 50))
 
-	// Mock the visitor stats query with error
-	mock.ExpectQuery(`SELECT COUNT\(DISTINCT request_ip\) as visitors, COUNT\(request_itemkey\) as hits.*WHERE request_time BETWEEN \(CURDATE\(\) - INTERVAL 1 DAY\) AND now\(\) AND ib_id = \?`).
-		WithArgs(1).
-		WillReturnError(errors.New("database error"))
+	// Mock the daily rollups
+	mock.ExpectQuery(`SELECT DATEDIFF\(rollup_date, \?\) AS bucket, visitors, hits\s+FROM analytics_daily`).
+		WithArgs(sqlmock.AnyArg(), 1, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"bucket", "visitors", "hits"}).
+			AddRow(100, 5, 10))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/stats?range=1y&bucket=day")
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Parse response JSON to verify structure
+	var result models.StatisticsType
+	err = json.Unmarshal(response.Body.Bytes(), &result)
+	assert.NoError(t, err, "Response should be valid JSON")
+
+	assert.Equal(t, 365, len(result.Labels), "Should have 365 data points")
+	assert.Equal(t, uint(10), result.Hits, "Hits count should match")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestStatisticsControllerInvalidRange(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.GET("/stats", StatisticsController)
+
+	// Perform the request
+	response := performRequest(router, "GET", "/stats?range=2y")
+
+	// Check response code
+	assert.Equal(t, http.StatusBadRequest, response.Code, "HTTP status code should be 400")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrInvalidParam), response.Body.String(), "Response should match expected error message")
+}
+
+func TestStatisticsControllerNotProtected(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockNonAdminMiddleware([]uint{1}))
+	router.GET("/stats", StatisticsController)
 
 	// Perform the request
 	response := performRequest(router, "GET", "/stats")
@@ -158,38 +246,30 @@ func TestStatisticsControllerVisitorStatsError(t *testing.T) {
 
 	// Check response body
 	assert.JSONEq(t, errorMessage(e.ErrInternalError), response.Body.String(), "Response should match expected error message")
-
-	// Make sure all expectations were met
-	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
 }
 
-func TestStatisticsControllerPrepareError(t *testing.T) {
+func TestStatisticsControllerGetError(t *testing.T) {
 	// Set up Gin
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
 	router.Use(mockAdminMiddleware([]uint{1}))
 	router.GET("/stats", StatisticsController)
 
+	// Set up fake Redis connection
+	redis.NewRedisMock()
+
 	// Set up SQL mock
 	mock, err := db.NewTestDb()
 	assert.NoError(t, err, "An error was not expected")
 	defer db.CloseDb()
 
-	// Mock the board stats query
-	mock.ExpectQuery(`SELECT \(SELECT COUNT\(thread_id\).*FROM imageboards WHERE ib_id = \?`).
-		WithArgs(1).
-		WillReturnRows(sqlmock.NewRows([]string{"thread_count", "post_count", "image_count"}).
-			AddRow(10, 100, 50))
+	// Mock a cache miss
+	redis.Cache.Mock.Command("GET", "stats:1:24h:hour").Expect(nil)
 
-	// Mock the visitor stats query
-	mock.ExpectQuery(`SELECT COUNT\(DISTINCT request_ip\) as visitors, COUNT\(request_itemkey\) as hits.*WHERE request_time BETWEEN \(CURDATE\(\) - INTERVAL 1 DAY\) AND now\(\) AND ib_id = \?`).
+	// Mock the board stats query with error
+	mock.ExpectQuery(testBoardStatsQuery).
 		WithArgs(1).
-		WillReturnRows(sqlmock.NewRows([]string{"visitors", "hits"}).
-			AddRow(200, 500))
-
-	// Mock the chart data prepare with error
-	mock.ExpectPrepare(`SELECT \(now\(\) - interval \? hour\) as time.*AND ib_id = \?`).
-		WillReturnError(errors.New("prepare error"))
+		WillReturnError(errors.New("database error"))
 
 	// Perform the request
 	response := performRequest(router, "GET", "/stats")
@@ -204,37 +284,34 @@ func TestStatisticsControllerPrepareError(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
 }
 
-func TestStatisticsControllerPeriodDataError(t *testing.T) {
+func TestStatisticsControllerVisitorStatsError(t *testing.T) {
 	// Set up Gin
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
 	router.Use(mockAdminMiddleware([]uint{1}))
 	router.GET("/stats", StatisticsController)
 
+	// Set up fake Redis connection
+	redis.NewRedisMock()
+
 	// Set up SQL mock
 	mock, err := db.NewTestDb()
 	assert.NoError(t, err, "An error was not expected")
 	defer db.CloseDb()
 
+	// Mock a cache miss
+	redis.Cache.Mock.Command("GET", "stats:1:24h:hour").Expect(nil)
+
 	// Mock the board stats query
-	mock.ExpectQuery(`SELECT \(SELECT COUNT\(thread_id\).*FROM imageboards WHERE ib_id = \?`).
+	mock.ExpectQuery(testBoardStatsQuery).
 		WithArgs(1).
 		WillReturnRows(sqlmock.NewRows([]string{"thread_count", "post_count", "image_count"}).
 			AddRow(10, 100, 50))
 
-	// Mock the visitor stats query
-	mock.ExpectQuery(`SELECT COUNT\(DISTINCT request_ip\) as visitors, COUNT\(request_itemkey\) as hits.*WHERE request_time BETWEEN \(CURDATE\(\) - INTERVAL 1 DAY\) AND now\(\) AND ib_id = \?`).
-		WithArgs(1).
-		WillReturnRows(sqlmock.NewRows([]string{"visitors", "hits"}).
-			AddRow(200, 500))
-
-	// Mock the chart data prepare
-	mock.ExpectPrepare(`SELECT \(now\(\) - interval \? hour\) as time.*AND ib_id = \?`)
-
-	// Mock first period data with error
-	mock.ExpectQuery(`SELECT \(now\(\) - interval \? hour\) as time.*AND ib_id = \?`).
-		WithArgs(24, 24, 20, 1).
-		WillReturnError(errors.New("query error"))
+	// Mock the visitor stats query with error
+	mock.ExpectQuery(testVisitorStatsQuery).
+		WithArgs(sqlmock.AnyArg(), 3600, sqlmock.AnyArg(), 1).
+		WillReturnError(errors.New("database error"))
 
 	// Perform the request
 	response := performRequest(router, "GET", "/stats")
@@ -12,6 +12,7 @@ import (
 	"github.com/eirka/eirka-libs/user"
 
 	"github.com/eirka/eirka-admin/models"
+	u "github.com/eirka/eirka-admin/utils"
 )
 
 // StickyThreadController will toggle a threads sticky bool
@@ -89,10 +90,10 @@ func StickyThreadController(c *gin.Context) {
 		Info:   m.Name,
 	}
 
-	// submit audit
-	err = audit.Submit()
+	// submit audit, undelivered entries are kept in the outbox
+	err = u.SubmitPostAudit(audit, m.ID, 0)
 	if err != nil {
-		c.Error(err).SetMeta("StickyThreadController.audit.Submit")
+		c.Error(err).SetMeta("StickyThreadController.SubmitAudit")
 	}
 
 }
@@ -17,6 +17,7 @@ import (
 func performRequest(r http.Handler, method, path string) *httptest.ResponseRecorder {
 	req, _ := http.NewRequest(method, path, nil)
 	req.Header.Set("X-Real-IP", "127.0.0.1")
+	req.RemoteAddr = "127.0.0.1:1234"
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
 	return w
@@ -27,6 +28,7 @@ func performJSONRequest(r http.Handler, method, path string, body []byte) *httpt
 	req, _ := http.NewRequest(method, path, bytes.NewBuffer(body))
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-Real-IP", "127.0.0.1")
+	req.RemoteAddr = "127.0.0.1:1234"
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
 	return w
@@ -61,3 +63,11 @@ func mockNonAdminMiddleware(params []uint) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// Authenticated user without board protection, for routes that check perms themselves
+func mockUserMiddleware(uid uint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("userdata", user.User{ID: uid, IsAuthenticated: true})
+		c.Next()
+	}
+}
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	e "github.com/eirka/eirka-libs/errors"
+	"github.com/eirka/eirka-libs/redis"
+
+	"github.com/eirka/eirka-admin/models"
+)
+
+// TopContentController will get the most requested threads, images and tags of a board
+func TopContentController(c *gin.Context) {
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("TopContentController.protected")
+		return
+	}
+
+	// Initialize model struct
+	m := &models.TopContentModel{
+		Ib:    params[0],
+		Range: c.DefaultQuery("range", "7d"),
+	}
+
+	// check the range before it is used in a key
+	if !m.IsValid() {
+		c.JSON(e.ErrorMessage(e.ErrInvalidParam))
+		c.Error(e.ErrInvalidParam).SetMeta("TopContentController.IsValid")
+		return
+	}
+
+	topKey := fmt.Sprintf("%s:%d:%s", "stats:top", m.Ib, m.Range)
+
+	// serve from the cache if the report was recently generated
+	output, err := redis.Cache.Get(topKey)
+	if err == nil {
+		c.Data(200, "application/json", output)
+		return
+	}
+
+	// Get the model which outputs JSON
+	err = m.Get()
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("TopContentController.Get")
+		return
+	}
+
+	// Marshal the structs into JSON
+	output, err = json.Marshal(m.Result)
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("TopContentController.json.Marshal")
+		return
+	}
+
+	// a cache failure only costs the next request a query
+	err = redis.Cache.SetEx(topKey, statisticsCacheTimeout, output)
+	if err != nil {
+		c.Error(err).SetMeta("TopContentController.redis.Cache.SetEx")
+	}
+
+	c.Data(200, "application/json", output)
+
+}
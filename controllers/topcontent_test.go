@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+	"github.com/eirka/eirka-libs/redis"
+)
+
+func TestTopContentController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.GET("/top", TopContentController)
+
+	// Set up fake Redis connection
+	redis.NewRedisMock()
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	// Mock a cache miss and the cache update
+	redis.Cache.Mock.Command("GET", "stats:top:1:30d").Expect(nil)
+	setex := redis.Cache.Mock.GenericCommand("SETEX").Expect("OK")
+
+	mock.ExpectQuery(`SELECT thread_id, thread_title`).
+		WithArgs(1, sqlmock.AnyArg(), "thread:%", 1, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"thread_id", "thread_title", "hits"}).
+			AddRow(2, "Popular", 300))
+
+	mock.ExpectQuery(`SELECT image_id`).
+		WithArgs(1, sqlmock.AnyArg(), "image:%", 1, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"image_id", "thread_id", "thread_title", "post_num", "hits"}))
+
+	mock.ExpectQuery(`SELECT tag_id, tag_name`).
+		WithArgs(1, sqlmock.AnyArg(), "tag:%", 1, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"tag_id", "tag_name", "hits"}))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/top?range=30d")
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.JSONEq(t, `{"threads":[{"id":2,"title":"Popular","hits":300}],"images":[],"tags":[]}`, response.Body.String(), "Response should match")
+
+	// The result should be cached
+	assert.Equal(t, 1, redis.Cache.Mock.Stats(setex), "Report should be cached")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestTopContentControllerCached(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.GET("/top", TopContentController)
+
+	// Set up fake Redis connection
+	redis.NewRedisMock()
+
+	// Mock a cache hit
+	redis.Cache.Mock.Command("GET", "stats:top:1:7d").Expect([]byte(`{"threads":[]}`))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/top")
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.JSONEq(t, `{"threads":[]}`, response.Body.String(), "Response should come from the cache")
+}
+
+func TestTopContentControllerInvalidRange(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1}))
+	router.GET("/top", TopContentController)
+
+	// Perform the request
+	response := performRequest(router, "GET", "/top?range=2y")
+
+	// Check response code
+	assert.Equal(t, http.StatusBadRequest, response.Code, "HTTP status code should be 400")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrInvalidParam), response.Body.String(), "Response should match expected error message")
+}
+
+func TestTopContentControllerNotProtected(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockNonAdminMiddleware([]uint{1}))
+	router.GET("/top", TopContentController)
+
+	// Perform the request
+	response := performRequest(router, "GET", "/top")
+
+	// Check response code
+	assert.Equal(t, http.StatusInternalServerError, response.Code, "HTTP status code should be 500")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrInternalError), response.Body.String(), "Response should match expected error message")
+}
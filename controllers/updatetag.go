@@ -12,6 +12,7 @@ import (
 	"github.com/eirka/eirka-libs/user"
 
 	"github.com/eirka/eirka-admin/models"
+	u "github.com/eirka/eirka-admin/utils"
 )
 
 // update tag input
@@ -106,10 +107,10 @@ func UpdateTagController(c *gin.Context) {
 		Info:   m.Tag,
 	}
 
-	// submit audit
-	err = audit.Submit()
+	// submit audit, undelivered entries are kept in the outbox
+	err = u.SubmitAudit(audit)
 	if err != nil {
-		c.Error(err).SetMeta("UpdateTagController.audit.Submit")
+		c.Error(err).SetMeta("UpdateTagController.SubmitAudit")
 	}
 
 }
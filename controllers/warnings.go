@@ -0,0 +1,247 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eirka/eirka-libs/audit"
+	e "github.com/eirka/eirka-libs/errors"
+	"github.com/eirka/eirka-libs/user"
+
+	local "github.com/eirka/eirka-admin/config"
+	"github.com/eirka/eirka-admin/models"
+	u "github.com/eirka/eirka-admin/utils"
+)
+
+// warning input
+type warnForm struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// WarnController will warn the poster of a post, enough warnings turn into a
+// temporary ban
+func WarnController(c *gin.Context) {
+	var err error
+	var wf warnForm
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	// get userdata from user middleware
+	userdata := c.MustGet("userdata").(user.User)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("WarnController.protected")
+		return
+	}
+
+	err = c.ShouldBindJSON(&wf)
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInvalidParam))
+		c.Error(err).SetMeta("WarnController.ShouldBindJSON")
+		return
+	}
+
+	// Initialize model struct
+	m := &models.WarnModel{
+		Ib:         params[0],
+		Thread:     params[1],
+		ID:         params[2],
+		User:       userdata.ID,
+		Reason:     wf.Reason,
+		Escalation: local.Settings.Warnings,
+	}
+
+	// Validate input parameters
+	err = m.ValidateInput()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error_message": err.Error()})
+		c.Error(err).SetMeta("WarnController.ValidateInput")
+		return
+	}
+
+	// Check the record id and get further info
+	err = m.Status()
+	if err == e.ErrNotFound {
+		c.JSON(e.ErrorMessage(e.ErrNotFound))
+		c.Error(err).SetMeta("WarnController.Status")
+		return
+	} else if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("WarnController.Status")
+		return
+	}
+
+	err = m.Post()
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("WarnController.Post")
+		return
+	}
+
+	// response message
+	c.JSON(http.StatusOK, gin.H{"success_message": u.AuditWarn})
+
+	// audit log
+	entry := audit.Audit{
+		User:   userdata.ID,
+		Ib:     m.Ib,
+		Type:   audit.ModLog,
+		IP:     c.ClientIP(),
+		Action: u.AuditWarn,
+		Info:   m.Reason,
+	}
+
+	// submit audit, undelivered entries are kept in the outbox
+	err = u.SubmitPostAudit(entry, m.Thread, m.ID)
+	if err != nil {
+		c.Error(err).SetMeta("WarnController.SubmitAudit")
+	}
+
+	// the ban the warning escalated to, temporary ip bans are not sent to cloudflare
+	switch {
+	case m.UserBan != nil:
+		entry.Action = u.AuditBanUser
+		entry.Info = banUserInfo(m.UserBan)
+	case m.IPBan != nil:
+		entry.Action = audit.AuditBanIP
+		entry.Info = m.IPBan.Reason
+	default:
+		return
+	}
+
+	err = u.SubmitPostAudit(entry, m.Thread, m.ID)
+	if err != nil {
+		c.Error(err).SetMeta("WarnController.Ban.SubmitAudit")
+	}
+
+}
+
+// WarningsController will list the warnings of a board
+func WarningsController(c *gin.Context) {
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("WarningsController.protected")
+		return
+	}
+
+	// Initialize model struct
+	m := &models.WarningsModel{
+		Ib:   params[0],
+		Page: params[1],
+	}
+
+	// Get the model which outputs JSON
+	err := m.Get()
+	if err == e.ErrNotFound {
+		c.JSON(e.ErrorMessage(e.ErrNotFound))
+		c.Error(err).SetMeta("WarningsController.Get")
+		return
+	} else if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("WarningsController.Get")
+		return
+	}
+
+	// Marshal the structs into JSON
+	output, err := json.Marshal(m.Result)
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("WarningsController.Marshal")
+		return
+	}
+
+	c.Data(200, "application/json", output)
+
+}
+
+// PosterWarningsController will list the warnings of the user or ip of a post
+func PosterWarningsController(c *gin.Context) {
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	if !c.MustGet("protected").(bool) {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(e.ErrInternalError).SetMeta("PosterWarningsController.protected")
+		return
+	}
+
+	// Initialize model struct
+	m := &models.PosterWarningsModel{
+		Ib:     params[0],
+		Thread: params[1],
+		ID:     params[2],
+	}
+
+	// Get the model which outputs JSON
+	err := m.Get()
+	if err == e.ErrNotFound {
+		c.JSON(e.ErrorMessage(e.ErrNotFound))
+		c.Error(err).SetMeta("PosterWarningsController.Get")
+		return
+	} else if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("PosterWarningsController.Get")
+		return
+	}
+
+	// Marshal the structs into JSON
+	output, err := json.Marshal(m.Result)
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("PosterWarningsController.Marshal")
+		return
+	}
+
+	c.Data(200, "application/json", output)
+
+}
+
+// UnseenWarningsController will show the user the warnings they have not seen
+// yet on the board, they are marked seen once they are returned
+func UnseenWarningsController(c *gin.Context) {
+
+	// Get parameters from validate middleware
+	params := c.MustGet("params").([]uint)
+
+	// get userdata from user middleware
+	userdata := c.MustGet("userdata").(user.User)
+
+	// Initialize model struct
+	m := &models.UnseenWarningsModel{
+		Ib:      params[0],
+		Account: userdata.ID,
+		IP:      c.ClientIP(),
+	}
+
+	// Get the model which outputs JSON
+	err := m.Get()
+	if err == e.ErrNotFound {
+		c.JSON(e.ErrorMessage(e.ErrNotFound))
+		c.Error(err).SetMeta("UnseenWarningsController.Get")
+		return
+	} else if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("UnseenWarningsController.Get")
+		return
+	}
+
+	// Marshal the structs into JSON
+	output, err := json.Marshal(m.Result)
+	if err != nil {
+		c.JSON(e.ErrorMessage(e.ErrInternalError))
+		c.Error(err).SetMeta("UnseenWarningsController.Marshal")
+		return
+	}
+
+	c.Data(200, "application/json", output)
+
+}
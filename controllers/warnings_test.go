@@ -0,0 +1,239 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/config"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+
+	local "github.com/eirka/eirka-admin/config"
+	u "github.com/eirka/eirka-admin/utils"
+)
+
+func TestWarnController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 2, 3}))
+	router.POST("/warn", WarnController)
+
+	config.Settings.Limits.CommentMinLength = 3
+	config.Settings.Limits.CommentMaxLength = 100
+
+	local.Settings.Warnings = local.Warnings{Limit: 3, BanDays: 7}
+	defer func() {
+		local.Settings.Warnings = local.Warnings{}
+	}()
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT posts.user_id, user_name, post_ip FROM threads`).
+		WithArgs(1, 2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "user_name", "post_ip"}).AddRow(5, "troll", "10.0.0.1"))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO warnings`).
+		WithArgs(1, 2, 2, 3, 5, "10.0.0.1", "stop spamming").
+		WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectQuery(`SELECT COUNT\(warning_id\) FROM warnings`).
+		WithArgs(1, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectExec(`INSERT INTO banned_users`).
+		WithArgs(2, 1, 5, false, "Warned 3 times", sqlmock.AnyArg(), 0).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/warn", []byte(`{"reason":"stop spamming"}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.JSONEq(t, successMessage(u.AuditWarn), response.Body.String(), "Response should match expected success message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestWarnControllerNoReason(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 2, 3}))
+	router.POST("/warn", WarnController)
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/warn", []byte(`{}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusBadRequest, response.Code, "HTTP status code should be 400")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrInvalidParam), response.Body.String(), "Response should match expected error message")
+}
+
+func TestWarnControllerNotFound(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 2, 3}))
+	router.POST("/warn", WarnController)
+
+	config.Settings.Limits.CommentMinLength = 3
+	config.Settings.Limits.CommentMaxLength = 100
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT posts.user_id, user_name, post_ip FROM threads`).
+		WithArgs(1, 2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "user_name", "post_ip"}))
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/warn", []byte(`{"reason":"stop spamming"}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusNotFound, response.Code, "HTTP status code should be 404")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrNotFound), response.Body.String(), "Response should match expected error message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestWarnControllerNotProtected(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockNonAdminMiddleware([]uint{1, 2, 3}))
+	router.POST("/warn", WarnController)
+
+	// Perform the request
+	response := performJSONRequest(router, "POST", "/warn", []byte(`{"reason":"stop spamming"}`))
+
+	// Check response code
+	assert.Equal(t, http.StatusInternalServerError, response.Code, "HTTP status code should be 500")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrInternalError), response.Body.String(), "Response should match expected error message")
+}
+
+func TestWarningsController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 1}))
+	router.GET("/warnings", WarningsController)
+
+	config.Settings.Limits.PostsPerPage = 10
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT COUNT\(warning_id\) FROM warnings WHERE ib_id = \?`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	mock.ExpectQuery(`SELECT warning_id, thread_id, post_num`).
+		WithArgs(1, 0, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"warning_id", "thread_id", "post_num", "warning_user_id", "user_name", "warning_ip",
+			"warning_reason", "warning_time", "warning_seen", "user_id", "user_name"}).
+			AddRow(7, 2, 3, 5, "troll", "10.0.0.1", "stop spamming", time.Now(), false, 2, "mod"))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/warnings")
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.Contains(t, response.Body.String(), `"warning_reason":"stop spamming"`, "Response should contain the warning")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestPosterWarningsControllerNotFound(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(mockAdminMiddleware([]uint{1, 2, 3}))
+	router.GET("/warnings", PosterWarningsController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT posts.user_id, user_name, post_ip FROM threads`).
+		WithArgs(1, 2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "user_name", "post_ip"}))
+
+	// Perform the request
+	response := performRequest(router, "GET", "/warnings")
+
+	// Check response code
+	assert.Equal(t, http.StatusNotFound, response.Code, "HTTP status code should be 404")
+
+	// Check response body
+	assert.JSONEq(t, errorMessage(e.ErrNotFound), response.Body.String(), "Response should match expected error message")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestUnseenWarningsController(t *testing.T) {
+	// Set up Gin
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("params", []uint{1})
+		c.Next()
+	})
+	router.Use(mockUserMiddleware(5))
+	router.GET("/warnings", UnseenWarningsController)
+
+	// Set up SQL mock
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT warning_id, thread_id, post_num, warning_reason, warning_time FROM warnings`).
+		WithArgs(1, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"warning_id", "thread_id", "post_num", "warning_reason", "warning_time"}).
+			AddRow(7, 2, 3, "stop spamming", time.Now()))
+	mock.ExpectExec(`UPDATE warnings SET warning_seen = 1`).
+		WithArgs(1, 5, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// Perform the request
+	response := performRequest(router, "GET", "/warnings")
+
+	// Check response code
+	assert.Equal(t, http.StatusOK, response.Code, "HTTP status code should be 200")
+
+	// Check response body
+	assert.Contains(t, response.Body.String(), `"warning_reason":"stop spamming"`, "Response should contain the warning")
+	assert.NotContains(t, response.Body.String(), "user_name", "Response should not name the moderator")
+
+	// Make sure all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
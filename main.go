@@ -9,6 +9,7 @@ import (
 	"github.com/facebookgo/grace/gracehttp"
 	"github.com/facebookgo/pidfile"
 	"github.com/gin-gonic/gin"
+	"github.com/robfig/cron"
 
 	"github.com/eirka/eirka-libs/config"
 	"github.com/eirka/eirka-libs/cors"
@@ -21,6 +22,8 @@ import (
 
 	local "github.com/eirka/eirka-admin/config"
 	c "github.com/eirka/eirka-admin/controllers"
+	"github.com/eirka/eirka-admin/models"
+	u "github.com/eirka/eirka-admin/utils"
 )
 
 func init() {
@@ -62,6 +65,16 @@ func init() {
 	// Set up Redis connection
 	r.NewRedisCache()
 
+	// scan for floods every minute
+	jobs := cron.New()
+
+	err = jobs.AddFunc("@every 1m", models.DetectFlood)
+	if err != nil {
+		panic("Could not add flood detection cron job")
+	}
+
+	jobs.Start()
+
 	// set cors domains
 	cors.SetDomains(local.Settings.CORS.Sites, strings.Split("GET,POST,DELETE", ","))
 
@@ -70,13 +83,45 @@ func init() {
 func main() {
 	r := gin.Default()
 
+	r.Use(u.Metrics())
 	r.Use(cors.CORS())
 	// verified the csrf token from the request
 	r.Use(csrf.Verify())
 
 	r.GET("/status", status.StatusController)
+
+	servers := []*http.Server{}
+
+	// serve metrics on their own address if one is set, or to the allowed
+	// clients of the admin daemon, they are not served at all otherwise
+	if local.Settings.Metrics.Port != 0 {
+		m := gin.New()
+		m.Use(gin.Recovery())
+		if len(local.Settings.Metrics.Allow) != 0 {
+			m.Use(u.MetricsAllow(local.Settings.Metrics.Allow))
+		}
+		m.GET("/metrics", u.MetricsController())
+
+		servers = append(servers, &http.Server{
+			Addr:              fmt.Sprintf("%s:%d", local.Settings.Metrics.Host, local.Settings.Metrics.Port),
+			ReadHeaderTimeout: 2 * time.Second,
+			Handler:           m,
+		})
+	} else if len(local.Settings.Metrics.Allow) != 0 {
+		r.GET("/metrics", u.MetricsAllow(local.Settings.Metrics.Allow), u.MetricsController())
+	}
 	r.NoRoute(c.ErrorController)
 
+	// any user, anonymous users are known by their ip
+	users := r.Group("/user")
+
+	users.Use(validate.ValidateParams())
+	users.Use(user.Auth(false))
+
+	users.GET("/warnings/:ib", c.UnseenWarningsController)
+
+	users.POST("/report/:ib/:thread/:post", c.AddReportController)
+
 	// requires mod perms
 	admin := r.Group("/")
 
@@ -85,20 +130,78 @@ func main() {
 	admin.Use(user.Protect())
 
 	admin.GET("/statistics/:ib", c.StatisticsController)
+	admin.GET("/statistics/:ib/top", c.TopContentController)
+	admin.GET("/statistics/:ib/moderators", c.ModeratorStatsController)
+	admin.GET("/statistics/:ib/duplicates", c.DuplicatesController)
+	admin.GET("/statistics/:ib/bans", c.BanStatsController)
 	admin.GET("/log/board/:ib/:page", c.BoardLogController)
 	admin.GET("/log/mod/:ib/:page", c.ModLogController)
+	admin.GET("/log/verify/:ib", c.AuditChainController)
+	admin.GET("/events/:ib", c.EventsController)
+	admin.GET("/audit/outbox/:ib", c.AuditOutboxController)
+	admin.GET("/alerts/:ib", c.AlertsController)
+	admin.GET("/reports/:ib/:page", c.ReportsController)
+	admin.GET("/post/:ib/:thread/:id", c.PostController)
+	admin.GET("/ip/:ib/:thread/:post/:page", c.IPHistoryController)
+	admin.GET("/file/:ib/:thread/:post/:page", c.FileHistoryController)
+	admin.GET("/file/:ib/hash/:page", c.BoardFileHistoryController)
+	admin.GET("/search/:ib/:page", c.SearchController)
+	admin.GET("/notes/:ib/ip/:thread/:post", c.NotesController(models.NoteIP))
+	admin.GET("/notes/:ib/user/:user", c.NotesController(models.NoteUser))
+	admin.GET("/notes/:ib/thread/:thread", c.NotesController(models.NoteThread))
+	admin.GET("/filters/:ib", c.FiltersController)
+	admin.GET("/rules/:ib", c.RulesController)
+	admin.GET("/bans/user/:ib/:page", c.UserBansController(false))
+	admin.GET("/warnings/:ib/:page", c.WarningsController)
+	admin.GET("/warnings/:ib/post/:thread/:post", c.PosterWarningsController)
 
 	admin.DELETE("/tag/:ib/:id", c.DeleteTagController)
 	admin.DELETE("/imagetag/:ib/:image/:tag", c.DeleteImageTagController)
 	admin.DELETE("/thread/:ib/:id", c.DeleteThreadController)
 	admin.DELETE("/post/:ib/:thread/:id", c.DeletePostController)
+	admin.DELETE("/note/:ib/:id", c.DeleteNoteController)
+	admin.DELETE("/filter/:ib/:id", c.DeleteFilterController)
+	admin.DELETE("/rule/:ib/:id", c.DeleteRuleController)
+	admin.DELETE("/ban/user/:ib/:id", c.UnbanUserController(false))
 
 	admin.POST("/tag/:ib", c.UpdateTagController)
 	admin.POST("/sticky/:ib/:thread", c.StickyThreadController)
 	admin.POST("/close/:ib/:thread", c.CloseThreadController)
 	admin.POST("/ban/ip/:ib/:thread/:post", c.BanIPController)
 	admin.POST("/ban/file/:ib/:thread/:post", c.BanFileController)
+	admin.POST("/ban/user/:ib/:thread/:post", c.BanUserController(false))
+	admin.POST("/warn/:ib/:thread/:post", c.WarnController)
 	admin.POST("/user/resetpassword/:ib", c.ResetPasswordController)
+	admin.POST("/reports/resolve/:ib/:thread/:post", c.ResolveReportController)
+	admin.POST("/reports/dismiss/:ib/:thread/:post", c.DismissReportController)
+	admin.POST("/notes/:ib/ip/:thread/:post", c.AddNoteController(models.NoteIP))
+	admin.POST("/notes/:ib/user/:user", c.AddNoteController(models.NoteUser))
+	admin.POST("/notes/:ib/thread/:thread", c.AddNoteController(models.NoteThread))
+	admin.POST("/note/:ib/:id", c.UpdateNoteController)
+	admin.POST("/bulk/delete/:ib", c.BulkDeleteController)
+	admin.POST("/batch/:ib", c.BatchController)
+	admin.POST("/filters/:ib", c.AddFilterController)
+	admin.POST("/filters/:ib/test", c.FilterTestController)
+	admin.POST("/filter/:ib/:id", c.UpdateFilterController)
+	admin.POST("/rules/:ib", c.AddRuleController)
+	admin.POST("/rule/:ib/:id", c.UpdateRuleController)
+
+	// requires site admin perms
+	site := r.Group("/site")
+
+	site.Use(validate.ValidateParams())
+	site.Use(user.Auth(true))
+	site.Use(u.SiteProtect())
+
+	site.GET("/statistics", c.SiteStatisticsController)
+	site.GET("/ip/:page", c.SiteIPHistoryController)
+	site.GET("/file/:page", c.SiteFileHistoryController)
+	site.GET("/search/:page", c.SiteSearchController)
+	site.GET("/bans/user/:page", c.UserBansController(true))
+
+	site.DELETE("/ban/user/:id", c.UnbanUserController(true))
+
+	site.POST("/ban/user/:ib/:thread/:post", c.BanUserController(true))
 
 	s := &http.Server{
 		Addr:              fmt.Sprintf("%s:%d", local.Settings.Admin.Host, local.Settings.Admin.Port),
@@ -106,7 +209,9 @@ func main() {
 		Handler:           r,
 	}
 
-	err := gracehttp.Serve(s)
+	servers = append(servers, s)
+
+	err := gracehttp.Serve(servers...)
 	if err != nil {
 		panic("Could not start server")
 	}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+)
+
+// AlertsLimit is how many of the latest alerts are returned
+const AlertsLimit = 100
+
+// AlertsModel holds request input
+type AlertsModel struct {
+	Ib     uint
+	Result AlertsType
+}
+
+// AlertsType is container for JSON response
+type AlertsType struct {
+	Alerts []Alert `json:"alerts"`
+}
+
+// Alert is an ip or file that was flagged by the flood detection
+type Alert struct {
+	ID     uint      `json:"id"`
+	Type   string    `json:"type"`
+	Value  string    `json:"value"`
+	Count  uint      `json:"count"`
+	Thread uint      `json:"thread"`
+	Post   uint      `json:"post"`
+	Banned bool      `json:"banned"`
+	Time   time.Time `json:"time"`
+}
+
+// Get will return the latest flood alerts of the board
+func (m *AlertsModel) Get() (err error) {
+
+	if m.Ib == 0 {
+		return e.ErrNotFound
+	}
+
+	// Initialize response header
+	response := AlertsType{
+		Alerts: []Alert{},
+	}
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	rows, err := dbase.Query(`SELECT alert_id,alert_type,alert_value,alert_count,thread_id,post_num,alert_banned,alert_time
+    FROM alerts
+    WHERE ib_id = ?
+    ORDER BY alert_time DESC LIMIT ?`, m.Ib, AlertsLimit)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		alert := Alert{}
+
+		err = rows.Scan(&alert.ID, &alert.Type, &alert.Value, &alert.Count, &alert.Thread, &alert.Post, &alert.Banned, &alert.Time)
+		if err != nil {
+			return
+		}
+
+		response.Alerts = append(response.Alerts, alert)
+	}
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	// This is the data we will serialize
+	m.Result = response
+
+	return
+
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+)
+
+func TestAlertsModelGet(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	flagged := time.Date(2024, 3, 10, 15, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT alert_id,alert_type,alert_value,alert_count,thread_id,post_num,alert_banned,alert_time\s+FROM alerts\s+WHERE ib_id = \?\s+ORDER BY alert_time DESC LIMIT \?`).
+		WithArgs(1, AlertsLimit).
+		WillReturnRows(sqlmock.NewRows([]string{"alert_id", "alert_type", "alert_value", "alert_count", "thread_id", "post_num", "alert_banned", "alert_time"}).
+			AddRow(3, AlertIP, "10.0.0.1", 25, 2, 40, true, flagged).
+			AddRow(1, AlertFile, "abc123", 4, 5, 2, false, flagged))
+
+	m := &AlertsModel{
+		Ib: 1,
+	}
+
+	err = m.Get()
+	assert.NoError(t, err, "No error should be returned")
+
+	if assert.Equal(t, 2, len(m.Result.Alerts), "Should have two alerts") {
+		assert.Equal(t, Alert{ID: 3, Type: AlertIP, Value: "10.0.0.1", Count: 25, Thread: 2, Post: 40, Banned: true, Time: flagged}, m.Result.Alerts[0], "Alert should match")
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestAlertsModelGetError(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT alert_id`).
+		WillReturnError(errors.New("database error"))
+
+	m := &AlertsModel{
+		Ib: 1,
+	}
+
+	err = m.Get()
+	assert.Error(t, err, "Error should be returned")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestAlertsModelGetInvalid(t *testing.T) {
+	m := &AlertsModel{}
+
+	err := m.Get()
+	assert.Equal(t, e.ErrNotFound, err, "Error should be ErrNotFound")
+}
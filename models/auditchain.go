@@ -0,0 +1,174 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/eirka/eirka-libs/audit"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+
+	local "github.com/eirka/eirka-admin/config"
+	u "github.com/eirka/eirka-admin/utils"
+)
+
+// AuditChainModel holds request input
+type AuditChainModel struct {
+	Ib     uint
+	Type   audit.LogType
+	Result AuditChainType
+}
+
+// ErrAuditNotChained is returned for log types that other services write
+// without a hash, most of their entries are not in the chain
+var ErrAuditNotChained = errors.New("only the mod log can be verified")
+
+// AuditChainType is the container for the JSON response, Head is set when the
+// newest entry was checked against the head kept outside the database
+type AuditChainType struct {
+	Valid     bool           `json:"valid"`
+	Entries   uint           `json:"entries"`
+	Anchor    uint           `json:"anchor_id,omitempty"`
+	Head      bool           `json:"head_checked"`
+	Truncated bool           `json:"truncated,omitempty"`
+	Broken    *AuditChainRow `json:"broken,omitempty"`
+}
+
+// AuditChainRow is the first entry whose hash does not match the chain
+type AuditChainRow struct {
+	ID       uint       `json:"audit_id"`
+	Time     *time.Time `json:"log_time"`
+	Action   string     `json:"log_action"`
+	Expected string     `json:"expected_hash"`
+	Stored   string     `json:"stored_hash"`
+}
+
+// IsValid will check struct validity
+func (i *AuditChainModel) IsValid() bool {
+
+	if i.Ib == 0 {
+		return false
+	}
+
+	switch i.Type {
+	case audit.BoardLog, audit.ModLog, audit.UserLog:
+	default:
+		return false
+	}
+
+	return true
+
+}
+
+// Verify will walk the hash chain of a log type of the board and report the first broken link
+func (i *AuditChainModel) Verify() (err error) {
+
+	if !i.IsValid() {
+		return e.ErrNotFound
+	}
+
+	// only the mod log is written by this service alone
+	if i.Type != audit.ModLog {
+		return ErrAuditNotChained
+	}
+
+	// a chain without a key can be rebuilt by anyone
+	if local.Settings.Audit.ChainKey == "" {
+		return u.ErrAuditChainKey
+	}
+
+	// the newest entry when the chain was last written to
+	headID, headHash, headOk, err := u.AuditHead(i.Ib, i.Type)
+	if err != nil {
+		return
+	}
+
+	// Initialize response header
+	response := AuditChainType{
+		Valid: true,
+	}
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	// the first entry is chained to the last archived entry, or to nothing if
+	// nothing was archived
+	var previous string
+
+	response.Anchor, previous, err = u.AuditAnchor(dbase, i.Ib, i.Type)
+	if err != nil {
+		return
+	}
+
+	// a head that was archived can only be checked against the anchor
+	if headOk && headID <= response.Anchor {
+		response.Head = headID < response.Anchor || headHash == previous
+	}
+
+	// get every chained entry in the order they were written
+	rows, err := dbase.Query(`SELECT audit_id,user_id,ib_id,audit_type,audit_ip,audit_time,audit_action,audit_info,thread_id,post_num,audit_hash
+    FROM audit
+    WHERE ib_id = ? AND audit_type = ? AND audit_hash IS NOT NULL
+    ORDER BY audit_id ASC`, i.Ib, i.Type)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uint
+		var stored string
+		entry := u.AuditEntry{}
+
+		err := rows.Scan(&id, &entry.User, &entry.Ib, &entry.Type, &entry.IP, &entry.Time, &entry.Action, &entry.Info,
+			&entry.Thread, &entry.Post, &stored)
+		if err != nil {
+			return err
+		}
+
+		response.Entries++
+
+		expected := entry.ChainHash(previous)
+
+		// a deleted or edited entry breaks every hash that follows it
+		if expected != stored {
+			response.Valid = false
+			response.Broken = &AuditChainRow{
+				ID:       id,
+				Time:     &entry.Time,
+				Action:   entry.Action,
+				Expected: expected,
+				Stored:   stored,
+			}
+			break
+		}
+
+		if headOk && id == headID {
+			response.Head = stored == headHash
+		}
+
+		previous = stored
+	}
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	// the newest entries were removed or the head entry was replaced
+	if response.Valid && headOk && !response.Head {
+		response.Valid = false
+		response.Truncated = true
+		response.Broken = &AuditChainRow{
+			ID:       headID,
+			Expected: headHash,
+		}
+	}
+
+	// This is the data we will serialize
+	i.Result = response
+
+	return
+
+}
@@ -0,0 +1,426 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/audit"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+	"github.com/eirka/eirka-libs/redis"
+
+	local "github.com/eirka/eirka-admin/config"
+	u "github.com/eirka/eirka-admin/utils"
+)
+
+// builds a chain of mod log entries and returns them with their hashes
+func testAuditChain(count int) (entries []u.AuditEntry) {
+	var previous string
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	for i := 0; i < count; i++ {
+		entry := u.AuditEntry{
+			Audit: audit.Audit{
+				User:   2,
+				Ib:     1,
+				Type:   audit.ModLog,
+				IP:     "10.0.0.1",
+				Action: audit.AuditDeletePost,
+				Info:   "Thread/1",
+			},
+			Thread: 1,
+			Post:   uint(i + 1),
+			Time:   now.Add(time.Duration(i) * time.Minute),
+		}
+		entry.Hash = entry.ChainHash(previous)
+		previous = entry.Hash
+		entries = append(entries, entry)
+	}
+
+	return
+}
+
+// sets the key the chain is signed with, the returned func unsets it
+func testAuditChainKey() func() {
+	local.Settings.Audit.ChainKey = "testkey"
+	return func() {
+		local.Settings.Audit.ChainKey = ""
+	}
+}
+
+// mocks the head kept in redis, an id of zero means no head was stored
+func testAuditHead(id uint, hash string) {
+	redis.NewRedisMock()
+
+	head := []interface{}{}
+	if id != 0 {
+		head = append(head, []byte("id"), []byte(strconv.Itoa(int(id))), []byte("hash"), []byte(hash))
+	}
+
+	redis.Cache.Mock.Command("HGETALL", fmt.Sprintf("audit:head:1:%d", audit.ModLog)).Expect(head)
+}
+
+// the last archived entry of the chain
+const testAuditAnchorQuery = `SELECT audit_id, audit_hash FROM audit_anchors WHERE ib_id = \? AND audit_type = \?`
+
+func testAuditChainRows(entries []u.AuditEntry) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"audit_id", "user_id", "ib_id", "audit_type", "audit_ip", "audit_time", "audit_action", "audit_info", "thread_id", "post_num", "audit_hash"})
+
+	for i, entry := range entries {
+		rows.AddRow(i+1, entry.User, entry.Ib, entry.Type, entry.IP, entry.Time, entry.Action, entry.Info, entry.Thread, entry.Post, entry.Hash)
+	}
+
+	return rows
+}
+
+func TestAuditChainVerify(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	defer testAuditChainKey()()
+
+	m := &AuditChainModel{
+		Ib:   1,
+		Type: audit.ModLog,
+	}
+
+	entries := testAuditChain(3)
+
+	testAuditHead(3, entries[2].Hash)
+
+	mock.ExpectQuery(testAuditAnchorQuery).
+		WithArgs(1, audit.ModLog).
+		WillReturnRows(sqlmock.NewRows([]string{"audit_id", "audit_hash"}))
+
+	mock.ExpectQuery(`SELECT audit_id,user_id,ib_id,audit_type,audit_ip,audit_time,audit_action,audit_info,thread_id,post_num,audit_hash
+    FROM audit`).
+		WithArgs(1, audit.ModLog).
+		WillReturnRows(testAuditChainRows(entries))
+
+	err = m.Verify()
+	assert.NoError(t, err, "No error should be returned")
+
+	assert.True(t, m.Result.Valid, "Chain should be valid")
+	assert.Equal(t, uint(3), m.Result.Entries, "All entries should be checked")
+	assert.Nil(t, m.Result.Broken, "There should be no broken link")
+	assert.True(t, m.Result.Head, "The newest entry should match the head")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestAuditChainVerifyDeletedEntry(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	defer testAuditChainKey()()
+
+	m := &AuditChainModel{
+		Ib:   1,
+		Type: audit.ModLog,
+	}
+
+	// remove the second entry from the chain
+	entries := testAuditChain(4)
+	entries = append(entries[:1], entries[2:]...)
+
+	testAuditHead(0, "")
+
+	mock.ExpectQuery(testAuditAnchorQuery).
+		WithArgs(1, audit.ModLog).
+		WillReturnRows(sqlmock.NewRows([]string{"audit_id", "audit_hash"}))
+
+	mock.ExpectQuery(`SELECT audit_id,user_id,ib_id,audit_type,audit_ip,audit_time,audit_action,audit_info,thread_id,post_num,audit_hash
+    FROM audit`).
+		WithArgs(1, audit.ModLog).
+		WillReturnRows(testAuditChainRows(entries))
+
+	err = m.Verify()
+	assert.NoError(t, err, "No error should be returned")
+
+	assert.False(t, m.Result.Valid, "Chain should be invalid")
+	assert.Equal(t, uint(2), m.Result.Entries, "Verification should stop at the broken link")
+	if assert.NotNil(t, m.Result.Broken, "There should be a broken link") {
+		assert.Equal(t, uint(2), m.Result.Broken.ID, "The entry after the deleted one should be reported")
+		assert.Equal(t, entries[1].Hash, m.Result.Broken.Stored, "Stored hash should be reported")
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestAuditChainVerifyEditedEntry(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	defer testAuditChainKey()()
+
+	m := &AuditChainModel{
+		Ib:   1,
+		Type: audit.ModLog,
+	}
+
+	// change the content of the first entry
+	entries := testAuditChain(2)
+	entries[0].Info = "something else"
+
+	testAuditHead(0, "")
+
+	mock.ExpectQuery(testAuditAnchorQuery).
+		WithArgs(1, audit.ModLog).
+		WillReturnRows(sqlmock.NewRows([]string{"audit_id", "audit_hash"}))
+
+	mock.ExpectQuery(`SELECT audit_id,user_id,ib_id,audit_type,audit_ip,audit_time,audit_action,audit_info,thread_id,post_num,audit_hash
+    FROM audit`).
+		WithArgs(1, audit.ModLog).
+		WillReturnRows(testAuditChainRows(entries))
+
+	err = m.Verify()
+	assert.NoError(t, err, "No error should be returned")
+
+	assert.False(t, m.Result.Valid, "Chain should be invalid")
+	if assert.NotNil(t, m.Result.Broken, "There should be a broken link") {
+		assert.Equal(t, uint(1), m.Result.Broken.ID, "The edited entry should be reported")
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestAuditChainVerifyPruned(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	defer testAuditChainKey()()
+
+	m := &AuditChainModel{
+		Ib:   1,
+		Type: audit.ModLog,
+	}
+
+	// the first entry was archived by the retention job
+	entries := testAuditChain(3)
+
+	testAuditHead(0, "")
+
+	mock.ExpectQuery(testAuditAnchorQuery).
+		WithArgs(1, audit.ModLog).
+		WillReturnRows(sqlmock.NewRows([]string{"audit_id", "audit_hash"}).AddRow(1, entries[0].Hash))
+
+	mock.ExpectQuery(`SELECT audit_id,user_id,ib_id,audit_type,audit_ip,audit_time,audit_action,audit_info,thread_id,post_num,audit_hash
+    FROM audit`).
+		WithArgs(1, audit.ModLog).
+		WillReturnRows(testAuditChainRows(entries[1:]))
+
+	err = m.Verify()
+	assert.NoError(t, err, "No error should be returned")
+
+	assert.True(t, m.Result.Valid, "Chain should be valid")
+	assert.Equal(t, uint(2), m.Result.Entries, "All entries should be checked")
+	assert.Equal(t, uint(1), m.Result.Anchor, "The archived entry should be the anchor")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestAuditChainVerifyPrunedDeletedOldest(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	defer testAuditChainKey()()
+
+	m := &AuditChainModel{
+		Ib:   1,
+		Type: audit.ModLog,
+	}
+
+	// the first entry was archived and the oldest surviving entry was deleted
+	entries := testAuditChain(4)
+
+	testAuditHead(0, "")
+
+	mock.ExpectQuery(testAuditAnchorQuery).
+		WithArgs(1, audit.ModLog).
+		WillReturnRows(sqlmock.NewRows([]string{"audit_id", "audit_hash"}).AddRow(1, entries[0].Hash))
+
+	mock.ExpectQuery(`SELECT audit_id,user_id,ib_id,audit_type,audit_ip,audit_time,audit_action,audit_info,thread_id,post_num,audit_hash
+    FROM audit`).
+		WithArgs(1, audit.ModLog).
+		WillReturnRows(testAuditChainRows(entries[2:]))
+
+	err = m.Verify()
+	assert.NoError(t, err, "No error should be returned")
+
+	assert.False(t, m.Result.Valid, "Chain should be invalid")
+	if assert.NotNil(t, m.Result.Broken, "There should be a broken link") {
+		assert.Equal(t, entries[2].Hash, m.Result.Broken.Stored, "The first surviving entry should be reported")
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestAuditChainVerifyUnanchoredPrune(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	defer testAuditChainKey()()
+
+	m := &AuditChainModel{
+		Ib:   1,
+		Type: audit.ModLog,
+	}
+
+	// the oldest entry is gone without the retention job saving an anchor
+	entries := testAuditChain(3)
+
+	testAuditHead(0, "")
+
+	mock.ExpectQuery(testAuditAnchorQuery).
+		WithArgs(1, audit.ModLog).
+		WillReturnRows(sqlmock.NewRows([]string{"audit_id", "audit_hash"}))
+
+	mock.ExpectQuery(`SELECT audit_id,user_id,ib_id,audit_type,audit_ip,audit_time,audit_action,audit_info,thread_id,post_num,audit_hash
+    FROM audit`).
+		WithArgs(1, audit.ModLog).
+		WillReturnRows(testAuditChainRows(entries[1:]))
+
+	err = m.Verify()
+	assert.NoError(t, err, "No error should be returned")
+
+	assert.False(t, m.Result.Valid, "Removing the oldest entries should be detected")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestAuditChainVerifyError(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	defer testAuditChainKey()()
+
+	m := &AuditChainModel{
+		Ib:   1,
+		Type: audit.ModLog,
+	}
+
+	expectedError := errors.New("database error")
+	testAuditHead(0, "")
+
+	mock.ExpectQuery(testAuditAnchorQuery).
+		WithArgs(1, audit.ModLog).
+		WillReturnRows(sqlmock.NewRows([]string{"audit_id", "audit_hash"}))
+
+	mock.ExpectQuery(`SELECT audit_id,user_id,ib_id,audit_type,audit_ip,audit_time,audit_action,audit_info,thread_id,post_num,audit_hash
+    FROM audit`).
+		WithArgs(1, audit.ModLog).
+		WillReturnError(expectedError)
+
+	err = m.Verify()
+	assert.Equal(t, expectedError, err, "Error should match the expected error")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestAuditChainVerifyInvalid(t *testing.T) {
+	m := &AuditChainModel{
+		Ib:   0,
+		Type: audit.ModLog,
+	}
+
+	err := m.Verify()
+	assert.Equal(t, e.ErrNotFound, err, "Error should be ErrNotFound")
+
+	m = &AuditChainModel{
+		Ib:   1,
+		Type: 9,
+	}
+
+	assert.False(t, m.IsValid(), "Unknown log type should not be valid")
+}
+
+func TestAuditChainVerifyTruncated(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	defer testAuditChainKey()()
+
+	m := &AuditChainModel{
+		Ib:   1,
+		Type: audit.ModLog,
+	}
+
+	// the newest entry was removed, what is left is still a valid chain
+	entries := testAuditChain(3)
+
+	testAuditHead(3, entries[2].Hash)
+
+	mock.ExpectQuery(testAuditAnchorQuery).
+		WithArgs(1, audit.ModLog).
+		WillReturnRows(sqlmock.NewRows([]string{"audit_id", "audit_hash"}))
+
+	mock.ExpectQuery(`SELECT audit_id,user_id,ib_id,audit_type,audit_ip,audit_time,audit_action,audit_info,thread_id,post_num,audit_hash
+    FROM audit`).
+		WithArgs(1, audit.ModLog).
+		WillReturnRows(testAuditChainRows(entries[:2]))
+
+	err = m.Verify()
+	assert.NoError(t, err, "No error should be returned")
+
+	assert.False(t, m.Result.Valid, "Removing the newest entries should be detected")
+	assert.True(t, m.Result.Truncated, "Chain should be reported as truncated")
+	if assert.NotNil(t, m.Result.Broken, "There should be a broken link") {
+		assert.Equal(t, uint(3), m.Result.Broken.ID, "The head should be reported")
+		assert.Equal(t, entries[2].Hash, m.Result.Broken.Expected, "The head hash should be reported")
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestAuditChainVerifyNoKey(t *testing.T) {
+	m := &AuditChainModel{
+		Ib:   1,
+		Type: audit.ModLog,
+	}
+
+	err := m.Verify()
+	assert.Equal(t, u.ErrAuditChainKey, err, "Error should be ErrAuditChainKey")
+}
+
+func TestAuditChainVerifyNotChained(t *testing.T) {
+	defer testAuditChainKey()()
+
+	m := &AuditChainModel{
+		Ib:   1,
+		Type: audit.BoardLog,
+	}
+
+	err := m.Verify()
+	assert.Equal(t, ErrAuditNotChained, err, "Error should be ErrAuditNotChained")
+}
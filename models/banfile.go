@@ -15,7 +15,14 @@ type BanFileModel struct {
 	ID     uint
 	User   uint
 	Reason string
-	Hash   string
+	// the board rule the ban was made for, zero for a custom reason
+	Rule uint
+	Hash string
+}
+
+// ValidateInput checks the data input for correctness
+func (m *BanFileModel) ValidateInput() (err error) {
+	return validateBanReason(m.Reason)
 }
 
 // IsValid will check struct validity
@@ -58,8 +65,15 @@ func (m *BanFileModel) Status() (err error) {
 		return
 	}
 
+	return m.status(dbase)
+
+}
+
+// status is Status with the handle, so it can run in a batch
+func (m *BanFileModel) status(h handle) (err error) {
+
 	// get thread ib and title
-	err = dbase.QueryRow(`SELECT image_hash FROM threads
+	err = h.QueryRow(`SELECT image_hash FROM threads
     INNER JOIN posts ON threads.thread_id = posts.thread_id
     INNER JOIN images ON posts.post_id = images.post_id
     WHERE ib_id = ? AND threads.thread_id = ? AND post_num = ? LIMIT 1`, m.Ib, m.Thread, m.ID).Scan(&m.Hash)
@@ -76,19 +90,26 @@ func (m *BanFileModel) Status() (err error) {
 // Post will add the file to the table
 func (m *BanFileModel) Post() (err error) {
 
-	// check model validity
-	if !m.IsValid() {
-		return errors.New("BanFileModel is not valid")
-	}
-
 	// Get Database handle
 	dbase, err := db.GetDb()
 	if err != nil {
 		return
 	}
 
-	_, err = dbase.Exec("INSERT IGNORE INTO banned_files (user_id,ib_id,ban_hash,ban_reason) VALUES (?,?,?,?)",
-		m.User, m.Ib, m.Hash, m.Reason)
+	return m.post(dbase)
+
+}
+
+// post is Post with the handle, so it can run in a batch
+func (m *BanFileModel) post(h handle) (err error) {
+
+	// check model validity
+	if !m.IsValid() {
+		return errors.New("BanFileModel is not valid")
+	}
+
+	_, err = h.Exec("INSERT IGNORE INTO banned_files (user_id,ib_id,ban_hash,ban_reason,rule_id) VALUES (?,?,?,?,?)",
+		m.User, m.Ib, m.Hash, m.Reason, m.Rule)
 	if err != nil {
 		return
 	}
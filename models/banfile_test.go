@@ -158,8 +158,8 @@ func TestBanFilePost(t *testing.T) {
 	defer db.CloseDb()
 
 	// Set expected exec with its expectations
-	mock.ExpectExec("INSERT IGNORE INTO banned_files \\(user_id,ib_id,ban_hash,ban_reason\\) VALUES \\(\\?,\\?,\\?,\\?\\)").
-		WithArgs(2, 1, "abcdef1234567890", "test reason").
+	mock.ExpectExec("INSERT IGNORE INTO banned_files \\(user_id,ib_id,ban_hash,ban_reason,rule_id\\) VALUES \\(\\?,\\?,\\?,\\?,\\?\\)").
+		WithArgs(2, 1, "abcdef1234567890", "test reason", 0).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	// Initialize model
@@ -211,8 +211,8 @@ func TestBanFilePostError(t *testing.T) {
 	defer db.CloseDb()
 
 	// Set expected exec with its expectations - will return an error
-	mock.ExpectExec("INSERT IGNORE INTO banned_files \\(user_id,ib_id,ban_hash,ban_reason\\) VALUES \\(\\?,\\?,\\?,\\?\\)").
-		WithArgs(2, 1, "abcdef1234567890", "test reason").
+	mock.ExpectExec("INSERT IGNORE INTO banned_files \\(user_id,ib_id,ban_hash,ban_reason,rule_id\\) VALUES \\(\\?,\\?,\\?,\\?,\\?\\)").
+		WithArgs(2, 1, "abcdef1234567890", "test reason", 0).
 		WillReturnError(errors.New("database error"))
 
 	// Initialize model
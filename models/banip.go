@@ -3,9 +3,12 @@ package models
 import (
 	"database/sql"
 	"errors"
+	"time"
 
+	"github.com/eirka/eirka-libs/config"
 	"github.com/eirka/eirka-libs/db"
 	e "github.com/eirka/eirka-libs/errors"
+	"github.com/eirka/eirka-libs/validate"
 )
 
 // BanIPModel holds request input
@@ -16,6 +19,30 @@ type BanIPModel struct {
 	User   uint
 	Reason string
 	IP     string
+	// a ban without expiry is permanent
+	Expires *time.Time
+	// the board rule the ban was made for, zero for a custom reason
+	Rule uint
+	// the account of the post is banned with the ip if set
+	Account *BanUserModel
+}
+
+// validateBanReason checks the full reason of a ban, a rule message with a
+// custom reason added to it can be longer than either
+func validateBanReason(reason string) (err error) {
+
+	r := validate.Validate{Input: reason, Max: config.Settings.Limits.CommentMaxLength}
+	if r.MaxLength() {
+		return e.ErrCommentLong
+	}
+
+	return
+
+}
+
+// ValidateInput checks the data input for correctness
+func (m *BanIPModel) ValidateInput() (err error) {
+	return validateBanReason(m.Reason)
 }
 
 // IsValid will check struct validity
@@ -58,8 +85,15 @@ func (m *BanIPModel) Status() (err error) {
 		return
 	}
 
+	return m.status(dbase)
+
+}
+
+// status is Status with the handle, so it can run in a batch
+func (m *BanIPModel) status(h handle) (err error) {
+
 	// get thread ib and title
-	err = dbase.QueryRow(`SELECT post_ip FROM threads
+	err = h.QueryRow(`SELECT post_ip FROM threads
     INNER JOIN posts ON threads.thread_id = posts.thread_id
     WHERE ib_id = ? AND threads.thread_id = ? AND post_num = ? LIMIT 1`, m.Ib, m.Thread, m.ID).Scan(&m.IP)
 	if err == sql.ErrNoRows {
@@ -75,19 +109,60 @@ func (m *BanIPModel) Status() (err error) {
 // Post will add the ip to the table
 func (m *BanIPModel) Post() (err error) {
 
-	// check model validity
-	if !m.IsValid() {
-		return errors.New("BanIPModel is not valid")
+	if m.Account == nil {
+		// Get Database handle
+		dbase, err := db.GetDb()
+		if err != nil {
+			return err
+		}
+
+		return m.post(dbase)
 	}
 
-	// Get Database handle
-	dbase, err := db.GetDb()
+	// Get transaction handle
+	tx, err := db.GetTransaction()
 	if err != nil {
 		return
 	}
+	defer tx.Rollback()
+
+	err = m.post(tx)
+	if err != nil {
+		return
+	}
+
+	err = m.Account.post(tx)
+	if err != nil {
+		return
+	}
+
+	// Commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return
+	}
+
+	return
+
+}
+
+// post is Post with the handle, so it can run in a batch
+func (m *BanIPModel) post(h handle) (err error) {
+
+	// check model validity
+	if !m.IsValid() {
+		return errors.New("BanIPModel is not valid")
+	}
 
-	_, err = dbase.Exec("INSERT IGNORE INTO banned_ips (user_id,ib_id,ban_ip,ban_reason) VALUES (?,?,?,?)",
-		m.User, m.Ib, m.IP, m.Reason)
+	// an existing ban is kept, a permanent ban stays permanent and a temporary
+	// ban only ever gets longer, the reason follows the ban that lasts longest
+	_, err = h.Exec(`INSERT INTO banned_ips (user_id,ib_id,ban_ip,ban_reason,ban_expires,rule_id) VALUES (?,?,?,?,?,?)
+    ON DUPLICATE KEY UPDATE
+    user_id = IF(`+banWins+`, VALUES(user_id), user_id),
+    ban_reason = IF(`+banWins+`, VALUES(ban_reason), ban_reason),
+    rule_id = IF(`+banWins+`, VALUES(rule_id), rule_id),
+    ban_expires = IF(ban_expires IS NULL OR VALUES(ban_expires) IS NULL, NULL, GREATEST(ban_expires, VALUES(ban_expires)))`,
+		m.User, m.Ib, m.IP, m.Reason, m.Expires, m.Rule)
 	if err != nil {
 		return
 	}
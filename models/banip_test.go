@@ -3,11 +3,15 @@ package models
 import (
 	"database/sql"
 	"errors"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/DATA-DOG/go-sqlmock.v1"
 
+	"github.com/eirka/eirka-libs/config"
 	"github.com/eirka/eirka-libs/db"
 	e "github.com/eirka/eirka-libs/errors"
 )
@@ -126,6 +130,17 @@ func TestBanIPIsValid(t *testing.T) {
 	}
 }
 
+func TestBanIPValidateInput(t *testing.T) {
+	m := &BanIPModel{Reason: "Spam"}
+	assert.NoError(t, m.ValidateInput(), "A short reason should be valid")
+
+	// a rule message with a custom reason added can pass the limit
+	rule := &Rule{Message: strings.Repeat("a", config.Settings.Limits.CommentMaxLength)}
+
+	m = &BanIPModel{Reason: rule.Reason("and more")}
+	assert.Equal(t, e.ErrCommentLong, m.ValidateInput(), "A reason longer than a comment should not be valid")
+}
+
 func TestBanIPStatus(t *testing.T) {
 	var err error
 
@@ -238,8 +253,8 @@ func TestBanIPPost(t *testing.T) {
 	}
 
 	// Post exec
-	mock.ExpectExec("INSERT IGNORE INTO banned_ips \\(user_id,ib_id,ban_ip,ban_reason\\) VALUES \\(\\?,\\?,\\?,\\?\\)").
-		WithArgs(m.User, m.Ib, m.IP, m.Reason).
+	mock.ExpectExec("INSERT INTO banned_ips \\(user_id,ib_id,ban_ip,ban_reason,ban_expires,rule_id\\) VALUES \\(\\?,\\?,\\?,\\?,\\?,\\?\\)").
+		WithArgs(m.User, m.Ib, m.IP, m.Reason, nil, 0).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	// Post the ban
@@ -250,6 +265,41 @@ func TestBanIPPost(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
 }
 
+func TestBanIPPostLongerBanKeepsReason(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	expires := time.Now().Add(time.Hour)
+
+	m := &BanIPModel{
+		Ib:      1,
+		Thread:  1,
+		ID:      1,
+		User:    2,
+		Reason:  "Spam",
+		IP:      "10.0.0.1",
+		Expires: &expires,
+		Rule:    3,
+	}
+
+	// the reason and rule of the existing ban only change when the new ban lasts at least as long
+	mock.ExpectExec(`INSERT INTO banned_ips .* ON DUPLICATE KEY UPDATE `+
+		`user_id = IF\(`+regexp.QuoteMeta(banWins)+`, VALUES\(user_id\), user_id\), `+
+		`ban_reason = IF\(`+regexp.QuoteMeta(banWins)+`, VALUES\(ban_reason\), ban_reason\), `+
+		`rule_id = IF\(`+regexp.QuoteMeta(banWins)+`, VALUES\(rule_id\), rule_id\), `+
+		`ban_expires = IF\(ban_expires IS NULL OR VALUES\(ban_expires\) IS NULL, NULL, GREATEST\(ban_expires, VALUES\(ban_expires\)\)\)`).
+		WithArgs(2, 1, "10.0.0.1", "Spam", &expires, 3).
+		WillReturnResult(sqlmock.NewResult(1, 2))
+
+	err = m.Post()
+	assert.NoError(t, err, "No error should be returned")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
 func TestBanIPPostInvalid(t *testing.T) {
 	// Initialize invalid model
 	m := &BanIPModel{
@@ -286,8 +336,8 @@ func TestBanIPPostError(t *testing.T) {
 
 	// Post exec error
 	expectedError := errors.New("database error")
-	mock.ExpectExec("INSERT IGNORE INTO banned_ips \\(user_id,ib_id,ban_ip,ban_reason\\) VALUES \\(\\?,\\?,\\?,\\?\\)").
-		WithArgs(m.User, m.Ib, m.IP, m.Reason).
+	mock.ExpectExec("INSERT INTO banned_ips \\(user_id,ib_id,ban_ip,ban_reason,ban_expires,rule_id\\) VALUES \\(\\?,\\?,\\?,\\?,\\?,\\?\\)").
+		WithArgs(m.User, m.Ib, m.IP, m.Reason, nil, 0).
 		WillReturnError(expectedError)
 
 	// Post the ban
@@ -0,0 +1,347 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/eirka/eirka-libs/config"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+
+	u "github.com/eirka/eirka-admin/utils"
+)
+
+// ErrBanNoAccount is returned when the post to ban the account of was made anonymously
+var ErrBanNoAccount = errors.New("post was not made with an account")
+
+// ErrBanExpired is returned when a ban would expire before it is made
+var ErrBanExpired = errors.New("ban expiry is in the past")
+
+// banWins is true when a new ban lasts at least as long as the current one
+const banWins = "VALUES(ban_expires) IS NULL OR (ban_expires IS NOT NULL AND VALUES(ban_expires) >= ban_expires)"
+
+// BanUserModel holds request input, a site wide ban is kept with the board
+// of the post it was made from
+type BanUserModel struct {
+	Ib      uint
+	Thread  uint
+	ID      uint
+	User    uint
+	Site    bool
+	Reason  string
+	Expires *time.Time
+	// the board rule the ban was made for, zero for a custom reason
+	Rule    uint
+	Account uint
+	Name    string
+}
+
+// IsValid will check struct validity
+func (m *BanUserModel) IsValid() bool {
+
+	if m.Ib == 0 {
+		return false
+	}
+
+	if m.Thread == 0 {
+		return false
+	}
+
+	if m.ID == 0 {
+		return false
+	}
+
+	if m.User == 0 || m.User == 1 {
+		return false
+	}
+
+	if m.Reason == "" {
+		return false
+	}
+
+	if m.Account == 0 || m.Account == 1 {
+		return false
+	}
+
+	return true
+
+}
+
+// ValidateInput checks the data input for correctness
+func (m *BanUserModel) ValidateInput() (err error) {
+
+	if m.Expires != nil && !m.Expires.After(time.Now()) {
+		return ErrBanExpired
+	}
+
+	return validateBanReason(m.Reason)
+
+}
+
+// Status will return the account of the post
+func (m *BanUserModel) Status() (err error) {
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	err = dbase.QueryRow(`SELECT posts.user_id, user_name FROM threads
+    INNER JOIN posts ON threads.thread_id = posts.thread_id
+    INNER JOIN users ON posts.user_id = users.user_id
+    WHERE ib_id = ? AND threads.thread_id = ? AND post_num = ? LIMIT 1`, m.Ib, m.Thread, m.ID).Scan(&m.Account, &m.Name)
+	if err == sql.ErrNoRows {
+		return e.ErrNotFound
+	} else if err != nil {
+		return
+	}
+
+	if m.Account == 1 {
+		return ErrBanNoAccount
+	}
+
+	return
+
+}
+
+// Post will add the account to the table, banning it again keeps the longer
+// ban and never narrows a site wide ban to the board
+func (m *BanUserModel) Post() (err error) {
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	return m.post(dbase)
+
+}
+
+// post is Post with the handle, so it can run with an ip ban
+func (m *BanUserModel) post(h handle) (err error) {
+
+	// check model validity
+	if !m.IsValid() {
+		return errors.New("BanUserModel is not valid")
+	}
+
+	// the reason and moderator follow the ban that lasts longer, so they are
+	// set before the expiry changes
+	_, err = h.Exec(`INSERT INTO banned_users (user_id,ib_id,ban_user_id,ban_site,ban_reason,ban_time,ban_expires,rule_id)
+    VALUES (?,?,?,?,?,NOW(),?,?)
+    ON DUPLICATE KEY UPDATE
+    user_id = IF(`+banWins+`, VALUES(user_id), user_id),
+    ban_reason = IF(`+banWins+`, VALUES(ban_reason), ban_reason),
+    rule_id = IF(`+banWins+`, VALUES(rule_id), rule_id),
+    ban_time = IF(`+banWins+`, NOW(), ban_time),
+    ban_site = GREATEST(ban_site, VALUES(ban_site)),
+    ban_expires = IF(ban_expires IS NULL OR VALUES(ban_expires) IS NULL, NULL, GREATEST(ban_expires, VALUES(ban_expires)))`,
+		m.User, m.Ib, m.Account, m.Site, m.Reason, m.Expires, m.Rule)
+	if err != nil {
+		return
+	}
+
+	return
+
+}
+
+// UserBansModel holds request input, site lists the site wide bans of every board
+type UserBansModel struct {
+	Ib     uint
+	Site   bool
+	Page   uint
+	Result UserBansType
+}
+
+// UserBansType is container for JSON response
+type UserBansType struct {
+	Body u.PagedResponse `json:"bans"`
+}
+
+// UserBan is a banned account and who banned it
+type UserBan struct {
+	ID      uint       `json:"ban_id"`
+	Ib      uint       `json:"ib_id"`
+	Site    bool       `json:"ban_site"`
+	Account uint       `json:"ban_user_id"`
+	Name    string     `json:"ban_user_name"`
+	Reason  string     `json:"ban_reason"`
+	Time    *time.Time `json:"ban_time"`
+	Expires *time.Time `json:"ban_expires"`
+	UID     uint       `json:"user_id"`
+	User    string     `json:"user_name"`
+}
+
+// Get will return the account bans that have not expired, the newest first
+func (m *UserBansModel) Get() (err error) {
+
+	if m.Page == 0 || (m.Ib == 0 && !m.Site) {
+		return e.ErrNotFound
+	}
+
+	// Initialize response header
+	response := UserBansType{}
+
+	scope, arg := "ib_id = ?", interface{}(m.Ib)
+	if m.Site {
+		scope, arg = "ban_site = ?", true
+	}
+
+	// to hold the bans
+	bans := []UserBan{}
+
+	// Initialize struct for pagination
+	paged := u.PagedResponse{}
+	// Set current page to parameter
+	paged.CurrentPage = m.Page
+	// Set threads per index page to config setting
+	paged.PerPage = config.Settings.Limits.PostsPerPage
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	// Get total ban count and put it in pagination struct
+	err = dbase.QueryRow(`SELECT COUNT(ban_id) FROM banned_users
+    WHERE `+scope+` AND (ban_expires IS NULL OR ban_expires > NOW())`, arg).Scan(&paged.Total)
+	if err != nil {
+		return
+	}
+
+	// Calculate Limit and total Pages
+	paged.Get()
+
+	// Return 404 if page requested is larger than actual pages
+	if m.Page > paged.Pages {
+		return e.ErrNotFound
+	}
+
+	rows, err := dbase.Query(`SELECT ban_id, ib_id, ban_site, ban_user_id, banned.user_name, ban_reason, ban_time, ban_expires,
+    banned_users.user_id, moderators.user_name
+    FROM banned_users
+    INNER JOIN users AS banned ON banned_users.ban_user_id = banned.user_id
+    INNER JOIN users AS moderators ON banned_users.user_id = moderators.user_id
+    WHERE `+scope+` AND (ban_expires IS NULL OR ban_expires > NOW())
+    ORDER BY ban_id DESC LIMIT ?,?`, arg, paged.Limit, paged.PerPage)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		ban := UserBan{}
+
+		err = rows.Scan(&ban.ID, &ban.Ib, &ban.Site, &ban.Account, &ban.Name, &ban.Reason, &ban.Time, &ban.Expires, &ban.UID, &ban.User)
+		if err != nil {
+			return
+		}
+
+		bans = append(bans, ban)
+	}
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	// Add bans slice to items interface
+	paged.Items = bans
+
+	// Add pagedresponse to the response struct
+	response.Body = paged
+
+	// This is the data we will serialize
+	m.Result = response
+
+	return
+
+}
+
+// UnbanUserModel holds request input, a board of zero finds the ban on any board
+type UnbanUserModel struct {
+	Ib   uint
+	ID   uint
+	Site bool
+	Name string
+}
+
+// Status will return the board, scope and banned account of the ban
+func (m *UnbanUserModel) Status() (err error) {
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	var ib uint
+
+	err = dbase.QueryRow(`SELECT ib_id, ban_site, user_name FROM banned_users
+    INNER JOIN users ON banned_users.ban_user_id = users.user_id
+    WHERE ban_id = ?`, m.ID).Scan(&ib, &m.Site, &m.Name)
+	if err == sql.ErrNoRows {
+		return e.ErrNotFound
+	} else if err != nil {
+		return
+	}
+
+	// the ban is on another board
+	if m.Ib != 0 && m.Ib != ib {
+		return e.ErrNotFound
+	}
+
+	m.Ib = ib
+
+	return
+
+}
+
+// Delete will remove the ban
+func (m *UnbanUserModel) Delete() (err error) {
+
+	if m.Ib == 0 || m.ID == 0 {
+		return errors.New("UnbanUserModel is not valid")
+	}
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	_, err = dbase.Exec("DELETE FROM banned_users WHERE ib_id = ? AND ban_id = ?", m.Ib, m.ID)
+	if err != nil {
+		return
+	}
+
+	return
+
+}
+
+// UserBanned returns true if the account has a ban on the board or a site wide
+// ban that has not expired, the anonymous account is never banned
+func UserBanned(ib, account uint) (banned bool, err error) {
+
+	if account == 0 || account == 1 {
+		return
+	}
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	err = dbase.QueryRow(`SELECT EXISTS(SELECT 1 FROM banned_users
+    WHERE ban_user_id = ? AND (ib_id = ? OR ban_site = 1) AND (ban_expires IS NULL OR ban_expires > NOW()))`,
+		account, ib).Scan(&banned)
+	if err != nil {
+		return
+	}
+
+	return
+
+}
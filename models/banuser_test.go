@@ -0,0 +1,219 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/config"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+)
+
+// the account ban post lookup
+const testBanUserQuery = `SELECT posts.user_id, user_name FROM threads`
+
+func TestBanUserModelValidateInput(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	m := &BanUserModel{}
+	assert.NoError(t, m.ValidateInput(), "Permanent ban should be valid")
+
+	m = &BanUserModel{Expires: &future}
+	assert.NoError(t, m.ValidateInput(), "Future expiry should be valid")
+
+	m = &BanUserModel{Expires: &past}
+	assert.Equal(t, ErrBanExpired, m.ValidateInput(), "Past expiry should be rejected")
+}
+
+func TestBanUserModelStatus(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(testBanUserQuery).
+		WithArgs(1, 2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "user_name"}).AddRow(5, "troll"))
+
+	m := &BanUserModel{Ib: 1, Thread: 2, ID: 3}
+
+	err = m.Status()
+	assert.NoError(t, err, "No error should be returned")
+	assert.Equal(t, uint(5), m.Account, "Account should match")
+	assert.Equal(t, "troll", m.Name, "Name should match")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestBanUserModelStatusAnonymous(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(testBanUserQuery).
+		WithArgs(1, 2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "user_name"}).AddRow(1, "Anonymous"))
+
+	m := &BanUserModel{Ib: 1, Thread: 2, ID: 3}
+
+	err = m.Status()
+	assert.Equal(t, ErrBanNoAccount, err, "Anonymous posts have no account")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestBanUserModelPost(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	expires := time.Now().Add(time.Hour)
+
+	mock.ExpectExec(`INSERT INTO banned_users`).
+		WithArgs(2, 1, 5, true, "spamming", &expires, 0).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	m := &BanUserModel{Ib: 1, Thread: 2, ID: 3, User: 2, Site: true, Reason: "spamming", Expires: &expires, Account: 5}
+
+	err = m.Post()
+	assert.NoError(t, err, "No error should be returned")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestBanUserModelPostKeepsSiteBan(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	expires := time.Now().Add(time.Hour)
+
+	// a board ban on an account that is banned site wide must not lift the site ban
+	mock.ExpectExec(`INSERT INTO banned_users .* ON DUPLICATE KEY UPDATE .*ban_site = GREATEST\(ban_site, VALUES\(ban_site\)\)`).
+		WithArgs(2, 1, 5, false, "spamming", &expires, 0).
+		WillReturnResult(sqlmock.NewResult(1, 2))
+
+	m := &BanUserModel{Ib: 1, Thread: 2, ID: 3, User: 2, Site: false, Reason: "spamming", Expires: &expires, Account: 5}
+
+	err = m.Post()
+	assert.NoError(t, err, "No error should be returned")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestBanUserModelPostInvalid(t *testing.T) {
+	m := &BanUserModel{Ib: 1, Thread: 2, ID: 3, User: 2, Reason: "spamming", Account: 1}
+
+	assert.Error(t, m.Post(), "Anonymous account should be rejected")
+}
+
+func TestBanIPModelPostWithAccount(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO banned_ips`).
+		WithArgs(2, 1, "10.0.0.1", "spamming", nil, 0).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO banned_users`).
+		WithArgs(2, 1, 5, false, "spamming", nil, 0).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	m := &BanIPModel{Ib: 1, Thread: 2, ID: 3, User: 2, Reason: "spamming", IP: "10.0.0.1"}
+	m.Account = &BanUserModel{Ib: 1, Thread: 2, ID: 3, User: 2, Reason: "spamming", Account: 5}
+
+	err = m.Post()
+	assert.NoError(t, err, "No error should be returned")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestUserBansModelGetSite(t *testing.T) {
+	var err error
+
+	config.Settings.Limits.PostsPerPage = 10
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT COUNT\(ban_id\) FROM banned_users\s+WHERE ban_site = \?`).
+		WithArgs(true).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	mock.ExpectQuery(`SELECT ban_id, ib_id, ban_site, ban_user_id`).
+		WithArgs(true, 0, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"ban_id", "ib_id", "ban_site", "ban_user_id", "user_name", "ban_reason", "ban_time", "ban_expires", "user_id", "user_name"}).
+			AddRow(4, 1, true, 5, "troll", "spamming", time.Now(), nil, 2, "mod"))
+
+	m := &UserBansModel{Site: true, Page: 1}
+
+	err = m.Get()
+	assert.NoError(t, err, "No error should be returned")
+
+	bans, ok := m.Result.Body.Items.([]UserBan)
+	if assert.True(t, ok, "Items should be account bans") && assert.Equal(t, 1, len(bans), "Should have one ban") {
+		assert.Equal(t, "troll", bans[0].Name, "Name should match")
+		assert.Nil(t, bans[0].Expires, "Ban should be permanent")
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestUnbanUserModelStatusOtherBoard(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT ib_id, ban_site, user_name FROM banned_users`).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id", "ban_site", "user_name"}).AddRow(2, false, "troll"))
+
+	m := &UnbanUserModel{Ib: 1, ID: 4}
+
+	err = m.Status()
+	assert.Equal(t, e.ErrNotFound, err, "Bans of other boards should not be found")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestUserBanned(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM banned_users
+    WHERE ban_user_id = \? AND \(ib_id = \? OR ban_site = 1\) AND \(ban_expires IS NULL OR ban_expires > NOW\(\)\)\)`).
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"banned"}).AddRow(true))
+
+	banned, err := UserBanned(1, 5)
+	assert.NoError(t, err, "No error should be returned")
+	assert.True(t, banned, "Account should be banned")
+
+	// the anonymous account is never looked up
+	banned, err = UserBanned(1, 1)
+	assert.NoError(t, err, "No error should be returned")
+	assert.False(t, banned, "Anonymous account should not be banned")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
@@ -0,0 +1,382 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/eirka/eirka-libs/audit"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+)
+
+// handle is a database or a transaction, models take one so they can run in a batch
+type handle interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Prepare(query string) (*sql.Stmt, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// the operations a batch can run
+const (
+	BatchDeletePost     = "delete_post"
+	BatchDeleteThread   = "delete_thread"
+	BatchSticky         = "sticky"
+	BatchClose          = "close"
+	BatchBanIP          = "ban_ip"
+	BatchBanFile        = "ban_file"
+	BatchDeleteImageTag = "delete_imagetag"
+)
+
+// the ways a batch can handle a failed operation
+const (
+	// BatchAllOrNothing rolls the whole batch back
+	BatchAllOrNothing = "all"
+	// BatchBestEffort rolls back the failed operation and carries on
+	BatchBestEffort = "best_effort"
+)
+
+// BatchMax is the most operations one batch can hold
+const BatchMax = 100
+
+// ErrBatchNotRun is the result of the operations after a failure in an all or nothing batch
+var ErrBatchNotRun = errors.New("not run")
+
+// BatchOperation is one moderation action, the fields it uses depend on the kind
+type BatchOperation struct {
+	Op     string `json:"op"`
+	Thread uint   `json:"thread"`
+	Post   uint   `json:"post"`
+	Image  uint   `json:"image"`
+	Tag    uint   `json:"tag"`
+	Reason string `json:"reason"`
+	Rule   uint   `json:"rule_id"`
+}
+
+// BatchResult is the outcome of an operation, the audit action is empty if the
+// operation changed nothing
+type BatchResult struct {
+	Op      string `json:"op"`
+	Success bool   `json:"success"`
+	Action  string `json:"action,omitempty"`
+	Error   string `json:"error,omitempty"`
+	Info    string `json:"-"`
+	Thread  uint   `json:"-"`
+	Post    uint   `json:"-"`
+	Tag     uint   `json:"-"`
+	IP      string `json:"-"`
+}
+
+// BatchModel holds request input
+type BatchModel struct {
+	Ib         uint
+	User       uint
+	Mode       string
+	Operations []BatchOperation
+	Result     BatchType
+}
+
+// BatchType is container for JSON response
+type BatchType struct {
+	Committed bool          `json:"committed"`
+	Results   []BatchResult `json:"results"`
+}
+
+// IsValid will check struct validity
+func (m *BatchModel) IsValid() bool {
+
+	if m.Ib == 0 {
+		return false
+	}
+
+	if m.User == 0 || m.User == 1 {
+		return false
+	}
+
+	if m.Mode != BatchAllOrNothing && m.Mode != BatchBestEffort {
+		return false
+	}
+
+	if len(m.Operations) == 0 || len(m.Operations) > BatchMax {
+		return false
+	}
+
+	return true
+
+}
+
+// Run will apply the operations in order in one transaction, with best effort a
+// failed operation is rolled back to its savepoint and the rest still run
+func (m *BatchModel) Run() (err error) {
+
+	// check model validity
+	if !m.IsValid() {
+		return e.ErrInvalidParam
+	}
+
+	// Get transaction handle
+	tx, err := db.GetTransaction()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	results := make([]BatchResult, len(m.Operations))
+
+	failed := false
+
+	for i, op := range m.Operations {
+
+		// an all or nothing batch stops at the first failure
+		if failed && m.Mode == BatchAllOrNothing {
+			results[i] = BatchResult{Op: op.Op, Error: ErrBatchNotRun.Error()}
+			continue
+		}
+
+		savepoint := fmt.Sprintf("batch_%d", i)
+
+		_, err = tx.Exec("SAVEPOINT " + savepoint)
+		if err != nil {
+			return
+		}
+
+		result, operr := m.run(tx, op)
+		if operr != nil {
+			failed = true
+
+			// database errors are not shown to the moderator
+			if _, ok := operr.(*e.RequestError); !ok {
+				operr = e.ErrInternalError
+			}
+
+			result.Error = operr.Error()
+
+			// undo what the operation did before it failed
+			_, err = tx.Exec("ROLLBACK TO SAVEPOINT " + savepoint)
+			if err != nil {
+				return
+			}
+		} else {
+			result.Success = true
+		}
+
+		result.Op = op.Op
+		results[i] = result
+	}
+
+	m.Result.Results = results
+
+	if failed && m.Mode == BatchAllOrNothing {
+		// nothing was applied so the results before the failure did not happen
+		for i := range results {
+			if results[i].Success {
+				results[i] = BatchResult{Op: results[i].Op, Error: ErrBatchNotRun.Error()}
+			}
+		}
+		return
+	}
+
+	// Commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return
+	}
+
+	m.Result.Committed = true
+
+	return
+
+}
+
+// run applies one operation with the regular models
+func (m *BatchModel) run(h handle, op BatchOperation) (result BatchResult, err error) {
+
+	switch op.Op {
+	case BatchDeletePost:
+		dm := &DeletePostModel{Ib: m.Ib, Thread: op.Thread, ID: op.Post}
+
+		err = dm.status(h)
+		if err != nil {
+			return
+		}
+
+		result.Thread = dm.Thread
+		result.Post = dm.ID
+
+		// delete toggles so an already deleted post is left alone
+		if dm.Deleted {
+			return
+		}
+
+		err = dm.delete(h)
+		if err != nil {
+			return
+		}
+
+		result.Action = audit.AuditDeletePost
+		result.Info = fmt.Sprintf("%s/%d", dm.Name, dm.ID)
+
+	case BatchDeleteThread:
+		dm := &DeleteThreadModel{Ib: m.Ib, ID: op.Thread}
+
+		err = dm.status(h)
+		if err != nil {
+			return
+		}
+
+		result.Thread = dm.ID
+
+		// delete toggles so an already deleted thread is left alone
+		if dm.Deleted {
+			return
+		}
+
+		err = dm.delete(h)
+		if err != nil {
+			return
+		}
+
+		result.Action = audit.AuditDeleteThread
+		result.Info = dm.Name
+
+	case BatchSticky:
+		sm := &StickyModel{Ib: m.Ib, ID: op.Thread}
+
+		err = sm.status(h)
+		if err != nil {
+			return
+		}
+
+		err = sm.toggle(h)
+		if err != nil {
+			return
+		}
+
+		result.Thread = sm.ID
+		result.Action = audit.AuditStickyThread
+		if sm.Sticky {
+			result.Action = audit.AuditUnstickyThread
+		}
+		result.Info = sm.Name
+
+	case BatchClose:
+		cm := &CloseModel{Ib: m.Ib, ID: op.Thread}
+
+		err = cm.status(h)
+		if err != nil {
+			return
+		}
+
+		err = cm.toggle(h)
+		if err != nil {
+			return
+		}
+
+		result.Thread = cm.ID
+		result.Action = audit.AuditCloseThread
+		if cm.Closed {
+			result.Action = audit.AuditOpenThread
+		}
+		result.Info = cm.Name
+
+	case BatchBanIP:
+		bm := &BanIPModel{Ib: m.Ib, Thread: op.Thread, ID: op.Post, User: m.User, Reason: op.Reason}
+
+		if op.Rule != 0 {
+			var rule Rule
+
+			rule, err = boardRule(h, m.Ib, op.Rule)
+			if err != nil {
+				return
+			}
+
+			bm.Rule = rule.ID
+			bm.Reason = rule.Reason(op.Reason)
+			bm.Expires = rule.Expires(time.Now())
+		}
+
+		err = bm.ValidateInput()
+		if err != nil {
+			return
+		}
+
+		err = bm.status(h)
+		if err != nil {
+			return
+		}
+
+		err = bm.post(h)
+		if err != nil {
+			return
+		}
+
+		// temporary bans are not sent to cloudflare
+		if bm.Expires == nil {
+			result.IP = bm.IP
+		}
+		result.Thread = bm.Thread
+		result.Post = bm.ID
+		result.Action = audit.AuditBanIP
+		result.Info = bm.Reason
+
+	case BatchBanFile:
+		bm := &BanFileModel{Ib: m.Ib, Thread: op.Thread, ID: op.Post, User: m.User, Reason: op.Reason}
+
+		if op.Rule != 0 {
+			var rule Rule
+
+			rule, err = boardRule(h, m.Ib, op.Rule)
+			if err != nil {
+				return
+			}
+
+			bm.Rule = rule.ID
+			bm.Reason = rule.Reason(op.Reason)
+		}
+
+		err = bm.ValidateInput()
+		if err != nil {
+			return
+		}
+
+		err = bm.status(h)
+		if err != nil {
+			return
+		}
+
+		err = bm.post(h)
+		if err != nil {
+			return
+		}
+
+		result.Thread = bm.Thread
+		result.Post = bm.ID
+		result.Action = audit.AuditBanFile
+		result.Info = bm.Reason
+
+	case BatchDeleteImageTag:
+		dm := &DeleteImageTagModel{Ib: m.Ib, Image: op.Image, Tag: op.Tag}
+
+		err = dm.status(h)
+		if err != nil {
+			return
+		}
+
+		err = dm.delete(h)
+		if err != nil {
+			return
+		}
+
+		result.Tag = dm.Tag
+		result.Action = audit.AuditDeleteImageTag
+		result.Info = fmt.Sprintf("%d/%s", dm.Image, dm.Name)
+
+	default:
+		err = e.ErrInvalidParam
+	}
+
+	return
+
+}
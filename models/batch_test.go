@@ -0,0 +1,285 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/audit"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+)
+
+// the sticky status query
+const testBatchStickyQuery = `SELECT thread_title, thread_sticky FROM threads WHERE thread_id = \? AND ib_id = \? LIMIT 1`
+
+func TestBatchModelIsValid(t *testing.T) {
+	ops := []BatchOperation{{Op: BatchSticky, Thread: 2}}
+
+	m := &BatchModel{Ib: 1, User: 2, Mode: BatchAllOrNothing, Operations: ops}
+	assert.True(t, m.IsValid(), "Should be valid")
+
+	m = &BatchModel{Ib: 1, User: 1, Mode: BatchAllOrNothing, Operations: ops}
+	assert.False(t, m.IsValid(), "Anonymous user should not be valid")
+
+	m = &BatchModel{Ib: 1, User: 2, Mode: "some", Operations: ops}
+	assert.False(t, m.IsValid(), "Unknown mode should not be valid")
+
+	m = &BatchModel{Ib: 1, User: 2, Mode: BatchBestEffort}
+	assert.False(t, m.IsValid(), "Empty batch should not be valid")
+
+	m = &BatchModel{Ib: 1, User: 2, Mode: BatchBestEffort, Operations: make([]BatchOperation, BatchMax+1)}
+	assert.False(t, m.IsValid(), "Oversized batch should not be valid")
+}
+
+func TestBatchModelRunBestEffort(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec("SAVEPOINT batch_0").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(testBatchStickyQuery).
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"title", "sticky"}).AddRow("Thread", false))
+	mock.ExpectPrepare("UPDATE threads SET thread_sticky").
+		ExpectExec().
+		WithArgs(true, 2, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectExec("SAVEPOINT batch_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(testBatchStickyQuery).
+		WithArgs(9, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"title", "sticky"}))
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT batch_1").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec("SAVEPOINT batch_2").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT batch_2").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectCommit()
+
+	m := &BatchModel{
+		Ib:   1,
+		User: 2,
+		Mode: BatchBestEffort,
+		Operations: []BatchOperation{
+			{Op: BatchSticky, Thread: 2},
+			{Op: BatchSticky, Thread: 9},
+			{Op: "explode"},
+		},
+	}
+
+	err = m.Run()
+	assert.NoError(t, err, "No error should be returned")
+
+	assert.True(t, m.Result.Committed, "Batch should be committed")
+	if assert.Equal(t, 3, len(m.Result.Results), "Every operation should have a result") {
+		assert.True(t, m.Result.Results[0].Success, "First operation should succeed")
+		assert.Equal(t, audit.AuditStickyThread, m.Result.Results[0].Action, "Action should match")
+		assert.Equal(t, "Thread", m.Result.Results[0].Info, "Info should match")
+		assert.False(t, m.Result.Results[1].Success, "Missing thread should fail")
+		assert.Equal(t, e.ErrNotFound.Error(), m.Result.Results[1].Error, "Error should match")
+		assert.Equal(t, e.ErrInvalidParam.Error(), m.Result.Results[2].Error, "Unknown operation should fail")
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestBatchModelRunAllOrNothing(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec("SAVEPOINT batch_0").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(testBatchStickyQuery).
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"title", "sticky"}).AddRow("Thread", false))
+	mock.ExpectPrepare("UPDATE threads SET thread_sticky").
+		ExpectExec().
+		WithArgs(true, 2, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectExec("SAVEPOINT batch_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(testBatchStickyQuery).
+		WithArgs(9, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"title", "sticky"}))
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT batch_1").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectRollback()
+
+	m := &BatchModel{
+		Ib:   1,
+		User: 2,
+		Mode: BatchAllOrNothing,
+		Operations: []BatchOperation{
+			{Op: BatchSticky, Thread: 2},
+			{Op: BatchSticky, Thread: 9},
+			{Op: BatchSticky, Thread: 3},
+		},
+	}
+
+	err = m.Run()
+	assert.NoError(t, err, "No error should be returned")
+
+	assert.False(t, m.Result.Committed, "Batch should not be committed")
+	if assert.Equal(t, 3, len(m.Result.Results), "Every operation should have a result") {
+		assert.Equal(t, ErrBatchNotRun.Error(), m.Result.Results[0].Error, "Rolled back operation should not count")
+		assert.Equal(t, e.ErrNotFound.Error(), m.Result.Results[1].Error, "Error should match")
+		assert.Equal(t, ErrBatchNotRun.Error(), m.Result.Results[2].Error, "Later operation should not run")
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestBatchModelRunDeletedPost(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT batch_0").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT thread_title, post_deleted FROM threads`).
+		WithArgs(2, 1, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"title", "deleted"}).AddRow("Thread", true))
+	mock.ExpectCommit()
+
+	m := &BatchModel{
+		Ib:         1,
+		User:       2,
+		Mode:       BatchAllOrNothing,
+		Operations: []BatchOperation{{Op: BatchDeletePost, Thread: 2, Post: 3}},
+	}
+
+	err = m.Run()
+	assert.NoError(t, err, "No error should be returned")
+
+	assert.True(t, m.Result.Committed, "Batch should be committed")
+	assert.True(t, m.Result.Results[0].Success, "Operation should succeed")
+	assert.Empty(t, m.Result.Results[0].Action, "Deleted post should be left alone")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestBatchModelRunBanRule(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT batch_0").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT rule_id, rule_title, rule_duration, rule_message`).
+		WithArgs(1, 4).
+		WillReturnRows(sqlmock.NewRows([]string{"rule_id", "rule_title", "rule_duration", "rule_message"}).AddRow(4, "Spam", 7, "Rule 1: no spam"))
+	mock.ExpectQuery(`SELECT post_ip FROM threads`).
+		WithArgs(1, 2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"post_ip"}).AddRow("10.0.0.1"))
+	mock.ExpectExec(`INSERT INTO banned_ips`).
+		WithArgs(2, 1, "10.0.0.1", "Rule 1: no spam", sqlmock.AnyArg(), 4).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	m := &BatchModel{
+		Ib:         1,
+		User:       2,
+		Mode:       BatchAllOrNothing,
+		Operations: []BatchOperation{{Op: BatchBanIP, Thread: 2, Post: 3, Rule: 4}},
+	}
+
+	err = m.Run()
+	assert.NoError(t, err, "No error should be returned")
+
+	assert.True(t, m.Result.Committed, "Batch should be committed")
+	assert.Equal(t, "Rule 1: no spam", m.Result.Results[0].Info, "Reason should come from the rule")
+	assert.Empty(t, m.Result.Results[0].IP, "Temporary bans should not go to cloudflare")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestBatchModelRunDeletePostDeletedOP(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	// the op is deleted but the status is read from the target post
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT batch_0").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT thread_title, post_deleted FROM threads.*AND post_num = \?`).
+		WithArgs(2, 1, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"title", "deleted"}).AddRow("Thread", false))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM posts`).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectPrepare("UPDATE posts SET post_deleted").
+		ExpectExec().
+		WithArgs(true, 2, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	m := &BatchModel{
+		Ib:         1,
+		User:       2,
+		Mode:       BatchAllOrNothing,
+		Operations: []BatchOperation{{Op: BatchDeletePost, Thread: 2, Post: 3}},
+	}
+
+	err = m.Run()
+	assert.NoError(t, err, "No error should be returned")
+
+	assert.True(t, m.Result.Committed, "Batch should be committed")
+	assert.Equal(t, audit.AuditDeletePost, m.Result.Results[0].Action, "Live post should be deleted")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestBatchModelRunDeletePostLiveOP(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	// the op is live but the target post is already deleted, so the thread
+	// must not be counted or deleted
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT batch_0").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT thread_title, post_deleted FROM threads.*AND post_num = \?`).
+		WithArgs(2, 1, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"title", "deleted"}).AddRow("Thread", true))
+	mock.ExpectCommit()
+
+	m := &BatchModel{
+		Ib:         1,
+		User:       2,
+		Mode:       BatchAllOrNothing,
+		Operations: []BatchOperation{{Op: BatchDeletePost, Thread: 2, Post: 3}},
+	}
+
+	err = m.Run()
+	assert.NoError(t, err, "No error should be returned")
+
+	assert.True(t, m.Result.Results[0].Success, "Operation should succeed")
+	assert.Empty(t, m.Result.Results[0].Action, "Deleted post should be left alone")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestBatchModelRunInvalid(t *testing.T) {
+	m := &BatchModel{Ib: 1, User: 2, Mode: BatchAllOrNothing}
+
+	err := m.Run()
+	assert.Equal(t, e.ErrInvalidParam, err, "Error should be ErrInvalidParam")
+}
@@ -0,0 +1,261 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+)
+
+// BulkDeleteMax is the most posts one bulk delete can remove
+const BulkDeleteMax = 1000
+
+// ErrBulkTooLarge is returned when the filter matches more than BulkDeleteMax posts
+var ErrBulkTooLarge = errors.New("too many posts matched")
+
+// BulkDeleteModel holds request input
+type BulkDeleteModel struct {
+	SearchFilter
+	ID     uint
+	User   uint
+	Result BulkDeleteType
+}
+
+// BulkDeleteType holds the posts a bulk delete removes
+type BulkDeleteType struct {
+	Posts   uint         `json:"posts"`
+	Threads []BulkThread `json:"threads"`
+}
+
+// BulkThread holds the matched posts of a thread, the thread is deleted with
+// them if they are its last posts
+type BulkThread struct {
+	ID      uint   `json:"thread_id"`
+	Title   string `json:"thread_title"`
+	Posts   []uint `json:"post_nums"`
+	Deleted bool   `json:"thread_deleted"`
+	ids     []uint
+}
+
+// IsValid will check struct validity
+func (m *BulkDeleteModel) IsValid() bool {
+
+	if m.Ib == 0 {
+		return false
+	}
+
+	if !m.Selective() {
+		return false
+	}
+
+	if m.User == 0 || m.User == 1 {
+		return false
+	}
+
+	return true
+
+}
+
+// Preview will return the posts the delete would remove without changing anything
+func (m *BulkDeleteModel) Preview() (err error) {
+
+	if m.Ib == 0 || !m.Selective() {
+		return e.ErrNotFound
+	}
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	return m.collect(dbase, "")
+
+}
+
+// Delete will mark the matched posts as deleted and the threads that are left
+// without posts, the deletion is recorded with the filter and every post
+func (m *BulkDeleteModel) Delete() (err error) {
+
+	// check model validity
+	if !m.IsValid() {
+		return errors.New("BulkDeleteModel is not valid")
+	}
+
+	// Get transaction handle
+	tx, err := db.GetTransaction()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	// lock the matched posts so the result is what gets deleted
+	err = m.collect(tx, " FOR UPDATE")
+	if err != nil {
+		return
+	}
+
+	posts := []interface{}{}
+	threads := []interface{}{m.Ib}
+
+	for _, thread := range m.Result.Threads {
+		for _, id := range thread.ids {
+			posts = append(posts, id)
+		}
+		if thread.Deleted {
+			threads = append(threads, thread.ID)
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE posts SET post_deleted = 1 WHERE post_id IN (`+placeholders(len(posts))+`)`, posts...)
+	if err != nil {
+		return
+	}
+
+	// the last post of a thread deletes the thread
+	if len(threads) > 1 {
+		_, err = tx.Exec(`UPDATE threads SET thread_deleted = 1
+    WHERE ib_id = ? AND thread_id IN (`+placeholders(len(threads)-1)+`)`, threads...)
+		if err != nil {
+			return
+		}
+	}
+
+	filter, err := json.Marshal(m.SearchFilter)
+	if err != nil {
+		return
+	}
+
+	detail, err := json.Marshal(m.Result)
+	if err != nil {
+		return
+	}
+
+	result, err := tx.Exec(`INSERT INTO bulk_deletes (ib_id,user_id,bulk_time,bulk_filter,bulk_posts,bulk_threads,bulk_detail)
+    VALUES (?,?,NOW(),?,?,?,?)`, m.Ib, m.User, string(filter), m.Result.Posts, len(threads)-1, string(detail))
+	if err != nil {
+		return
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return
+	}
+
+	m.ID = uint(id)
+
+	// Commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return
+	}
+
+	return
+
+}
+
+// collect gathers the undeleted posts that match the filter grouped by
+// thread and marks the threads they would empty
+func (m *BulkDeleteModel) collect(h handle, lock string) (err error) {
+
+	// deleted posts are left alone
+	filter := m.SearchFilter
+	deleted := false
+	filter.Deleted = &deleted
+
+	where, args := filter.Where()
+
+	rows, err := h.Query(`SELECT posts.post_id, threads.thread_id, thread_title, post_num
+    FROM threads
+    INNER JOIN posts ON threads.thread_id = posts.thread_id
+    WHERE `+where+`
+    ORDER BY threads.thread_id, post_num`+lock, args...)
+	if err != nil {
+		return searchError(err)
+	}
+	defer rows.Close()
+
+	response := BulkDeleteType{
+		Threads: []BulkThread{},
+	}
+
+	for rows.Next() {
+		var id, thread, num uint
+		var title string
+
+		err = rows.Scan(&id, &thread, &title, &num)
+		if err != nil {
+			return
+		}
+
+		response.Posts++
+
+		if response.Posts > BulkDeleteMax {
+			return ErrBulkTooLarge
+		}
+
+		// rows are ordered by thread so a new thread starts a new group
+		last := len(response.Threads) - 1
+		if last < 0 || response.Threads[last].ID != thread {
+			response.Threads = append(response.Threads, BulkThread{ID: thread, Title: title})
+			last++
+		}
+
+		response.Threads[last].Posts = append(response.Threads[last].Posts, num)
+		response.Threads[last].ids = append(response.Threads[last].ids, id)
+	}
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	if response.Posts == 0 {
+		return e.ErrNotFound
+	}
+
+	threads := []interface{}{}
+	for _, thread := range response.Threads {
+		threads = append(threads, thread.ID)
+	}
+
+	// the undeleted posts left in the threads
+	remaining, err := h.Query(`SELECT thread_id, COUNT(post_id) FROM posts
+    WHERE post_deleted = 0 AND thread_id IN (`+placeholders(len(threads))+`)
+    GROUP BY thread_id`, threads...)
+	if err != nil {
+		return
+	}
+	defer remaining.Close()
+
+	counts := make(map[uint]int)
+
+	for remaining.Next() {
+		var thread uint
+		var count int
+
+		err = remaining.Scan(&thread, &count)
+		if err != nil {
+			return
+		}
+
+		counts[thread] = count
+	}
+	if remaining.Err() != nil {
+		return remaining.Err()
+	}
+
+	for i := range response.Threads {
+		response.Threads[i].Deleted = counts[response.Threads[i].ID] == len(response.Threads[i].Posts)
+	}
+
+	m.Result = response
+
+	return
+
+}
+
+// placeholders returns n comma separated query placeholders
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
@@ -0,0 +1,216 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+)
+
+// the bulk delete match query
+const testBulkMatchQuery = `SELECT posts.post_id, threads.thread_id, thread_title, post_num\s+FROM threads`
+
+// the bulk delete remaining posts query
+const testBulkRemainingQuery = `SELECT thread_id, COUNT\(post_id\) FROM posts`
+
+func TestBulkDeleteModelPreview(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(testBulkMatchQuery).
+		WithArgs(1, "%spam%", "%spam%").
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "thread_id", "thread_title", "post_num"}).
+			AddRow(10, 2, "Spam Thread", 1).
+			AddRow(11, 2, "Spam Thread", 2).
+			AddRow(30, 4, "Good Thread", 7))
+
+	mock.ExpectQuery(testBulkRemainingQuery).
+		WithArgs(2, 4).
+		WillReturnRows(sqlmock.NewRows([]string{"thread_id", "count"}).
+			AddRow(2, 2).
+			AddRow(4, 9))
+
+	m := &BulkDeleteModel{
+		SearchFilter: SearchFilter{
+			Ib:    1,
+			Query: "spam",
+			Mode:  SearchSubstring,
+		},
+	}
+
+	err = m.Preview()
+	assert.NoError(t, err, "No error should be returned")
+
+	assert.Equal(t, uint(3), m.Result.Posts, "Post count should match")
+	if assert.Equal(t, 2, len(m.Result.Threads), "Should have two threads") {
+		assert.Equal(t, []uint{1, 2}, m.Result.Threads[0].Posts, "Post numbers should match")
+		assert.True(t, m.Result.Threads[0].Deleted, "Emptied thread should be deleted")
+		assert.False(t, m.Result.Threads[1].Deleted, "Thread with other posts should stay")
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestBulkDeleteModelPreviewNoMatches(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(testBulkMatchQuery).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "thread_id", "thread_title", "post_num"}))
+
+	m := &BulkDeleteModel{
+		SearchFilter: SearchFilter{
+			Ib:    1,
+			Query: "spam",
+		},
+	}
+
+	err = m.Preview()
+	assert.Equal(t, e.ErrNotFound, err, "Error should be ErrNotFound")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestBulkDeleteModelPreviewTooLarge(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	rows := sqlmock.NewRows([]string{"post_id", "thread_id", "thread_title", "post_num"})
+	for i := 1; i <= BulkDeleteMax+1; i++ {
+		rows.AddRow(i, 2, "Spam Thread", i)
+	}
+
+	mock.ExpectQuery(testBulkMatchQuery).
+		WillReturnRows(rows)
+
+	m := &BulkDeleteModel{
+		SearchFilter: SearchFilter{
+			Ib:    1,
+			Query: "spam",
+		},
+	}
+
+	err = m.Preview()
+	assert.Equal(t, ErrBulkTooLarge, err, "Error should be ErrBulkTooLarge")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestBulkDeleteModelDelete(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(testBulkMatchQuery+`[\s\S]+FOR UPDATE`).
+		WithArgs(1, "spam", "spam").
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "thread_id", "thread_title", "post_num"}).
+			AddRow(10, 2, "Spam Thread", 1).
+			AddRow(30, 4, "Good Thread", 7))
+	mock.ExpectQuery(testBulkRemainingQuery).
+		WithArgs(2, 4).
+		WillReturnRows(sqlmock.NewRows([]string{"thread_id", "count"}).
+			AddRow(2, 1).
+			AddRow(4, 9))
+	mock.ExpectExec(`UPDATE posts SET post_deleted = 1 WHERE post_id IN \(\?,\?\)`).
+		WithArgs(10, 30).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`UPDATE threads SET thread_deleted = 1\s+WHERE ib_id = \? AND thread_id IN \(\?\)`).
+		WithArgs(1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO bulk_deletes`).
+		WithArgs(1, 2, sqlmock.AnyArg(), 2, 1, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectCommit()
+
+	m := &BulkDeleteModel{
+		SearchFilter: SearchFilter{
+			Ib:    1,
+			Query: "spam",
+			Mode:  SearchRegex,
+		},
+		User: 2,
+	}
+
+	err = m.Delete()
+	assert.NoError(t, err, "No error should be returned")
+	assert.Equal(t, uint(5), m.ID, "Record id should be set")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestBulkDeleteModelDeleteInvalid(t *testing.T) {
+	m := &BulkDeleteModel{
+		SearchFilter: SearchFilter{
+			Query: "spam",
+		},
+		User: 2,
+	}
+
+	assert.Error(t, m.Delete(), "An error should be returned")
+}
+
+func TestBulkDeleteModelPreviewIP(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	// every undeleted post of the ip, without a text condition
+	mock.ExpectQuery(testBulkMatchQuery+`\s+INNER JOIN posts ON threads.thread_id = posts.thread_id\s+WHERE threads.ib_id = \? AND post_ip = \? AND post_deleted != 1\s+ORDER BY`).
+		WithArgs(1, "10.0.0.1").
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "thread_id", "thread_title", "post_num"}).
+			AddRow(10, 2, "Spam Thread", 1))
+
+	mock.ExpectQuery(testBulkRemainingQuery).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"thread_id", "count"}).
+			AddRow(2, 1))
+
+	m := &BulkDeleteModel{
+		SearchFilter: SearchFilter{
+			Ib: 1,
+			IP: "10.0.0.1",
+		},
+	}
+
+	assert.NoError(t, m.ValidateInput(), "An ip should be enough to select posts")
+
+	err = m.Preview()
+	assert.NoError(t, err, "No error should be returned")
+	assert.Equal(t, uint(1), m.Result.Posts, "Post count should match")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestBulkDeleteModelPreviewNothingSelected(t *testing.T) {
+	deleted := false
+
+	// the board and deleted state alone would select every post
+	m := &BulkDeleteModel{
+		SearchFilter: SearchFilter{
+			Ib:      1,
+			Deleted: &deleted,
+		},
+		User: 2,
+	}
+
+	assert.Equal(t, e.ErrInvalidParam, m.ValidateInput(), "Error should be ErrInvalidParam")
+	assert.Equal(t, e.ErrNotFound, m.Preview(), "Error should be ErrNotFound")
+	assert.False(t, m.IsValid(), "Model should not be valid")
+}
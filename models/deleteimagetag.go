@@ -48,8 +48,15 @@ func (m *DeleteImageTagModel) Status() (err error) {
 		return
 	}
 
+	return m.status(dbase)
+
+}
+
+// status is Status with the handle, so it can run in a batch
+func (m *DeleteImageTagModel) status(h handle) (err error) {
+
 	// Check if the tag is there
-	err = dbase.QueryRow("SELECT tag_name FROM tags WHERE tag_id = ? AND ib_id = ? LIMIT 1", m.Tag, m.Ib).Scan(&m.Name)
+	err = h.QueryRow("SELECT tag_name FROM tags WHERE tag_id = ? AND ib_id = ? LIMIT 1", m.Tag, m.Ib).Scan(&m.Name)
 	if err == sql.ErrNoRows {
 		return e.ErrNotFound
 	} else if err != nil {
@@ -63,18 +70,25 @@ func (m *DeleteImageTagModel) Status() (err error) {
 // Delete will remove the entry
 func (m *DeleteImageTagModel) Delete() (err error) {
 
-	// check model validity
-	if !m.IsValid() {
-		return errors.New("DeleteImageTagModel is not valid")
-	}
-
 	// Get Database handle
 	dbase, err := db.GetDb()
 	if err != nil {
 		return
 	}
 
-	ps1, err := dbase.Prepare(`DELETE tm FROM tagmap AS tm
+	return m.delete(dbase)
+
+}
+
+// delete is Delete with the handle, so it can run in a batch
+func (m *DeleteImageTagModel) delete(h handle) (err error) {
+
+	// check model validity
+	if !m.IsValid() {
+		return errors.New("DeleteImageTagModel is not valid")
+	}
+
+	ps1, err := h.Prepare(`DELETE tm FROM tagmap AS tm
     INNER JOIN tags ON tm.tag_id = tags.tag_id
     WHERE image_id = ? AND tm.tag_id = ? AND ib_id = ?`)
 	if err != nil {
@@ -49,10 +49,17 @@ func (m *DeletePostModel) Status() (err error) {
 		return
 	}
 
-	// get thread ib and title
-	err = dbase.QueryRow(`SELECT thread_title, post_deleted FROM threads
+	return m.status(dbase)
+
+}
+
+// status is Status with the handle, so it can run in a batch
+func (m *DeletePostModel) status(h handle) (err error) {
+
+	// get thread title and the deleted flag of the post
+	err = h.QueryRow(`SELECT thread_title, post_deleted FROM threads
 	INNER JOIN posts on threads.thread_id = posts.thread_id
-	WHERE threads.thread_id = ? AND ib_id = ? LIMIT 1`, m.Thread, m.Ib).Scan(&m.Name, &m.Deleted)
+	WHERE threads.thread_id = ? AND ib_id = ? AND post_num = ? LIMIT 1`, m.Thread, m.Ib, m.ID).Scan(&m.Name, &m.Deleted)
 	if err == sql.ErrNoRows {
 		return e.ErrNotFound
 	} else if err != nil {
@@ -78,10 +85,33 @@ func (m *DeletePostModel) Delete() (err error) {
 	}
 	defer tx.Rollback()
 
+	err = m.delete(tx)
+	if err != nil {
+		return
+	}
+
+	// Commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return
+	}
+
+	return
+
+}
+
+// delete is Delete in the transaction of the handle, so it can run in a batch
+func (m *DeletePostModel) delete(h handle) (err error) {
+
+	// check model validity
+	if !m.IsValid() {
+		return errors.New("DeletePostModel is not valid")
+	}
+
 	// If we're deleting a post (not undeleting), check if this is the only non-deleted post in the thread
 	if !m.Deleted {
 		var postCount int
-		err = tx.QueryRow(`SELECT COUNT(*) FROM posts
+		err = h.QueryRow(`SELECT COUNT(*) FROM posts
 		WHERE thread_id = ? AND post_deleted = 0`, m.Thread).Scan(&postCount)
 		if err != nil {
 			return
@@ -89,7 +119,7 @@ func (m *DeletePostModel) Delete() (err error) {
 
 		// If this is the only non-deleted post in the thread, also mark the thread as deleted
 		if postCount == 1 {
-			ps2, err := tx.Prepare(`UPDATE threads SET thread_deleted = 1
+			ps2, err := h.Prepare(`UPDATE threads SET thread_deleted = 1
 			WHERE thread_id = ? AND ib_id = ? LIMIT 1`)
 			if err != nil {
 				return err
@@ -104,7 +134,7 @@ func (m *DeletePostModel) Delete() (err error) {
 	}
 
 	// set post to deleted
-	ps1, err := tx.Prepare(`UPDATE posts SET post_deleted = ?
+	ps1, err := h.Prepare(`UPDATE posts SET post_deleted = ?
 	WHERE posts.thread_id = ? AND posts.post_num = ? LIMIT 1`)
 	if err != nil {
 		return
@@ -116,12 +146,6 @@ func (m *DeletePostModel) Delete() (err error) {
 		return
 	}
 
-	// Commit transaction
-	err = tx.Commit()
-	if err != nil {
-		return
-	}
-
 	return
 
 }
@@ -90,14 +90,15 @@ func TestDeletePostStatus(t *testing.T) {
 	// Initialize model with parameters
 	m := &DeletePostModel{
 		Thread: 1,
+		ID:     2,
 		Ib:     1,
 	}
 
 	// Status query successful
 	mock.ExpectQuery(`SELECT thread_title, post_deleted FROM threads
 		INNER JOIN posts on threads.thread_id = posts.thread_id
-		WHERE threads.thread_id = \? AND ib_id = \? LIMIT 1`).
-		WithArgs(m.Thread, m.Ib).
+		WHERE threads.thread_id = \? AND ib_id = \? AND post_num = \? LIMIT 1`).
+		WithArgs(m.Thread, m.Ib, m.ID).
 		WillReturnRows(sqlmock.NewRows([]string{"thread_title", "post_deleted"}).
 			AddRow("Test Thread", false))
 
@@ -123,14 +124,15 @@ func TestDeletePostStatusNotFound(t *testing.T) {
 	// Initialize model with parameters
 	m := &DeletePostModel{
 		Thread: 1,
+		ID:     2,
 		Ib:     1,
 	}
 
 	// Status query not found
 	mock.ExpectQuery(`SELECT thread_title, post_deleted FROM threads
 		INNER JOIN posts on threads.thread_id = posts.thread_id
-		WHERE threads.thread_id = \? AND ib_id = \? LIMIT 1`).
-		WithArgs(m.Thread, m.Ib).
+		WHERE threads.thread_id = \? AND ib_id = \? AND post_num = \? LIMIT 1`).
+		WithArgs(m.Thread, m.Ib, m.ID).
 		WillReturnError(sql.ErrNoRows)
 
 	// Get the status
@@ -152,6 +154,7 @@ func TestDeletePostStatusError(t *testing.T) {
 	// Initialize model with parameters
 	m := &DeletePostModel{
 		Thread: 1,
+		ID:     2,
 		Ib:     1,
 	}
 
@@ -159,8 +162,8 @@ func TestDeletePostStatusError(t *testing.T) {
 	expectedError := errors.New("database error")
 	mock.ExpectQuery(`SELECT thread_title, post_deleted FROM threads
 		INNER JOIN posts on threads.thread_id = posts.thread_id
-		WHERE threads.thread_id = \? AND ib_id = \? LIMIT 1`).
-		WithArgs(m.Thread, m.Ib).
+		WHERE threads.thread_id = \? AND ib_id = \? AND post_num = \? LIMIT 1`).
+		WithArgs(m.Thread, m.Ib, m.ID).
 		WillReturnError(expectedError)
 
 	// Get the status
@@ -44,8 +44,15 @@ func (m *DeleteThreadModel) Status() (err error) {
 		return
 	}
 
+	return m.status(dbase)
+
+}
+
+// status is Status with the handle, so it can run in a batch
+func (m *DeleteThreadModel) status(h handle) (err error) {
+
 	// Check if favorite is already there
-	err = dbase.QueryRow("SELECT thread_title, thread_deleted FROM threads WHERE thread_id = ? AND ib_id = ? LIMIT 1", m.ID, m.Ib).Scan(&m.Name, &m.Deleted)
+	err = h.QueryRow("SELECT thread_title, thread_deleted FROM threads WHERE thread_id = ? AND ib_id = ? LIMIT 1", m.ID, m.Ib).Scan(&m.Name, &m.Deleted)
 	if err == sql.ErrNoRows {
 		return e.ErrNotFound
 	} else if err != nil {
@@ -59,18 +66,25 @@ func (m *DeleteThreadModel) Status() (err error) {
 // Delete will remove the entry
 func (m *DeleteThreadModel) Delete() (err error) {
 
-	// check model validity
-	if !m.IsValid() {
-		return errors.New("DeleteThreadModel is not valid")
-	}
-
 	// Get Database handle
 	dbase, err := db.GetDb()
 	if err != nil {
 		return
 	}
 
-	ps1, err := dbase.Prepare("UPDATE threads SET thread_deleted = ? WHERE thread_id = ? AND ib_id = ?")
+	return m.delete(dbase)
+
+}
+
+// delete is Delete with the handle, so it can run in a batch
+func (m *DeleteThreadModel) delete(h handle) (err error) {
+
+	// check model validity
+	if !m.IsValid() {
+		return errors.New("DeleteThreadModel is not valid")
+	}
+
+	ps1, err := h.Prepare("UPDATE threads SET thread_deleted = ? WHERE thread_id = ? AND ib_id = ?")
 	if err != nil {
 		return
 	}
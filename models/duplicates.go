@@ -0,0 +1,89 @@
+package models
+
+import (
+	"time"
+
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+)
+
+// DuplicatesLimit is how many files the duplicate report returns
+const DuplicatesLimit = 50
+
+// DuplicatesModel holds request input
+type DuplicatesModel struct {
+	Ib     uint
+	Result DuplicatesType
+}
+
+// DuplicatesType is container for JSON response
+type DuplicatesType struct {
+	Body []Duplicate `json:"duplicates"`
+}
+
+// Duplicate is a file posted more than once on a board with its latest post
+type Duplicate struct {
+	Hash    string     `json:"image_hash"`
+	Copies  uint       `json:"copies"`
+	Threads uint       `json:"threads"`
+	Banned  bool       `json:"banned"`
+	Thread  uint       `json:"thread_id"`
+	Num     uint       `json:"post_num"`
+	Last    *time.Time `json:"post_time"`
+}
+
+// Get will return the most duplicated files of the board
+func (m *DuplicatesModel) Get() (err error) {
+
+	if m.Ib == 0 {
+		return e.ErrNotFound
+	}
+
+	// Initialize response header
+	response := DuplicatesType{
+		Body: []Duplicate{},
+	}
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	// the files posted more than once and their last post
+	rows, err := dbase.Query(`SELECT dupes.image_hash, dupes.copies, dupes.threads,
+    EXISTS(SELECT 1 FROM banned_files WHERE banned_files.ib_id = ? AND ban_hash = dupes.image_hash) AS banned,
+    posts.thread_id, posts.post_num, posts.post_time FROM (
+    SELECT image_hash, COUNT(image_id) AS copies, COUNT(DISTINCT threads.thread_id) AS threads, MAX(posts.post_id) AS last_post
+    FROM threads
+    INNER JOIN posts ON threads.thread_id = posts.thread_id
+    INNER JOIN images ON posts.post_id = images.post_id
+    WHERE ib_id = ? AND post_deleted != 1
+    GROUP BY image_hash HAVING copies > 1) AS dupes
+    INNER JOIN posts ON posts.post_id = dupes.last_post
+    ORDER BY dupes.copies DESC LIMIT ?`, m.Ib, m.Ib, DuplicatesLimit)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		dupe := Duplicate{}
+
+		err = rows.Scan(&dupe.Hash, &dupe.Copies, &dupe.Threads, &dupe.Banned, &dupe.Thread, &dupe.Num, &dupe.Last)
+		if err != nil {
+			return
+		}
+
+		response.Body = append(response.Body, dupe)
+	}
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	// This is the data we will serialize
+	m.Result = response
+
+	return
+
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/db"
+)
+
+func TestDuplicatesModelGet(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	posted := time.Date(2024, 3, 10, 15, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT dupes.image_hash, dupes.copies, dupes.threads`).
+		WithArgs(1, 1, DuplicatesLimit).
+		WillReturnRows(sqlmock.NewRows([]string{"image_hash", "copies", "threads", "banned", "thread_id", "post_num", "post_time"}).
+			AddRow("abcdef", 9, 4, true, 2, 5, posted).
+			AddRow("123456", 2, 1, false, 3, 7, posted))
+
+	m := &DuplicatesModel{
+		Ib: 1,
+	}
+
+	err = m.Get()
+	assert.NoError(t, err, "No error should be returned")
+
+	if assert.Equal(t, 2, len(m.Result.Body), "Should have two files") {
+		assert.Equal(t, uint(9), m.Result.Body[0].Copies, "Copies should match")
+		assert.True(t, m.Result.Body[0].Banned, "File should be banned")
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
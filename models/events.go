@@ -0,0 +1,61 @@
+package models
+
+import (
+	"database/sql"
+
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+
+	u "github.com/eirka/eirka-admin/utils"
+)
+
+// EventModel turns published audit entries into mod log entries
+type EventModel struct {
+	Ib    uint
+	users map[uint]Log
+}
+
+// Get will return the mod log entry for a published audit entry
+func (m *EventModel) Get(entry u.AuditEntry) (log Log, err error) {
+
+	if m.users == nil {
+		m.users = make(map[uint]Log)
+	}
+
+	// the user info is cached for the life of the stream
+	user, ok := m.users[entry.User]
+	if !ok {
+
+		// Get Database handle
+		dbase, err := db.GetDb()
+		if err != nil {
+			return log, err
+		}
+
+		// get the name and role like the mod log does
+		err = dbase.QueryRow(`SELECT users.user_id,user_name,
+    COALESCE((SELECT MAX(role_id) FROM user_ib_role_map WHERE user_ib_role_map.user_id = users.user_id AND ib_id = ?),user_role_map.role_id) as role
+    FROM users
+    INNER JOIN user_role_map ON (user_role_map.user_id = users.user_id)
+    WHERE users.user_id = ?`, m.Ib, entry.User).Scan(&user.UID, &user.Name, &user.Group)
+		if err == sql.ErrNoRows {
+			return log, e.ErrNotFound
+		} else if err != nil {
+			return log, err
+		}
+
+		m.users[entry.User] = user
+	}
+
+	log = Log{
+		UID:    user.UID,
+		Name:   user.Name,
+		Group:  user.Group,
+		Time:   &entry.Time,
+		Action: entry.Action,
+		Meta:   entry.Info,
+	}
+
+	return
+
+}
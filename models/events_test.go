@@ -0,0 +1,80 @@
+package models
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/audit"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+
+	u "github.com/eirka/eirka-admin/utils"
+)
+
+func TestEventModelGet(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	m := &EventModel{
+		Ib: 1,
+	}
+
+	entry := u.AuditEntry{
+		Audit: audit.Audit{
+			User:   2,
+			Ib:     1,
+			Type:   audit.ModLog,
+			IP:     "10.0.0.1",
+			Action: audit.AuditDeletePost,
+			Info:   "Thread/1",
+		},
+		Time: time.Now(),
+	}
+
+	// the user is only looked up once
+	mock.ExpectQuery(`SELECT users.user_id,user_name,(.+)WHERE users.user_id = \?`).
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "user_name", "role"}).
+			AddRow(2, "mod", 3))
+
+	for i := 0; i < 2; i++ {
+		log, err := m.Get(entry)
+		assert.NoError(t, err, "No error should be returned")
+
+		assert.Equal(t, uint(2), log.UID, "User id should match")
+		assert.Equal(t, "mod", log.Name, "User name should match")
+		assert.Equal(t, uint(3), log.Group, "User group should match")
+		assert.Equal(t, audit.AuditDeletePost, log.Action, "Action should match")
+		assert.Equal(t, "Thread/1", log.Meta, "Meta should match")
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestEventModelGetNotFound(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	m := &EventModel{
+		Ib: 1,
+	}
+
+	mock.ExpectQuery(`SELECT users.user_id,user_name,(.+)WHERE users.user_id = \?`).
+		WithArgs(1, 2).
+		WillReturnError(sql.ErrNoRows)
+
+	_, err = m.Get(u.AuditEntry{Audit: audit.Audit{User: 2}})
+	assert.Equal(t, e.ErrNotFound, err, "Error should be ErrNotFound")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
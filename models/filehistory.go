@@ -0,0 +1,193 @@
+package models
+
+import (
+	"encoding/hex"
+	"time"
+
+	"github.com/eirka/eirka-libs/config"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+
+	u "github.com/eirka/eirka-admin/utils"
+)
+
+// FileHistoryModel holds request input
+type FileHistoryModel struct {
+	Hash   string
+	Page   uint
+	Result FileHistoryType
+}
+
+// FileHistoryType is container for JSON response
+type FileHistoryType struct {
+	Banned bool            `json:"banned"`
+	Bans   []FileBan       `json:"bans"`
+	Body   u.PagedResponse `json:"posts"`
+}
+
+// FilePost is a post with the file on any board
+type FilePost struct {
+	Ib      uint       `json:"ib_id"`
+	Thread  uint       `json:"thread_id"`
+	Title   string     `json:"thread_title"`
+	Num     uint       `json:"post_num"`
+	Time    *time.Time `json:"post_time"`
+	Deleted bool       `json:"post_deleted"`
+	Image   uint       `json:"image_id"`
+}
+
+// FileBan is a ban on the file
+type FileBan struct {
+	Ib     uint   `json:"ib_id"`
+	UID    uint   `json:"user_id"`
+	Name   string `json:"user_name"`
+	Reason string `json:"ban_reason"`
+}
+
+// ValidateInput checks the hash is hex encoded
+func (m *FileHistoryModel) ValidateInput() (err error) {
+
+	if m.Hash == "" {
+		return e.ErrInvalidParam
+	}
+
+	_, err = hex.DecodeString(m.Hash)
+	if err != nil {
+		return e.ErrInvalidParam
+	}
+
+	return
+
+}
+
+// OnBoard checks the file was posted on the board, board moderators can only
+// look up the files their board has seen
+func (m *FileHistoryModel) OnBoard(ib uint) (err error) {
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	var count uint
+
+	err = dbase.QueryRow(`SELECT COUNT(image_id) FROM images
+    INNER JOIN posts ON images.post_id = posts.post_id
+    INNER JOIN threads ON posts.thread_id = threads.thread_id
+    WHERE ib_id = ? AND image_hash = ?`, ib, m.Hash).Scan(&count)
+	if err != nil {
+		return
+	}
+
+	if count == 0 {
+		return e.ErrNotFound
+	}
+
+	return
+
+}
+
+// Get will return the bans on the file and the posts using it on every board, the newest first
+func (m *FileHistoryModel) Get() (err error) {
+
+	if m.Hash == "" || m.Page == 0 {
+		return e.ErrNotFound
+	}
+
+	// Initialize response header
+	response := FileHistoryType{
+		Bans: []FileBan{},
+	}
+
+	// to hold the posts
+	posts := []FilePost{}
+
+	// Initialize struct for pagination
+	paged := u.PagedResponse{}
+	// Set current page to parameter
+	paged.CurrentPage = m.Page
+	// Set threads per index page to config setting
+	paged.PerPage = config.Settings.Limits.PostsPerPage
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	// Get total post count and put it in pagination struct
+	err = dbase.QueryRow("SELECT COUNT(image_id) FROM images WHERE image_hash = ?", m.Hash).Scan(&paged.Total)
+	if err != nil {
+		return
+	}
+
+	// Calculate Limit and total Pages
+	paged.Get()
+
+	// Return 404 if page requested is larger than actual pages
+	if m.Page > paged.Pages {
+		return e.ErrNotFound
+	}
+
+	rows, err := dbase.Query(`SELECT ib_id, threads.thread_id, thread_title, post_num, post_time, post_deleted, image_id
+    FROM images
+    INNER JOIN posts ON images.post_id = posts.post_id
+    INNER JOIN threads ON posts.thread_id = threads.thread_id
+    WHERE image_hash = ?
+    ORDER BY posts.post_id DESC LIMIT ?,?`, m.Hash, paged.Limit, paged.PerPage)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		post := FilePost{}
+
+		err = rows.Scan(&post.Ib, &post.Thread, &post.Title, &post.Num, &post.Time, &post.Deleted, &post.Image)
+		if err != nil {
+			return
+		}
+
+		posts = append(posts, post)
+	}
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	bans, err := dbase.Query(`SELECT ib_id, banned_files.user_id, user_name, ban_reason FROM banned_files
+    INNER JOIN users ON banned_files.user_id = users.user_id
+    WHERE ban_hash = ?`, m.Hash)
+	if err != nil {
+		return
+	}
+	defer bans.Close()
+
+	for bans.Next() {
+		ban := FileBan{}
+
+		err = bans.Scan(&ban.Ib, &ban.UID, &ban.Name, &ban.Reason)
+		if err != nil {
+			return
+		}
+
+		response.Bans = append(response.Bans, ban)
+	}
+	if bans.Err() != nil {
+		return bans.Err()
+	}
+
+	response.Banned = len(response.Bans) > 0
+
+	// Add posts slice to items interface
+	paged.Items = posts
+
+	// Add pagedresponse to the response struct
+	response.Body = paged
+
+	// This is the data we will serialize
+	m.Result = response
+
+	return
+
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/config"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+)
+
+func TestFileHistoryModelGet(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	config.Settings.Limits.PostsPerPage = 10
+
+	posted := time.Date(2024, 3, 10, 15, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT COUNT\(image_id\) FROM images WHERE image_hash = \?`).
+		WithArgs("abcdef").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	mock.ExpectQuery(`SELECT ib_id, threads.thread_id, thread_title, post_num, post_time, post_deleted, image_id`).
+		WithArgs("abcdef", 0, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id", "thread_id", "thread_title", "post_num", "post_time", "post_deleted", "image_id"}).
+			AddRow(1, 2, "Test Thread", 5, posted, false, 11).
+			AddRow(3, 8, "Other Board", 1, posted, true, 4))
+
+	mock.ExpectQuery(`SELECT ib_id, banned_files.user_id, user_name, ban_reason FROM banned_files`).
+		WithArgs("abcdef").
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id", "user_id", "user_name", "ban_reason"}).
+			AddRow(1, 2, "mod", "gore"))
+
+	m := &FileHistoryModel{
+		Hash: "abcdef",
+		Page: 1,
+	}
+
+	err = m.Get()
+	assert.NoError(t, err, "No error should be returned")
+
+	posts, ok := m.Result.Body.Items.([]FilePost)
+	if assert.True(t, ok, "Items should be posts") && assert.Equal(t, 2, len(posts), "Should have two posts") {
+		assert.Equal(t, uint(11), posts[0].Image, "Image should match")
+	}
+
+	assert.True(t, m.Result.Banned, "File should be banned")
+	assert.Equal(t, 1, len(m.Result.Bans), "Should have one ban")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestFileHistoryModelGetPageNotFound(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	config.Settings.Limits.PostsPerPage = 10
+
+	mock.ExpectQuery(`SELECT COUNT\(image_id\) FROM images WHERE image_hash = \?`).
+		WithArgs("abcdef").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	m := &FileHistoryModel{
+		Hash: "abcdef",
+		Page: 2,
+	}
+
+	err = m.Get()
+	assert.Equal(t, e.ErrNotFound, err, "Error should be ErrNotFound")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestFileHistoryModelValidateInput(t *testing.T) {
+	assert.NoError(t, (&FileHistoryModel{Hash: "d41d8cd98f00b204e9800998ecf8427e"}).ValidateInput(), "Hex hash should pass")
+	assert.Equal(t, e.ErrInvalidParam, (&FileHistoryModel{Hash: "not a hash"}).ValidateInput(), "Error should be ErrInvalidParam")
+	assert.Equal(t, e.ErrInvalidParam, (&FileHistoryModel{}).ValidateInput(), "Error should be ErrInvalidParam")
+}
@@ -0,0 +1,389 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/eirka/eirka-libs/config"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+)
+
+// the ways a filter pattern is matched
+const (
+	FilterLiteral = "literal"
+	FilterRegex   = "regex"
+)
+
+// what happens to a post that matches a filter
+const (
+	FilterBlock   = "block"
+	FilterReplace = "replace"
+	FilterFlag    = "flag"
+)
+
+// FilterMaxLength is the longest pattern or replacement a filter can have
+const FilterMaxLength = 255
+
+// ErrFilterPattern is returned when a regex filter does not compile
+var ErrFilterPattern = errors.New("filter pattern is not a valid regex")
+
+// Filter is a word or phrase filter that is applied to new posts
+type Filter struct {
+	ID          uint   `json:"filter_id"`
+	Pattern     string `json:"filter_pattern"`
+	Mode        string `json:"filter_mode"`
+	Action      string `json:"filter_action"`
+	Replacement string `json:"filter_replacement"`
+	Enabled     bool   `json:"filter_enabled"`
+}
+
+// Compile returns the expression that matches the filter, literal patterns
+// ignore case
+func (f *Filter) Compile() (*regexp.Regexp, error) {
+
+	if f.Mode == FilterLiteral {
+		return regexp.Compile("(?i)" + regexp.QuoteMeta(f.Pattern))
+	}
+
+	return regexp.Compile(f.Pattern)
+
+}
+
+// FiltersModel holds request input
+type FiltersModel struct {
+	Ib     uint
+	Result FiltersType
+}
+
+// FiltersType is container for JSON response
+type FiltersType struct {
+	Body []Filter `json:"filters"`
+}
+
+// Get will return the filters of the board in the order they are applied
+func (m *FiltersModel) Get() (err error) {
+
+	if m.Ib == 0 {
+		return e.ErrNotFound
+	}
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	filters, err := boardFilters(dbase, m.Ib, false)
+	if err != nil {
+		return
+	}
+
+	// This is the data we will serialize
+	m.Result = FiltersType{Body: filters}
+
+	return
+
+}
+
+// boardFilters returns the filters of a board by id
+func boardFilters(h handle, ib uint, enabled bool) (filters []Filter, err error) {
+
+	filters = []Filter{}
+
+	query := `SELECT filter_id, filter_pattern, filter_mode, filter_action, filter_replacement, filter_enabled
+    FROM filters WHERE ib_id = ?`
+	if enabled {
+		query += " AND filter_enabled = 1"
+	}
+
+	rows, err := h.Query(query+" ORDER BY filter_id ASC", ib)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		filter := Filter{}
+
+		err = rows.Scan(&filter.ID, &filter.Pattern, &filter.Mode, &filter.Action, &filter.Replacement, &filter.Enabled)
+		if err != nil {
+			return
+		}
+
+		filters = append(filters, filter)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return
+
+}
+
+// FilterModel holds request input
+type FilterModel struct {
+	Ib   uint
+	User uint
+	Filter
+}
+
+// IsValid will check struct validity
+func (m *FilterModel) IsValid() bool {
+
+	if m.Ib == 0 {
+		return false
+	}
+
+	if m.User == 0 || m.User == 1 {
+		return false
+	}
+
+	if m.Pattern == "" {
+		return false
+	}
+
+	if m.Mode == "" {
+		return false
+	}
+
+	if m.Action == "" {
+		return false
+	}
+
+	return true
+
+}
+
+// ValidateInput checks the data input for correctness
+func (m *FilterModel) ValidateInput() (err error) {
+
+	if m.Mode == "" {
+		m.Mode = FilterLiteral
+	}
+
+	if m.Pattern == "" || len(m.Pattern) > FilterMaxLength {
+		return e.ErrInvalidParam
+	}
+
+	if len(m.Replacement) > FilterMaxLength {
+		return e.ErrInvalidParam
+	}
+
+	switch m.Mode {
+	case FilterLiteral, FilterRegex:
+	default:
+		return e.ErrInvalidParam
+	}
+
+	switch m.Action {
+	case FilterBlock, FilterFlag:
+		// only replace uses the replacement
+		m.Replacement = ""
+	case FilterReplace:
+	default:
+		return e.ErrInvalidParam
+	}
+
+	// a broken pattern would never match when posting
+	_, err = m.Compile()
+	if err != nil {
+		return ErrFilterPattern
+	}
+
+	return
+
+}
+
+// Status will return the saved filter
+func (m *FilterModel) Status() (err error) {
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	err = dbase.QueryRow(`SELECT filter_pattern, filter_mode, filter_action, filter_replacement, filter_enabled
+    FROM filters WHERE ib_id = ? AND filter_id = ?`,
+		m.Ib, m.ID).Scan(&m.Pattern, &m.Mode, &m.Action, &m.Replacement, &m.Enabled)
+	if err == sql.ErrNoRows {
+		return e.ErrNotFound
+	} else if err != nil {
+		return
+	}
+
+	return
+
+}
+
+// Post will add the filter to the board
+func (m *FilterModel) Post() (err error) {
+
+	// check model validity
+	if !m.IsValid() {
+		return errors.New("FilterModel is not valid")
+	}
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	result, err := dbase.Exec(`INSERT INTO filters (ib_id,user_id,filter_pattern,filter_mode,filter_action,filter_replacement,filter_enabled,filter_time)
+    VALUES (?,?,?,?,?,?,?,NOW())`, m.Ib, m.User, m.Pattern, m.Mode, m.Action, m.Replacement, m.Enabled)
+	if err != nil {
+		return
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return
+	}
+
+	m.ID = uint(id)
+
+	return
+
+}
+
+// Update will change the filter
+func (m *FilterModel) Update() (err error) {
+
+	// check model validity
+	if !m.IsValid() || m.ID == 0 {
+		return errors.New("FilterModel is not valid")
+	}
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	_, err = dbase.Exec(`UPDATE filters SET user_id = ?, filter_pattern = ?, filter_mode = ?, filter_action = ?,
+    filter_replacement = ?, filter_enabled = ?, filter_time = NOW() WHERE ib_id = ? AND filter_id = ?`,
+		m.User, m.Pattern, m.Mode, m.Action, m.Replacement, m.Enabled, m.Ib, m.ID)
+	if err != nil {
+		return
+	}
+
+	return
+
+}
+
+// Delete will remove the filter
+func (m *FilterModel) Delete() (err error) {
+
+	if m.Ib == 0 || m.ID == 0 {
+		return errors.New("FilterModel is not valid")
+	}
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	_, err = dbase.Exec("DELETE FROM filters WHERE ib_id = ? AND filter_id = ?", m.Ib, m.ID)
+	if err != nil {
+		return
+	}
+
+	return
+
+}
+
+// FilterTestModel holds request input
+type FilterTestModel struct {
+	Ib     uint
+	Text   string
+	Result FilterTestType
+}
+
+// FilterTestType is container for JSON response
+type FilterTestType struct {
+	Text    string        `json:"text"`
+	Blocked bool          `json:"blocked"`
+	Flagged bool          `json:"flagged"`
+	Matches []FilterMatch `json:"matches"`
+}
+
+// FilterMatch is a filter that matched the text
+type FilterMatch struct {
+	ID     uint   `json:"filter_id"`
+	Action string `json:"filter_action"`
+	Count  int    `json:"count"`
+}
+
+// ValidateInput checks the data input for correctness
+func (m *FilterTestModel) ValidateInput() (err error) {
+
+	if m.Text == "" {
+		return e.ErrNoComment
+	}
+
+	if len(m.Text) > config.Settings.Limits.CommentMaxLength {
+		return e.ErrCommentLong
+	}
+
+	return
+
+}
+
+// Get will run the text through the enabled filters of the board in order,
+// replacements are seen by the filters after them
+func (m *FilterTestModel) Get() (err error) {
+
+	if m.Ib == 0 || m.Text == "" {
+		return e.ErrNotFound
+	}
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	filters, err := boardFilters(dbase, m.Ib, true)
+	if err != nil {
+		return
+	}
+
+	response := FilterTestType{
+		Text:    m.Text,
+		Matches: []FilterMatch{},
+	}
+
+	for _, filter := range filters {
+		re, err := filter.Compile()
+		if err != nil {
+			return fmt.Errorf("filter %d: %w", filter.ID, err)
+		}
+
+		found := re.FindAllStringIndex(response.Text, -1)
+		if len(found) == 0 {
+			continue
+		}
+
+		response.Matches = append(response.Matches, FilterMatch{ID: filter.ID, Action: filter.Action, Count: len(found)})
+
+		switch filter.Action {
+		case FilterBlock:
+			response.Blocked = true
+		case FilterFlag:
+			response.Flagged = true
+		case FilterReplace:
+			response.Text = re.ReplaceAllLiteralString(response.Text, filter.Replacement)
+		}
+	}
+
+	// This is the data we will serialize
+	m.Result = response
+
+	return
+
+}
@@ -0,0 +1,162 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/config"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+)
+
+// the board filters query
+const testFiltersQuery = `SELECT filter_id, filter_pattern, filter_mode, filter_action, filter_replacement, filter_enabled\s+FROM filters`
+
+var testFilterColumns = []string{"filter_id", "filter_pattern", "filter_mode", "filter_action", "filter_replacement", "filter_enabled"}
+
+func TestFilterCompile(t *testing.T) {
+	literal := &Filter{Pattern: "a.b", Mode: FilterLiteral}
+
+	re, err := literal.Compile()
+	if assert.NoError(t, err, "No error should be returned") {
+		assert.True(t, re.MatchString("xA.By"), "Literal should ignore case")
+		assert.False(t, re.MatchString("axb"), "Literal should not treat the dot as a wildcard")
+	}
+
+	regex := &Filter{Pattern: "a.b", Mode: FilterRegex}
+
+	re, err = regex.Compile()
+	if assert.NoError(t, err, "No error should be returned") {
+		assert.True(t, re.MatchString("axb"), "Regex should use the wildcard")
+	}
+}
+
+func TestFilterModelValidateInput(t *testing.T) {
+	tests := []struct {
+		filter Filter
+		err    error
+	}{
+		{Filter{Pattern: "spam", Action: FilterBlock}, nil},
+		{Filter{Pattern: "(spam", Mode: FilterLiteral, Action: FilterFlag}, nil},
+		{Filter{Pattern: "sp[a-z]+m", Mode: FilterRegex, Action: FilterReplace, Replacement: "ham"}, nil},
+		{Filter{Pattern: "(spam", Mode: FilterRegex, Action: FilterBlock}, ErrFilterPattern},
+		{Filter{Pattern: "", Action: FilterBlock}, e.ErrInvalidParam},
+		{Filter{Pattern: "spam", Mode: "glob", Action: FilterBlock}, e.ErrInvalidParam},
+		{Filter{Pattern: "spam", Action: "hide"}, e.ErrInvalidParam},
+	}
+
+	for _, test := range tests {
+		m := &FilterModel{Filter: test.filter}
+		assert.Equal(t, test.err, m.ValidateInput(), "Error should match for %q", test.filter.Pattern)
+	}
+
+	m := &FilterModel{Filter: Filter{Pattern: "spam", Action: FilterBlock, Replacement: "ham"}}
+	assert.NoError(t, m.ValidateInput(), "No error should be returned")
+	assert.Equal(t, FilterLiteral, m.Mode, "Mode should default to literal")
+	assert.Empty(t, m.Replacement, "Block should not keep a replacement")
+}
+
+func TestFiltersModelGet(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(testFiltersQuery).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(testFilterColumns).
+			AddRow(1, "spam", FilterLiteral, FilterBlock, "", true).
+			AddRow(2, "ham", FilterLiteral, FilterFlag, "", false))
+
+	m := &FiltersModel{Ib: 1}
+
+	err = m.Get()
+	assert.NoError(t, err, "No error should be returned")
+	if assert.Equal(t, 2, len(m.Result.Body), "Should have two filters") {
+		assert.False(t, m.Result.Body[1].Enabled, "Disabled filters should be listed")
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestFilterModelPost(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectExec(`INSERT INTO filters`).
+		WithArgs(1, 2, "spam", FilterLiteral, FilterReplace, "ham", true).
+		WillReturnResult(sqlmock.NewResult(4, 1))
+
+	m := &FilterModel{
+		Ib:     1,
+		User:   2,
+		Filter: Filter{Pattern: "spam", Mode: FilterLiteral, Action: FilterReplace, Replacement: "ham", Enabled: true},
+	}
+
+	err = m.Post()
+	assert.NoError(t, err, "No error should be returned")
+	assert.Equal(t, uint(4), m.ID, "ID should be set")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestFilterModelPostInvalid(t *testing.T) {
+	m := &FilterModel{
+		Ib:     1,
+		User:   1,
+		Filter: Filter{Pattern: "spam", Mode: FilterLiteral, Action: FilterBlock},
+	}
+
+	assert.Error(t, m.Post(), "An error should be returned")
+}
+
+func TestFilterTestModelGet(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(testFiltersQuery + ` WHERE ib_id = \? AND filter_enabled = 1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(testFilterColumns).
+			AddRow(1, "spam", FilterLiteral, FilterReplace, "ham", true).
+			AddRow(2, `ham\s+and`, FilterRegex, FilterFlag, "", true).
+			AddRow(3, "buy now", FilterLiteral, FilterBlock, "", true))
+
+	m := &FilterTestModel{Ib: 1, Text: "SPAM spam and eggs"}
+
+	err = m.Get()
+	assert.NoError(t, err, "No error should be returned")
+
+	assert.Equal(t, "ham ham and eggs", m.Result.Text, "Text should be replaced")
+	assert.False(t, m.Result.Blocked, "Text should not be blocked")
+	assert.True(t, m.Result.Flagged, "Later filters should see the replaced text")
+	assert.Equal(t, []FilterMatch{{ID: 1, Action: FilterReplace, Count: 2}, {ID: 2, Action: FilterFlag, Count: 1}},
+		m.Result.Matches, "Matches should match")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestFilterTestModelValidateInput(t *testing.T) {
+	originalMax := config.Settings.Limits.CommentMaxLength
+	config.Settings.Limits.CommentMaxLength = 10
+	defer func() {
+		config.Settings.Limits.CommentMaxLength = originalMax
+	}()
+
+	m := &FilterTestModel{Text: "spam"}
+	assert.NoError(t, m.ValidateInput(), "No error should be returned")
+
+	m = &FilterTestModel{Text: ""}
+	assert.Equal(t, e.ErrNoComment, m.ValidateInput(), "Empty text should be rejected")
+
+	m = &FilterTestModel{Text: "spam spam spam"}
+	assert.Equal(t, e.ErrCommentLong, m.ValidateInput(), "Long text should be rejected")
+}
@@ -0,0 +1,274 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/eirka/eirka-libs/audit"
+	"github.com/eirka/eirka-libs/db"
+
+	local "github.com/eirka/eirka-admin/config"
+	u "github.com/eirka/eirka-admin/utils"
+)
+
+// the kinds of flood alerts
+const (
+	AlertIP   = "ip"
+	AlertFile = "file"
+)
+
+// floodAuditIP is the audit ip of automatic bans, they are made by the server
+const floodAuditIP = "127.0.0.1"
+
+// FloodModel holds the thresholds a board is scanned with
+type FloodModel struct {
+	Ib         uint
+	BanUser    uint
+	Thresholds local.FloodThresholds
+	Result     []Alert
+}
+
+// DetectFlood will scan the recent posts of every board and flag floods
+func DetectFlood() {
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	rows, err := dbase.Query("SELECT ib_id FROM imageboards")
+	if err != nil {
+		return
+	}
+
+	boards := []uint{}
+
+	for rows.Next() {
+		var ib uint
+
+		err = rows.Scan(&ib)
+		if err != nil {
+			rows.Close()
+			return
+		}
+
+		boards = append(boards, ib)
+	}
+	rows.Close()
+
+	for _, ib := range boards {
+		m := &FloodModel{
+			Ib:         ib,
+			BanUser:    local.Settings.Flood.BanUser,
+			Thresholds: local.Settings.Flood.Thresholds(ib),
+		}
+
+		// a failed board is scanned again on the next run
+		err = m.Detect()
+		if err != nil {
+			continue
+		}
+	}
+
+}
+
+// Detect will flag the ips and files that crossed the thresholds and ban them
+// if they crossed the hard thresholds
+func (m *FloodModel) Detect() (err error) {
+
+	if m.Thresholds.Minutes == 0 {
+		return
+	}
+
+	since := time.Now().Add(-time.Duration(m.Thresholds.Minutes) * time.Minute)
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	if m.Thresholds.IPPosts != 0 {
+		// the ips with too many posts and their last post
+		rows, err := dbase.Query(`SELECT flood.post_ip, flood.posts, posts.thread_id, posts.post_num FROM (
+    SELECT post_ip, COUNT(*) AS posts, MAX(post_id) AS last_post
+    FROM threads
+    INNER JOIN posts ON threads.thread_id = posts.thread_id
+    WHERE ib_id = ? AND post_time > ?
+    GROUP BY post_ip HAVING posts >= ?) AS flood
+    INNER JOIN posts ON posts.post_id = flood.last_post`, m.Ib, since, m.Thresholds.IPPosts)
+		if err != nil {
+			return err
+		}
+
+		err = m.scan(rows, AlertIP)
+		if err != nil {
+			return err
+		}
+	}
+
+	if m.Thresholds.HashThreads != 0 {
+		// the files posted in too many threads and their last post
+		rows, err := dbase.Query(`SELECT flood.image_hash, flood.threads, posts.thread_id, posts.post_num FROM (
+    SELECT image_hash, COUNT(DISTINCT threads.thread_id) AS threads, MAX(posts.post_id) AS last_post
+    FROM threads
+    INNER JOIN posts ON threads.thread_id = posts.thread_id
+    INNER JOIN images ON posts.post_id = images.post_id
+    WHERE ib_id = ? AND post_time > ?
+    GROUP BY image_hash HAVING threads >= ?) AS flood
+    INNER JOIN posts ON posts.post_id = flood.last_post`, m.Ib, since, m.Thresholds.HashThreads)
+		if err != nil {
+			return err
+		}
+
+		err = m.scan(rows, AlertFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := range m.Result {
+		alert := &m.Result[i]
+
+		if m.banned(alert) {
+			alert.Banned, err = m.ban(alert)
+			if err != nil {
+				return
+			}
+		}
+
+		_, err = dbase.Exec(`INSERT INTO alerts (ib_id,alert_type,alert_value,alert_count,thread_id,post_num,alert_banned,alert_time)
+    VALUES (?,?,?,?,?,?,?,NOW())
+    ON DUPLICATE KEY UPDATE alert_count = VALUES(alert_count), thread_id = VALUES(thread_id), post_num = VALUES(post_num),
+    alert_banned = alert_banned OR VALUES(alert_banned), alert_time = VALUES(alert_time)`,
+			m.Ib, alert.Type, alert.Value, alert.Count, alert.Thread, alert.Post, alert.Banned)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+
+}
+
+// scan adds the flagged rows to the result
+func (m *FloodModel) scan(rows *sql.Rows, kind string) (err error) {
+	defer rows.Close()
+
+	for rows.Next() {
+		alert := Alert{Type: kind}
+
+		err = rows.Scan(&alert.Value, &alert.Count, &alert.Thread, &alert.Post)
+		if err != nil {
+			return
+		}
+
+		m.Result = append(m.Result, alert)
+	}
+
+	return rows.Err()
+
+}
+
+// banned returns true if the alert crossed the hard threshold of its kind
+func (m *FloodModel) banned(alert *Alert) bool {
+
+	// bans need a user to be recorded under
+	if m.BanUser == 0 {
+		return false
+	}
+
+	switch alert.Type {
+	case AlertIP:
+		return m.Thresholds.BanIPPosts != 0 && alert.Count >= m.Thresholds.BanIPPosts
+	case AlertFile:
+		return m.Thresholds.BanHashThreads != 0 && alert.Count >= m.Thresholds.BanHashThreads
+	}
+
+	return false
+
+}
+
+// ban applies the ban of the alert with the regular ban models, values that
+// are already banned are skipped so the job does not ban them on every run
+func (m *FloodModel) ban(alert *Alert) (banned bool, err error) {
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	// audit log
+	entry := audit.Audit{
+		User: m.BanUser,
+		Ib:   m.Ib,
+		Type: audit.ModLog,
+		IP:   floodAuditIP,
+	}
+
+	var count uint
+
+	switch alert.Type {
+	case AlertIP:
+		// a temporary ban is still made permanent
+		err = dbase.QueryRow("SELECT COUNT(*) FROM banned_ips WHERE ib_id = ? AND ban_ip = ? AND ban_expires IS NULL",
+			m.Ib, alert.Value).Scan(&count)
+		if err != nil || count != 0 {
+			return count != 0, err
+		}
+
+		ban := BanIPModel{
+			Ib:     m.Ib,
+			Thread: alert.Thread,
+			ID:     alert.Post,
+			User:   m.BanUser,
+			Reason: fmt.Sprintf("Flood detected: %d posts in %d minutes", alert.Count, m.Thresholds.Minutes),
+			IP:     alert.Value,
+		}
+
+		err = ban.Post()
+		if err != nil {
+			return
+		}
+
+		// ban the ip in cloudflare
+		go u.CloudFlareBanIP(ban.IP, ban.Reason)
+
+		entry.Action = audit.AuditBanIP
+		entry.Info = ban.Reason
+
+	case AlertFile:
+		err = dbase.QueryRow("SELECT COUNT(*) FROM banned_files WHERE ib_id = ? AND ban_hash = ?",
+			m.Ib, alert.Value).Scan(&count)
+		if err != nil || count != 0 {
+			return count != 0, err
+		}
+
+		ban := BanFileModel{
+			Ib:     m.Ib,
+			Thread: alert.Thread,
+			ID:     alert.Post,
+			User:   m.BanUser,
+			Reason: fmt.Sprintf("Flood detected: file posted in %d threads in %d minutes", alert.Count, m.Thresholds.Minutes),
+			Hash:   alert.Value,
+		}
+
+		err = ban.Post()
+		if err != nil {
+			return
+		}
+
+		entry.Action = audit.AuditBanFile
+		entry.Info = ban.Reason
+	}
+
+	// submit audit, undelivered entries are kept in the outbox and the ban
+	// stands either way
+	u.SubmitPostAudit(entry, alert.Thread, alert.Post)
+
+	return true, nil
+
+}
@@ -0,0 +1,303 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/audit"
+	"github.com/eirka/eirka-libs/db"
+
+	local "github.com/eirka/eirka-admin/config"
+)
+
+// the flood queries
+const (
+	testFloodIPQuery    = `SELECT flood.post_ip, flood.posts, posts.thread_id, posts.post_num FROM \(`
+	testFloodFileQuery  = `SELECT flood.image_hash, flood.threads, posts.thread_id, posts.post_num FROM \(`
+	testFloodAlertQuery = `INSERT INTO alerts \(ib_id,alert_type,alert_value,alert_count,thread_id,post_num,alert_banned,alert_time\)`
+)
+
+// the checks for bans that are already in place
+const (
+	testFloodBannedIPQuery   = `SELECT COUNT\(\*\) FROM banned_ips WHERE ib_id = \? AND ban_ip = \? AND ban_expires IS NULL`
+	testFloodBannedFileQuery = `SELECT COUNT\(\*\) FROM banned_files WHERE ib_id = \? AND ban_hash = \?`
+)
+
+// testFloodAudit expects the audit entry of an automatic ban
+func testFloodAudit(mock sqlmock.Sqlmock, action, info string, thread, post uint) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT ib_id FROM imageboards WHERE ib_id = \? FOR UPDATE`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id"}).AddRow(1))
+	mock.ExpectQuery(`SELECT audit_hash FROM audit`).
+		WithArgs(1, audit.ModLog).
+		WillReturnRows(sqlmock.NewRows([]string{"audit_hash"}).AddRow("previous"))
+	mock.ExpectExec(`INSERT INTO audit`).
+		WithArgs(2, 1, audit.ModLog, "127.0.0.1", sqlmock.AnyArg(), action, info, thread, post, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+}
+
+func TestFloodThresholds(t *testing.T) {
+	flood := local.Flood{
+		Default: local.FloodThresholds{Minutes: 10, IPPosts: 20},
+		Boards: map[uint]local.FloodThresholds{
+			2: {Minutes: 5, IPPosts: 5},
+		},
+	}
+
+	assert.Equal(t, uint(20), flood.Thresholds(1).IPPosts, "Boards without overrides should use the default")
+	assert.Equal(t, uint(5), flood.Thresholds(2).IPPosts, "Board overrides should be used")
+}
+
+func TestFloodModelDetect(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	m := &FloodModel{
+		Ib: 1,
+		Thresholds: local.FloodThresholds{
+			Minutes:     10,
+			IPPosts:     20,
+			HashThreads: 3,
+		},
+	}
+
+	mock.ExpectQuery(testFloodIPQuery).
+		WithArgs(1, sqlmock.AnyArg(), 20).
+		WillReturnRows(sqlmock.NewRows([]string{"post_ip", "posts", "thread_id", "post_num"}).
+			AddRow("10.0.0.1", 25, 2, 40))
+
+	mock.ExpectQuery(testFloodFileQuery).
+		WithArgs(1, sqlmock.AnyArg(), 3).
+		WillReturnRows(sqlmock.NewRows([]string{"image_hash", "threads", "thread_id", "post_num"}).
+			AddRow("abc123", 4, 5, 2))
+
+	// flagged without bans
+	mock.ExpectExec(testFloodAlertQuery).
+		WithArgs(1, AlertIP, "10.0.0.1", 25, 2, 40, false).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec(testFloodAlertQuery).
+		WithArgs(1, AlertFile, "abc123", 4, 5, 2, false).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+
+	err = m.Detect()
+	assert.NoError(t, err, "No error should be returned")
+
+	assert.Equal(t, 2, len(m.Result), "Both floods should be flagged")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestFloodModelDetectBan(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	m := &FloodModel{
+		Ib:      1,
+		BanUser: 2,
+		Thresholds: local.FloodThresholds{
+			Minutes:    10,
+			IPPosts:    20,
+			BanIPPosts: 50,
+		},
+	}
+
+	mock.ExpectQuery(testFloodIPQuery).
+		WithArgs(1, sqlmock.AnyArg(), 20).
+		WillReturnRows(sqlmock.NewRows([]string{"post_ip", "posts", "thread_id", "post_num"}).
+			AddRow("10.0.0.1", 60, 2, 40).
+			AddRow("10.0.0.2", 30, 3, 1))
+
+	// the first ip crossed the hard threshold
+	mock.ExpectQuery(testFloodBannedIPQuery).
+		WithArgs(1, "10.0.0.1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	mock.ExpectExec(`INSERT INTO banned_ips \(user_id,ib_id,ban_ip,ban_reason,ban_expires,rule_id\) VALUES \(\?,\?,\?,\?,\?,\?\)`).
+		WithArgs(2, 1, "10.0.0.1", "Flood detected: 60 posts in 10 minutes", nil, 0).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	testFloodAudit(mock, audit.AuditBanIP, "Flood detected: 60 posts in 10 minutes", 2, 40)
+
+	mock.ExpectExec(testFloodAlertQuery).
+		WithArgs(1, AlertIP, "10.0.0.1", 60, 2, 40, true).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec(testFloodAlertQuery).
+		WithArgs(1, AlertIP, "10.0.0.2", 30, 3, 1, false).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+
+	err = m.Detect()
+	assert.NoError(t, err, "No error should be returned")
+
+	if assert.Equal(t, 2, len(m.Result), "Both ips should be flagged") {
+		assert.True(t, m.Result[0].Banned, "First ip should be banned")
+		assert.False(t, m.Result[1].Banned, "Second ip should not be banned")
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestFloodModelDetectAlreadyBanned(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	m := &FloodModel{
+		Ib:      1,
+		BanUser: 2,
+		Thresholds: local.FloodThresholds{
+			Minutes:        10,
+			IPPosts:        20,
+			BanIPPosts:     50,
+			HashThreads:    3,
+			BanHashThreads: 5,
+		},
+	}
+
+	mock.ExpectQuery(testFloodIPQuery).
+		WithArgs(1, sqlmock.AnyArg(), 20).
+		WillReturnRows(sqlmock.NewRows([]string{"post_ip", "posts", "thread_id", "post_num"}).
+			AddRow("10.0.0.1", 60, 2, 40))
+
+	mock.ExpectQuery(testFloodFileQuery).
+		WithArgs(1, sqlmock.AnyArg(), 3).
+		WillReturnRows(sqlmock.NewRows([]string{"image_hash", "threads", "thread_id", "post_num"}).
+			AddRow("abc123", 6, 5, 2))
+
+	// both were banned on an earlier run so nothing is banned or audited again
+	mock.ExpectQuery(testFloodBannedIPQuery).
+		WithArgs(1, "10.0.0.1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	mock.ExpectExec(testFloodAlertQuery).
+		WithArgs(1, AlertIP, "10.0.0.1", 60, 2, 40, true).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectQuery(testFloodBannedFileQuery).
+		WithArgs(1, "abc123").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	mock.ExpectExec(testFloodAlertQuery).
+		WithArgs(1, AlertFile, "abc123", 6, 5, 2, true).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+
+	err = m.Detect()
+	assert.NoError(t, err, "No error should be returned")
+
+	if assert.Equal(t, 2, len(m.Result), "Both floods should be flagged") {
+		assert.True(t, m.Result[0].Banned, "Ip should stay banned")
+		assert.True(t, m.Result[1].Banned, "File should stay banned")
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestFloodModelDetectBanFile(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	m := &FloodModel{
+		Ib:      1,
+		BanUser: 2,
+		Thresholds: local.FloodThresholds{
+			Minutes:        10,
+			HashThreads:    3,
+			BanHashThreads: 5,
+		},
+	}
+
+	mock.ExpectQuery(testFloodFileQuery).
+		WithArgs(1, sqlmock.AnyArg(), 3).
+		WillReturnRows(sqlmock.NewRows([]string{"image_hash", "threads", "thread_id", "post_num"}).
+			AddRow("abc123", 6, 5, 2))
+
+	mock.ExpectQuery(testFloodBannedFileQuery).
+		WithArgs(1, "abc123").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	mock.ExpectExec(`INSERT IGNORE INTO banned_files`).
+		WithArgs(2, 1, "abc123", "Flood detected: file posted in 6 threads in 10 minutes", 0).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	testFloodAudit(mock, audit.AuditBanFile, "Flood detected: file posted in 6 threads in 10 minutes", 5, 2)
+
+	mock.ExpectExec(testFloodAlertQuery).
+		WithArgs(1, AlertFile, "abc123", 6, 5, 2, true).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = m.Detect()
+	assert.NoError(t, err, "No error should be returned")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestFloodModelDetectNoBanUser(t *testing.T) {
+	m := &FloodModel{
+		Thresholds: local.FloodThresholds{
+			BanIPPosts: 50,
+		},
+	}
+
+	assert.False(t, m.banned(&Alert{Type: AlertIP, Count: 100}), "Bans should need a ban user")
+}
+
+func TestFloodModelDetectDisabled(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	// no window means nothing is scanned
+	m := &FloodModel{
+		Ib: 1,
+	}
+
+	err = m.Detect()
+	assert.NoError(t, err, "No error should be returned")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestFloodModelDetectError(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	m := &FloodModel{
+		Ib: 1,
+		Thresholds: local.FloodThresholds{
+			Minutes: 10,
+			IPPosts: 20,
+		},
+	}
+
+	mock.ExpectQuery(testFloodIPQuery).
+		WillReturnError(errors.New("database error"))
+
+	err = m.Detect()
+	if assert.Error(t, err, "Error should be returned") {
+		assert.Contains(t, err.Error(), "database error", "Error should contain the expected error message")
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
@@ -0,0 +1,157 @@
+package models
+
+import (
+	"net"
+	"time"
+
+	"github.com/eirka/eirka-libs/config"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+
+	u "github.com/eirka/eirka-admin/utils"
+)
+
+// IPHistoryModel holds request input
+type IPHistoryModel struct {
+	IP     string
+	Page   uint
+	Result IPHistoryType
+}
+
+// IPHistoryType is container for JSON response
+type IPHistoryType struct {
+	Bans []IPBan         `json:"bans"`
+	Body u.PagedResponse `json:"posts"`
+}
+
+// IPPost is a post from the ip on any board
+type IPPost struct {
+	Ib      uint       `json:"ib_id"`
+	Thread  uint       `json:"thread_id"`
+	Title   string     `json:"thread_title"`
+	Num     uint       `json:"post_num"`
+	Time    *time.Time `json:"post_time"`
+	Deleted bool       `json:"post_deleted"`
+	Hash    *string    `json:"image_hash"`
+}
+
+// IPBan is a ban on the ip
+type IPBan struct {
+	Ib     uint   `json:"ib_id"`
+	UID    uint   `json:"user_id"`
+	Name   string `json:"user_name"`
+	Reason string `json:"ban_reason"`
+}
+
+// ValidateInput checks the ip can be parsed
+func (m *IPHistoryModel) ValidateInput() (err error) {
+
+	if net.ParseIP(m.IP) == nil {
+		return e.ErrIPParse
+	}
+
+	return
+
+}
+
+// Get will return the bans on the ip and its posts on every board, the newest first
+func (m *IPHistoryModel) Get() (err error) {
+
+	if m.IP == "" || m.Page == 0 {
+		return e.ErrNotFound
+	}
+
+	// Initialize response header
+	response := IPHistoryType{
+		Bans: []IPBan{},
+	}
+
+	// to hold the posts
+	posts := []IPPost{}
+
+	// Initialize struct for pagination
+	paged := u.PagedResponse{}
+	// Set current page to parameter
+	paged.CurrentPage = m.Page
+	// Set threads per index page to config setting
+	paged.PerPage = config.Settings.Limits.PostsPerPage
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	// Get total post count and put it in pagination struct
+	err = dbase.QueryRow("SELECT COUNT(post_id) FROM posts WHERE post_ip = ?", m.IP).Scan(&paged.Total)
+	if err != nil {
+		return
+	}
+
+	// Calculate Limit and total Pages
+	paged.Get()
+
+	// Return 404 if page requested is larger than actual pages
+	if m.Page > paged.Pages {
+		return e.ErrNotFound
+	}
+
+	rows, err := dbase.Query(`SELECT ib_id, threads.thread_id, thread_title, post_num, post_time, post_deleted, image_hash
+    FROM posts
+    INNER JOIN threads ON posts.thread_id = threads.thread_id
+    LEFT JOIN images ON posts.post_id = images.post_id
+    WHERE post_ip = ?
+    ORDER BY posts.post_id DESC LIMIT ?,?`, m.IP, paged.Limit, paged.PerPage)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		post := IPPost{}
+
+		err = rows.Scan(&post.Ib, &post.Thread, &post.Title, &post.Num, &post.Time, &post.Deleted, &post.Hash)
+		if err != nil {
+			return
+		}
+
+		posts = append(posts, post)
+	}
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	bans, err := dbase.Query(`SELECT ib_id, banned_ips.user_id, user_name, ban_reason FROM banned_ips
+    INNER JOIN users ON banned_ips.user_id = users.user_id
+    WHERE ban_ip = ?`, m.IP)
+	if err != nil {
+		return
+	}
+	defer bans.Close()
+
+	for bans.Next() {
+		ban := IPBan{}
+
+		err = bans.Scan(&ban.Ib, &ban.UID, &ban.Name, &ban.Reason)
+		if err != nil {
+			return
+		}
+
+		response.Bans = append(response.Bans, ban)
+	}
+	if bans.Err() != nil {
+		return bans.Err()
+	}
+
+	// Add posts slice to items interface
+	paged.Items = posts
+
+	// Add pagedresponse to the response struct
+	response.Body = paged
+
+	// This is the data we will serialize
+	m.Result = response
+
+	return
+
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/config"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+)
+
+func TestIPHistoryModelGet(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	config.Settings.Limits.PostsPerPage = 10
+
+	posted := time.Date(2024, 3, 10, 15, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT COUNT\(post_id\) FROM posts WHERE post_ip = \?`).
+		WithArgs("10.0.0.1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	mock.ExpectQuery(`SELECT ib_id, threads.thread_id, thread_title, post_num, post_time, post_deleted, image_hash`).
+		WithArgs("10.0.0.1", 0, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id", "thread_id", "thread_title", "post_num", "post_time", "post_deleted", "image_hash"}).
+			AddRow(1, 2, "Test Thread", 5, posted, false, "abcdef").
+			AddRow(3, 8, "Other Board", 1, posted, true, nil))
+
+	mock.ExpectQuery(`SELECT ib_id, banned_ips.user_id, user_name, ban_reason FROM banned_ips`).
+		WithArgs("10.0.0.1").
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id", "user_id", "user_name", "ban_reason"}).
+			AddRow(3, 2, "mod", "spam"))
+
+	m := &IPHistoryModel{
+		IP:   "10.0.0.1",
+		Page: 1,
+	}
+
+	err = m.Get()
+	assert.NoError(t, err, "No error should be returned")
+
+	posts, ok := m.Result.Body.Items.([]IPPost)
+	if assert.True(t, ok, "Items should be posts") && assert.Equal(t, 2, len(posts), "Should have two posts") {
+		assert.Equal(t, uint(3), posts[1].Ib, "Board should match")
+		assert.Nil(t, posts[1].Hash, "Post should have no image")
+	}
+
+	if assert.Equal(t, 1, len(m.Result.Bans), "Should have one ban") {
+		assert.Equal(t, "spam", m.Result.Bans[0].Reason, "Reason should match")
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestIPHistoryModelGetPageNotFound(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	config.Settings.Limits.PostsPerPage = 10
+
+	mock.ExpectQuery(`SELECT COUNT\(post_id\) FROM posts WHERE post_ip = \?`).
+		WithArgs("10.0.0.1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	m := &IPHistoryModel{
+		IP:   "10.0.0.1",
+		Page: 2,
+	}
+
+	err = m.Get()
+	assert.Equal(t, e.ErrNotFound, err, "Error should be ErrNotFound")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestIPHistoryModelValidateInput(t *testing.T) {
+	assert.NoError(t, (&IPHistoryModel{IP: "10.0.0.1"}).ValidateInput(), "IPv4 should parse")
+	assert.NoError(t, (&IPHistoryModel{IP: "2001:db8::1"}).ValidateInput(), "IPv6 should parse")
+	assert.Equal(t, e.ErrIPParse, (&IPHistoryModel{IP: "10.0.0"}).ValidateInput(), "Error should be ErrIPParse")
+	assert.Equal(t, e.ErrIPParse, (&IPHistoryModel{}).ValidateInput(), "Error should be ErrIPParse")
+}
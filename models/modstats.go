@@ -0,0 +1,169 @@
+package models
+
+import (
+	"sort"
+	"time"
+
+	"github.com/eirka/eirka-libs/audit"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+)
+
+// ModeratorStatsModel holds request input
+type ModeratorStatsModel struct {
+	Ib     uint
+	Range  string
+	Result ModeratorStatsType
+}
+
+// ModeratorStatsType holds the moderation activity of a board
+type ModeratorStatsType struct {
+	Total      uint                `json:"total"`
+	Actions    map[string]uint     `json:"actions"`
+	Moderators []ModeratorActivity `json:"moderators"`
+	Labels     []time.Time         `json:"labels"`
+	Series     []Series            `json:"series"`
+}
+
+// ModeratorActivity holds the action counts of a moderator
+type ModeratorActivity struct {
+	UID     uint            `json:"id"`
+	Name    string          `json:"name"`
+	Total   uint            `json:"total"`
+	Actions map[string]uint `json:"actions"`
+}
+
+// IsValid will check struct validity
+func (m *ModeratorStatsModel) IsValid() bool {
+
+	if m.Ib == 0 {
+		return false
+	}
+
+	_, ok := StatisticsRanges[m.Range]
+	if !ok {
+		return false
+	}
+
+	return true
+
+}
+
+// Days returns the start of every day in the range, the last one is today in UTC
+func (m *ModeratorStatsModel) Days(now time.Time) (days []time.Time) {
+
+	size := StatisticsBuckets["day"]
+	count := int(StatisticsRanges[m.Range] / size)
+
+	now = now.UTC()
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	for i := count - 1; i >= 0; i-- {
+		days = append(days, today.AddDate(0, 0, -i))
+	}
+
+	return
+
+}
+
+// Get will count the mod log entries of the board by moderator, action and day
+func (m *ModeratorStatsModel) Get() (err error) {
+
+	// check model validity
+	if !m.IsValid() {
+		return e.ErrInvalidParam
+	}
+
+	// Initialize response header
+	response := ModeratorStatsType{
+		Actions:    make(map[string]uint),
+		Moderators: []ModeratorActivity{},
+		Series:     []Series{},
+	}
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	days := m.Days(time.Now())
+
+	rows, err := dbase.Query(`SELECT audit.user_id, user_name, audit_action, DATEDIFF(audit_time, ?) AS bucket, COUNT(*) AS actions
+    FROM audit
+    INNER JOIN users ON audit.user_id = users.user_id
+    WHERE ib_id = ? AND audit_type = ? AND audit_time >= ?
+    GROUP BY audit.user_id, user_name, audit_action, bucket`, days[0], m.Ib, audit.ModLog, days[0])
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	// where each moderator is in the response
+	index := make(map[uint]int)
+
+	// the daily counts of each moderator
+	daily := make(map[uint][]uint)
+
+	for rows.Next() {
+		var uid, count uint
+		var name, action string
+		var bucket int64
+
+		err = rows.Scan(&uid, &name, &action, &bucket, &count)
+		if err != nil {
+			return
+		}
+
+		i, ok := index[uid]
+		if !ok {
+			i = len(response.Moderators)
+			index[uid] = i
+
+			response.Moderators = append(response.Moderators, ModeratorActivity{
+				UID:     uid,
+				Name:    name,
+				Actions: make(map[string]uint),
+			})
+			daily[uid] = make([]uint, len(days))
+		}
+
+		response.Moderators[i].Total += count
+		response.Moderators[i].Actions[action] += count
+
+		response.Total += count
+		response.Actions[action] += count
+
+		// skip anything outside of the chart
+		if bucket < 0 || bucket >= int64(len(days)) {
+			continue
+		}
+
+		daily[uid][bucket] += count
+	}
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	// the busiest moderators come first
+	sort.SliceStable(response.Moderators, func(a, b int) bool {
+		return response.Moderators[a].Total > response.Moderators[b].Total
+	})
+
+	// the series are in the same order as the moderators
+	for _, moderator := range response.Moderators {
+		response.Series = append(response.Series, Series{
+			Name: moderator.Name,
+			Data: daily[moderator.UID],
+		})
+	}
+
+	response.Labels = days
+
+	// This is the data we will serialize
+	m.Result = response
+
+	return
+
+}
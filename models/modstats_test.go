@@ -0,0 +1,110 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/audit"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+)
+
+// the grouped moderation activity query
+const testModeratorStatsQuery = `SELECT audit.user_id, user_name, audit_action, DATEDIFF\(audit_time, \?\) AS bucket, COUNT\(\*\) AS actions`
+
+func TestModeratorStatsIsValid(t *testing.T) {
+	assert.True(t, (&ModeratorStatsModel{Ib: 1, Range: "30d"}).IsValid(), "Known range should be valid")
+	assert.False(t, (&ModeratorStatsModel{Ib: 0, Range: "30d"}).IsValid(), "Missing ib should not be valid")
+	assert.False(t, (&ModeratorStatsModel{Ib: 1, Range: "2y"}).IsValid(), "Unknown range should not be valid")
+}
+
+func TestModeratorStatsDays(t *testing.T) {
+	now := time.Date(2024, 3, 10, 15, 42, 17, 0, time.UTC)
+
+	m := &ModeratorStatsModel{Ib: 1, Range: "7d"}
+	days := m.Days(now)
+	if assert.Equal(t, 7, len(days), "Should have 7 days") {
+		assert.Equal(t, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), days[0], "First day should start at midnight")
+		assert.Equal(t, time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), days[6], "Last day should be today")
+	}
+}
+
+func TestModeratorStatsModelGet(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	m := &ModeratorStatsModel{
+		Ib:    1,
+		Range: "7d",
+	}
+
+	mock.ExpectQuery(testModeratorStatsQuery).
+		WithArgs(sqlmock.AnyArg(), 1, audit.ModLog, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "user_name", "audit_action", "bucket", "actions"}).
+			AddRow(2, "alice", audit.AuditDeletePost, 0, 1).
+			AddRow(3, "bob", audit.AuditDeletePost, 6, 4).
+			AddRow(3, "bob", audit.AuditBanIP, 6, 2))
+
+	err = m.Get()
+	assert.NoError(t, err, "No error should be returned")
+
+	assert.Equal(t, uint(7), m.Result.Total, "Total should match")
+	assert.Equal(t, map[string]uint{audit.AuditDeletePost: 5, audit.AuditBanIP: 2}, m.Result.Actions, "Action totals should match")
+	assert.Equal(t, 7, len(m.Result.Labels), "Should have 7 data points")
+
+	// the busiest moderator is first
+	if assert.Equal(t, 2, len(m.Result.Moderators), "Should have two moderators") {
+		assert.Equal(t, "bob", m.Result.Moderators[0].Name, "Busiest moderator should be first")
+		assert.Equal(t, uint(6), m.Result.Moderators[0].Total, "Moderator total should match")
+		assert.Equal(t, uint(2), m.Result.Moderators[0].Actions[audit.AuditBanIP], "Moderator action count should match")
+		assert.Equal(t, "alice", m.Result.Moderators[1].Name, "Second moderator should match")
+	}
+
+	if assert.Equal(t, 2, len(m.Result.Series), "Should have a series per moderator") {
+		assert.Equal(t, "bob", m.Result.Series[0].Name, "Series should follow the moderator order")
+		assert.Equal(t, []uint{0, 0, 0, 0, 0, 0, 6}, m.Result.Series[0].Data, "Series data should match")
+		assert.Equal(t, []uint{1, 0, 0, 0, 0, 0, 0}, m.Result.Series[1].Data, "Series data should match")
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestModeratorStatsModelGetError(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	m := &ModeratorStatsModel{
+		Ib:    1,
+		Range: "30d",
+	}
+
+	mock.ExpectQuery(testModeratorStatsQuery).
+		WillReturnError(errors.New("database error"))
+
+	err = m.Get()
+	if assert.Error(t, err, "Error should be returned") {
+		assert.Contains(t, err.Error(), "database error", "Error should contain the expected error message")
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestModeratorStatsModelGetInvalid(t *testing.T) {
+	m := &ModeratorStatsModel{
+		Ib:    1,
+		Range: "2y",
+	}
+
+	err := m.Get()
+	assert.Equal(t, e.ErrInvalidParam, err, "Error should be ErrInvalidParam")
+}
@@ -0,0 +1,338 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"html"
+	"time"
+
+	"github.com/microcosm-cc/bluemonday"
+
+	"github.com/eirka/eirka-libs/config"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+	"github.com/eirka/eirka-libs/validate"
+)
+
+// the kinds of things a note can be attached to
+const (
+	NoteIP     = "ip"
+	NoteUser   = "user"
+	NoteThread = "thread"
+)
+
+// NoteTarget is the ip, user or thread notes are attached to, ips are
+// addressed by a post so they are never sent to the client
+type NoteTarget struct {
+	Ib     uint
+	Type   string
+	Thread uint
+	ID     uint
+	Target string
+}
+
+// Status will check the target exists and set the value the notes are stored under
+func (m *NoteTarget) Status() (err error) {
+
+	if m.Ib == 0 {
+		return e.ErrNotFound
+	}
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	switch m.Type {
+	case NoteIP:
+		err = dbase.QueryRow(`SELECT post_ip FROM threads
+    INNER JOIN posts ON threads.thread_id = posts.thread_id
+    WHERE ib_id = ? AND threads.thread_id = ? AND post_num = ? LIMIT 1`, m.Ib, m.Thread, m.ID).Scan(&m.Target)
+	case NoteUser:
+		// the anonymous user is shared by everyone
+		if m.ID == 0 || m.ID == 1 {
+			return e.ErrNotFound
+		}
+		err = dbase.QueryRow(`SELECT user_id FROM users WHERE user_id = ?`, m.ID).Scan(&m.Target)
+	case NoteThread:
+		err = dbase.QueryRow(`SELECT thread_id FROM threads WHERE ib_id = ? AND thread_id = ?`, m.Ib, m.Thread).Scan(&m.Target)
+	default:
+		return e.ErrNotFound
+	}
+	if err == sql.ErrNoRows {
+		return e.ErrNotFound
+	} else if err != nil {
+		return
+	}
+
+	return
+
+}
+
+// NotesModel holds request input
+type NotesModel struct {
+	NoteTarget
+	Result NotesType
+}
+
+// NotesType is container for JSON response
+type NotesType struct {
+	Body []Note `json:"notes"`
+}
+
+// Note is a private moderator note
+type Note struct {
+	ID      uint       `json:"note_id"`
+	User    uint       `json:"user_id"`
+	Name    string     `json:"user_name"`
+	Text    string     `json:"note_text"`
+	Time    *time.Time `json:"note_time"`
+	Updated *time.Time `json:"note_updated"`
+}
+
+// Get will return the notes of the target, the newest first
+func (m *NotesModel) Get() (err error) {
+
+	if m.Target == "" {
+		return e.ErrNotFound
+	}
+
+	// Initialize response header
+	response := NotesType{
+		Body: []Note{},
+	}
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	rows, err := dbase.Query(`SELECT note_id, notes.user_id, user_name, note_text, note_time, note_updated
+    FROM notes
+    INNER JOIN users ON notes.user_id = users.user_id
+    WHERE ib_id = ? AND note_type = ? AND note_target = ?
+    ORDER BY note_id DESC`, m.Ib, m.Type, m.Target)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		note := Note{}
+
+		err = rows.Scan(&note.ID, &note.User, &note.Name, &note.Text, &note.Time, &note.Updated)
+		if err != nil {
+			return
+		}
+
+		response.Body = append(response.Body, note)
+	}
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	// This is the data we will serialize
+	m.Result = response
+
+	return
+
+}
+
+// NoteModel holds request input
+type NoteModel struct {
+	ID     uint
+	Ib     uint
+	User   uint
+	Author uint
+	Type   string
+	Target string
+	Text   string
+}
+
+// IsValid will check struct validity
+func (m *NoteModel) IsValid() bool {
+
+	if m.Ib == 0 {
+		return false
+	}
+
+	if m.User == 0 || m.User == 1 {
+		return false
+	}
+
+	if m.Type == "" {
+		return false
+	}
+
+	if m.Target == "" {
+		return false
+	}
+
+	if m.Text == "" {
+		return false
+	}
+
+	return true
+
+}
+
+// ValidateInput checks the data input for correctness
+func (m *NoteModel) ValidateInput() (err error) {
+
+	// Initialize bluemonday
+	p := bluemonday.StrictPolicy()
+
+	// sanitize for html and xss
+	m.Text = html.UnescapeString(p.Sanitize(m.Text))
+
+	// Validate note input
+	text := validate.Validate{Input: m.Text, Max: config.Settings.Limits.CommentMaxLength, Min: config.Settings.Limits.CommentMinLength}
+	if text.IsEmpty() {
+		return e.ErrNoComment
+	} else if text.MinLength() {
+		return e.ErrCommentShort
+	} else if text.MaxLength() {
+		return e.ErrCommentLong
+	}
+
+	return
+
+}
+
+// Status will return the author and target of the note
+func (m *NoteModel) Status() (err error) {
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	err = dbase.QueryRow(`SELECT user_id, note_type, note_target FROM notes WHERE ib_id = ? AND note_id = ?`,
+		m.Ib, m.ID).Scan(&m.Author, &m.Type, &m.Target)
+	if err == sql.ErrNoRows {
+		return e.ErrNotFound
+	} else if err != nil {
+		return
+	}
+
+	return
+
+}
+
+// Post will add the note to the target
+func (m *NoteModel) Post() (err error) {
+
+	// check model validity
+	if !m.IsValid() {
+		return errors.New("NoteModel is not valid")
+	}
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	result, err := dbase.Exec(`INSERT INTO notes (ib_id,user_id,note_type,note_target,note_text,note_time)
+    VALUES (?,?,?,?,?,NOW())`, m.Ib, m.User, m.Type, m.Target, m.Text)
+	if err != nil {
+		return
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return
+	}
+
+	m.ID = uint(id)
+	m.Author = m.User
+
+	return
+
+}
+
+// Update will change the text of the note
+func (m *NoteModel) Update() (err error) {
+
+	// check model validity
+	if !m.IsValid() || m.ID == 0 {
+		return errors.New("NoteModel is not valid")
+	}
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	_, err = dbase.Exec("UPDATE notes SET note_text = ?, note_updated = NOW() WHERE ib_id = ? AND note_id = ?",
+		m.Text, m.Ib, m.ID)
+	if err != nil {
+		return
+	}
+
+	return
+
+}
+
+// Delete will remove the note
+func (m *NoteModel) Delete() (err error) {
+
+	if m.Ib == 0 || m.ID == 0 {
+		return errors.New("NoteModel is not valid")
+	}
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	_, err = dbase.Exec("DELETE FROM notes WHERE ib_id = ? AND note_id = ?", m.Ib, m.ID)
+	if err != nil {
+		return
+	}
+
+	return
+
+}
+
+// NoteCountModel holds request input
+type NoteCountModel struct {
+	Ib     uint
+	Thread uint
+	ID     uint
+	Result uint
+}
+
+// Get will count the notes on the ip, user and thread of a post
+func (m *NoteCountModel) Get() (err error) {
+
+	if m.Ib == 0 || m.Thread == 0 || m.ID == 0 {
+		return e.ErrNotFound
+	}
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	err = dbase.QueryRow(`SELECT COUNT(note_id) FROM threads
+    INNER JOIN posts ON threads.thread_id = posts.thread_id
+    INNER JOIN notes ON notes.ib_id = threads.ib_id AND (
+    (note_type = ? AND note_target = post_ip) OR
+    (note_type = ? AND note_target = CAST(posts.user_id AS CHAR) AND posts.user_id != 1) OR
+    (note_type = ? AND note_target = CAST(threads.thread_id AS CHAR)))
+    WHERE threads.ib_id = ? AND threads.thread_id = ? AND post_num = ?`,
+		NoteIP, NoteUser, NoteThread, m.Ib, m.Thread, m.ID).Scan(&m.Result)
+	if err != nil {
+		return
+	}
+
+	return
+
+}
@@ -0,0 +1,194 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/config"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+)
+
+func TestNoteTargetStatus(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT post_ip FROM threads`).
+		WithArgs(1, 2, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"post_ip"}).AddRow("10.0.0.1"))
+
+	m := &NoteTarget{
+		Ib:     1,
+		Type:   NoteIP,
+		Thread: 2,
+		ID:     5,
+	}
+
+	err = m.Status()
+	assert.NoError(t, err, "No error should be returned")
+	assert.Equal(t, "10.0.0.1", m.Target, "Target should be the post ip")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestNoteTargetStatusNotFound(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT thread_id FROM threads`).
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"thread_id"}))
+
+	m := &NoteTarget{
+		Ib:     1,
+		Type:   NoteThread,
+		Thread: 2,
+	}
+
+	err = m.Status()
+	assert.Equal(t, e.ErrNotFound, err, "Error should be ErrNotFound")
+
+	// the anonymous user cannot have notes
+	anon := &NoteTarget{
+		Ib:   1,
+		Type: NoteUser,
+		ID:   1,
+	}
+
+	err = anon.Status()
+	assert.Equal(t, e.ErrNotFound, err, "Error should be ErrNotFound")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestNotesModelGet(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	written := time.Date(2024, 3, 10, 15, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT note_id, notes.user_id, user_name, note_text, note_time, note_updated`).
+		WithArgs(1, NoteUser, "7").
+		WillReturnRows(sqlmock.NewRows([]string{"note_id", "user_id", "user_name", "note_text", "note_time", "note_updated"}).
+			AddRow(3, 2, "mod", "warned twice, next is a ban", written, nil))
+
+	m := &NotesModel{
+		NoteTarget: NoteTarget{
+			Ib:     1,
+			Type:   NoteUser,
+			ID:     7,
+			Target: "7",
+		},
+	}
+
+	err = m.Get()
+	assert.NoError(t, err, "No error should be returned")
+
+	if assert.Equal(t, 1, len(m.Result.Body), "Should have one note") {
+		assert.Equal(t, "mod", m.Result.Body[0].Name, "Author should match")
+		assert.Nil(t, m.Result.Body[0].Updated, "Note should not be updated")
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestNoteModelValidateInput(t *testing.T) {
+	originalMin := config.Settings.Limits.CommentMinLength
+	originalMax := config.Settings.Limits.CommentMaxLength
+	config.Settings.Limits.CommentMinLength = 3
+	config.Settings.Limits.CommentMaxLength = 20
+	defer func() {
+		config.Settings.Limits.CommentMinLength = originalMin
+		config.Settings.Limits.CommentMaxLength = originalMax
+	}()
+
+	tests := []struct {
+		text string
+		err  error
+	}{
+		{"watch this one", nil},
+		{"<b></b>", e.ErrNoComment},
+		{"ok", e.ErrCommentShort},
+		{"this note is far too long", e.ErrCommentLong},
+	}
+
+	for _, test := range tests {
+		m := &NoteModel{Text: test.text}
+		assert.Equal(t, test.err, m.ValidateInput(), "Error should match for %q", test.text)
+	}
+}
+
+func TestNoteModelPost(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectExec(`INSERT INTO notes`).
+		WithArgs(1, 2, NoteThread, "4", "keep an eye on it").
+		WillReturnResult(sqlmock.NewResult(9, 1))
+
+	m := &NoteModel{
+		Ib:     1,
+		User:   2,
+		Type:   NoteThread,
+		Target: "4",
+		Text:   "keep an eye on it",
+	}
+
+	err = m.Post()
+	assert.NoError(t, err, "No error should be returned")
+	assert.Equal(t, uint(9), m.ID, "ID should be set")
+	assert.Equal(t, uint(2), m.Author, "Author should be the user")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestNoteModelPostInvalid(t *testing.T) {
+	m := &NoteModel{
+		Ib:     1,
+		User:   1,
+		Type:   NoteThread,
+		Target: "4",
+		Text:   "keep an eye on it",
+	}
+
+	assert.Error(t, m.Post(), "An error should be returned")
+}
+
+func TestNoteCountModelGet(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT COUNT\(note_id\) FROM threads`).
+		WithArgs(NoteIP, NoteUser, NoteThread, 1, 2, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	m := &NoteCountModel{
+		Ib:     1,
+		Thread: 2,
+		ID:     5,
+	}
+
+	err = m.Get()
+	assert.NoError(t, err, "No error should be returned")
+	assert.Equal(t, uint(3), m.Result, "Count should match")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
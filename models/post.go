@@ -0,0 +1,136 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/eirka/eirka-libs/audit"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+)
+
+// PostModel holds request input
+type PostModel struct {
+	Ib     uint
+	Thread uint
+	ID     uint
+	Result PostType
+}
+
+// PostType is container for JSON response
+type PostType struct {
+	Body PostInfo `json:"post"`
+}
+
+// PostInfo holds everything a moderator needs to know about a post
+type PostInfo struct {
+	Thread     uint       `json:"thread_id"`
+	Title      string     `json:"thread_title"`
+	Num        uint       `json:"post_num"`
+	Time       *time.Time `json:"post_time"`
+	Deleted    bool       `json:"post_deleted"`
+	UID        uint       `json:"user_id"`
+	Name       string     `json:"user_name"`
+	IP         string     `json:"post_ip"`
+	IPBanned   bool       `json:"ip_banned"`
+	IPPosts    uint       `json:"ip_posts"`
+	Hash       *string    `json:"image_hash"`
+	HashBanned bool       `json:"image_banned"`
+	Notes      uint       `json:"note_count"`
+	History    []Log      `json:"history"`
+}
+
+// Get will gather the post with its poster, bans, notes and mod log entries
+func (m *PostModel) Get() (err error) {
+
+	if m.Ib == 0 || m.Thread == 0 || m.ID == 0 {
+		return e.ErrNotFound
+	}
+
+	// Initialize response header
+	response := PostType{}
+
+	post := PostInfo{
+		History: []Log{},
+	}
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	var id uint
+
+	// get the post with its image and the bans on its ip and file
+	err = dbase.QueryRow(`SELECT posts.post_id, threads.thread_id, thread_title, post_num, post_time, post_deleted,
+    posts.user_id, user_name, post_ip, image_hash,
+    EXISTS(SELECT 1 FROM banned_ips WHERE banned_ips.ib_id = threads.ib_id AND ban_ip = post_ip
+    AND (ban_expires IS NULL OR ban_expires > NOW())) AS ip_banned,
+    EXISTS(SELECT 1 FROM banned_files WHERE banned_files.ib_id = threads.ib_id AND ban_hash = image_hash) AS image_banned
+    FROM threads
+    INNER JOIN posts ON threads.thread_id = posts.thread_id
+    INNER JOIN users ON posts.user_id = users.user_id
+    LEFT JOIN images ON posts.post_id = images.post_id
+    WHERE threads.ib_id = ? AND threads.thread_id = ? AND post_num = ? LIMIT 1`, m.Ib, m.Thread, m.ID).
+		Scan(&id, &post.Thread, &post.Title, &post.Num, &post.Time, &post.Deleted,
+			&post.UID, &post.Name, &post.IP, &post.Hash, &post.IPBanned, &post.HashBanned)
+	if err == sql.ErrNoRows {
+		return e.ErrNotFound
+	} else if err != nil {
+		return
+	}
+
+	// the other posts on the board from the same ip
+	err = dbase.QueryRow(`SELECT COUNT(post_id) FROM threads
+    INNER JOIN posts ON threads.thread_id = posts.thread_id
+    WHERE ib_id = ? AND post_ip = ? AND post_id != ?`, m.Ib, post.IP, id).Scan(&post.IPPosts)
+	if err != nil {
+		return
+	}
+
+	notes := NoteCountModel{
+		Ib:     m.Ib,
+		Thread: m.Thread,
+		ID:     m.ID,
+	}
+
+	err = notes.Get()
+	if err != nil {
+		return
+	}
+
+	post.Notes = notes.Result
+
+	// the actions on the post and on its whole thread
+	rows, err := dbase.Query(`SELECT audit.user_id, user_name, audit_time, audit_action, audit_info FROM audit
+    INNER JOIN users ON audit.user_id = users.user_id
+    WHERE ib_id = ? AND audit_type = ? AND thread_id = ? AND post_num IN (0, ?)
+    ORDER BY audit_id DESC`, m.Ib, audit.ModLog, post.Thread, post.Num)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		entry := Log{}
+
+		err = rows.Scan(&entry.UID, &entry.Name, &entry.Time, &entry.Action, &entry.Meta)
+		if err != nil {
+			return
+		}
+
+		post.History = append(post.History, entry)
+	}
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	response.Body = post
+
+	// This is the data we will serialize
+	m.Result = response
+
+	return
+
+}
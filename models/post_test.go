@@ -0,0 +1,92 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/audit"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+)
+
+// the post inspector post query
+const testPostQuery = `SELECT posts.post_id, threads.thread_id, thread_title, post_num, post_time, post_deleted`
+
+func TestPostModelGet(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	posted := time.Date(2024, 3, 10, 15, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(testPostQuery).
+		WithArgs(1, 2, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "thread_id", "thread_title", "post_num", "post_time", "post_deleted",
+			"user_id", "user_name", "post_ip", "image_hash", "ip_banned", "image_banned"}).
+			AddRow(40, 2, "Test Thread", 5, posted, false, 7, "poster", "10.0.0.1", "abcdef", true, false))
+
+	mock.ExpectQuery(`SELECT COUNT\(post_id\) FROM threads`).
+		WithArgs(1, "10.0.0.1", 40).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+
+	mock.ExpectQuery(`SELECT COUNT\(note_id\) FROM threads`).
+		WithArgs(NoteIP, NoteUser, NoteThread, 1, 2, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	mock.ExpectQuery(`SELECT audit.user_id, user_name, audit_time, audit_action, audit_info FROM audit .* thread_id = \? AND post_num IN \(0, \?\)`).
+		WithArgs(1, audit.ModLog, 2, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "user_name", "audit_time", "audit_action", "audit_info"}).
+			AddRow(2, "mod", posted, audit.AuditDeletePost, "Test Thread/5").
+			AddRow(2, "mod", posted, audit.AuditCloseThread, "Test Thread"))
+
+	m := &PostModel{
+		Ib:     1,
+		Thread: 2,
+		ID:     5,
+	}
+
+	err = m.Get()
+	assert.NoError(t, err, "No error should be returned")
+
+	post := m.Result.Body
+	assert.Equal(t, "10.0.0.1", post.IP, "IP should match")
+	assert.True(t, post.IPBanned, "IP should be banned")
+	assert.Equal(t, uint(12), post.IPPosts, "Other posts should match")
+	if assert.NotNil(t, post.Hash, "Hash should be set") {
+		assert.Equal(t, "abcdef", *post.Hash, "Hash should match")
+	}
+	assert.False(t, post.HashBanned, "Hash should not be banned")
+	assert.Equal(t, "poster", post.Name, "Name should match")
+	assert.Equal(t, uint(2), post.Notes, "Note count should match")
+	assert.Equal(t, 2, len(post.History), "Should have the post and thread log entries")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestPostModelGetNotFound(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(testPostQuery).
+		WithArgs(1, 2, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id"}))
+
+	m := &PostModel{
+		Ib:     1,
+		Thread: 2,
+		ID:     5,
+	}
+
+	err = m.Get()
+	assert.Equal(t, e.ErrNotFound, err, "Error should be ErrNotFound")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
@@ -0,0 +1,383 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/microcosm-cc/bluemonday"
+
+	"github.com/eirka/eirka-libs/config"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+	"github.com/eirka/eirka-libs/validate"
+
+	u "github.com/eirka/eirka-admin/utils"
+)
+
+// the states of a report
+const (
+	ReportOpen      = 0
+	ReportResolved  = 1
+	ReportDismissed = 2
+)
+
+// the separator of the grouped report reasons
+const reportReasonSeparator = "\x1e"
+
+// ReportsModel holds request input
+type ReportsModel struct {
+	Ib     uint
+	Page   uint
+	Result ReportsType
+}
+
+// ReportsType is container for JSON response
+type ReportsType struct {
+	Body u.PagedResponse `json:"reports"`
+}
+
+// Report holds the open reports of a post
+type Report struct {
+	Thread  uint       `json:"thread_id"`
+	Title   string     `json:"thread_title"`
+	Post    uint       `json:"post_num"`
+	Count   uint       `json:"report_count"`
+	Reasons []string   `json:"report_reasons"`
+	First   *time.Time `json:"report_first"`
+	Last    *time.Time `json:"report_last"`
+}
+
+// Get will return the posts with open reports, the most recently reported first
+func (m *ReportsModel) Get() (err error) {
+
+	if m.Ib == 0 || m.Page == 0 {
+		return e.ErrNotFound
+	}
+
+	// Initialize response header
+	response := ReportsType{}
+
+	// to hold the reported posts
+	reports := []Report{}
+
+	// Initialize struct for pagination
+	paged := u.PagedResponse{}
+	// Set current page to parameter
+	paged.CurrentPage = m.Page
+	// Set threads per index page to config setting
+	paged.PerPage = config.Settings.Limits.PostsPerPage
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	// Get total reported post count and put it in pagination struct
+	err = dbase.QueryRow(`SELECT COUNT(DISTINCT reports.post_id) FROM reports
+    INNER JOIN posts ON reports.post_id = posts.post_id
+    INNER JOIN threads ON posts.thread_id = threads.thread_id
+    WHERE ib_id = ? AND report_status = ?`, m.Ib, ReportOpen).Scan(&paged.Total)
+	if err != nil {
+		return
+	}
+
+	// Calculate Limit and total Pages
+	paged.Get()
+
+	// Return 404 if page requested is larger than actual pages
+	if m.Page > paged.Pages {
+		return e.ErrNotFound
+	}
+
+	rows, err := dbase.Query(`SELECT threads.thread_id, thread_title, post_num, COUNT(report_id) AS report_count,
+    GROUP_CONCAT(DISTINCT report_reason SEPARATOR '`+reportReasonSeparator+`'),
+    MIN(report_time), MAX(report_time) AS report_last
+    FROM reports
+    INNER JOIN posts ON reports.post_id = posts.post_id
+    INNER JOIN threads ON posts.thread_id = threads.thread_id
+    WHERE ib_id = ? AND report_status = ?
+    GROUP BY reports.post_id, threads.thread_id, thread_title, post_num
+    ORDER BY report_last DESC LIMIT ?,?`, m.Ib, ReportOpen, paged.Limit, paged.PerPage)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		report := Report{}
+		var reasons string
+
+		err = rows.Scan(&report.Thread, &report.Title, &report.Post, &report.Count, &reasons, &report.First, &report.Last)
+		if err != nil {
+			return
+		}
+
+		report.Reasons = strings.Split(reasons, reportReasonSeparator)
+
+		reports = append(reports, report)
+	}
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	// Add reports slice to items interface
+	paged.Items = reports
+
+	// Add pagedresponse to the response struct
+	response.Body = paged
+
+	// This is the data we will serialize
+	m.Result = response
+
+	return
+
+}
+
+// ReportModel holds request input
+type ReportModel struct {
+	Ib     uint
+	Thread uint
+	ID     uint
+	User   uint
+	Name   string
+	Count  uint
+}
+
+// IsValid will check struct validity
+func (m *ReportModel) IsValid() bool {
+
+	if m.Ib == 0 {
+		return false
+	}
+
+	if m.Thread == 0 {
+		return false
+	}
+
+	if m.ID == 0 {
+		return false
+	}
+
+	if m.User == 0 || m.User == 1 {
+		return false
+	}
+
+	return true
+
+}
+
+// Status will return the thread title and the open report count of the post
+func (m *ReportModel) Status() (err error) {
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	err = dbase.QueryRow(`SELECT thread_title, COUNT(report_id) FROM threads
+    INNER JOIN posts ON threads.thread_id = posts.thread_id
+    INNER JOIN reports ON posts.post_id = reports.post_id
+    WHERE ib_id = ? AND threads.thread_id = ? AND post_num = ? AND report_status = ?
+    GROUP BY thread_title`, m.Ib, m.Thread, m.ID, ReportOpen).Scan(&m.Name, &m.Count)
+	if err == sql.ErrNoRows {
+		return e.ErrNotFound
+	} else if err != nil {
+		return
+	}
+
+	return
+
+}
+
+// Resolve will close the open reports of the post as handled
+func (m *ReportModel) Resolve() (err error) {
+	return m.close(ReportResolved)
+}
+
+// Dismiss will close the open reports of the post without action
+func (m *ReportModel) Dismiss() (err error) {
+	return m.close(ReportDismissed)
+}
+
+// close sets the open reports of the post to the status and records the moderator
+func (m *ReportModel) close(status uint) (err error) {
+
+	// check model validity
+	if !m.IsValid() {
+		return errors.New("ReportModel is not valid")
+	}
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	_, err = dbase.Exec(`UPDATE reports
+    INNER JOIN posts ON reports.post_id = posts.post_id
+    INNER JOIN threads ON posts.thread_id = threads.thread_id
+    SET report_status = ?, report_handled_by = ?, report_handled_time = NOW()
+    WHERE ib_id = ? AND threads.thread_id = ? AND post_num = ? AND report_status = ?`,
+		status, m.User, m.Ib, m.Thread, m.ID, ReportOpen)
+	if err != nil {
+		return
+	}
+
+	return
+
+}
+
+// ErrReportDuplicate is returned when the ip already has an open report on the post
+var ErrReportDuplicate = errors.New("post already reported")
+
+// ErrReportBanned is returned when the account or ip of the reporter is banned on the board
+var ErrReportBanned = errors.New("banned users can not report posts")
+
+// AddReportModel holds request input
+type AddReportModel struct {
+	Ib     uint
+	Thread uint
+	ID     uint
+	User   uint
+	IP     string
+	Reason string
+	PostID uint
+}
+
+// IsValid will check struct validity
+func (m *AddReportModel) IsValid() bool {
+
+	if m.Ib == 0 {
+		return false
+	}
+
+	if m.Thread == 0 {
+		return false
+	}
+
+	if m.ID == 0 {
+		return false
+	}
+
+	if m.IP == "" {
+		return false
+	}
+
+	if m.Reason == "" {
+		return false
+	}
+
+	if m.PostID == 0 {
+		return false
+	}
+
+	return true
+
+}
+
+// ValidateInput checks the data input for correctness, the reason is shown
+// to the moderators
+func (m *AddReportModel) ValidateInput() (err error) {
+
+	// Initialize bluemonday
+	p := bluemonday.StrictPolicy()
+
+	// sanitize for html and xss
+	m.Reason = html.UnescapeString(p.Sanitize(m.Reason))
+
+	// Validate reason input
+	reason := validate.Validate{Input: m.Reason, Max: config.Settings.Limits.CommentMaxLength, Min: config.Settings.Limits.CommentMinLength}
+	if reason.IsEmpty() {
+		return e.ErrNoComment
+	} else if reason.MinLength() {
+		return e.ErrCommentShort
+	} else if reason.MaxLength() {
+		return e.ErrCommentLong
+	}
+
+	return
+
+}
+
+// Status will get the id of the post and check that the reporter is not banned
+// and has not reported it already
+func (m *AddReportModel) Status() (err error) {
+
+	banned, err := UserBanned(m.Ib, m.User)
+	if err != nil {
+		return
+	}
+
+	if banned {
+		return ErrReportBanned
+	}
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	err = dbase.QueryRow(`SELECT EXISTS(SELECT 1 FROM banned_ips
+    WHERE ib_id = ? AND ban_ip = ? AND (ban_expires IS NULL OR ban_expires > NOW()))`, m.Ib, m.IP).Scan(&banned)
+	if err != nil {
+		return
+	}
+
+	if banned {
+		return ErrReportBanned
+	}
+
+	err = dbase.QueryRow(`SELECT post_id FROM threads
+    INNER JOIN posts ON threads.thread_id = posts.thread_id
+    WHERE ib_id = ? AND threads.thread_id = ? AND post_num = ? AND post_deleted = 0 LIMIT 1`, m.Ib, m.Thread, m.ID).Scan(&m.PostID)
+	if err == sql.ErrNoRows {
+		return e.ErrNotFound
+	} else if err != nil {
+		return
+	}
+
+	var count uint
+
+	err = dbase.QueryRow(`SELECT COUNT(*) FROM reports WHERE post_id = ? AND report_ip = ? AND report_status = ?`,
+		m.PostID, m.IP, ReportOpen).Scan(&count)
+	if err != nil {
+		return
+	}
+
+	if count > 0 {
+		return ErrReportDuplicate
+	}
+
+	return
+
+}
+
+// Post will add an open report to the post
+func (m *AddReportModel) Post() (err error) {
+
+	// check model validity
+	if !m.IsValid() {
+		return errors.New("AddReportModel is not valid")
+	}
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	_, err = dbase.Exec(`INSERT INTO reports (post_id,report_reason,report_ip,report_time,report_status)
+    VALUES (?,?,?,NOW(),?)`, m.PostID, m.Reason, m.IP, ReportOpen)
+	if err != nil {
+		return
+	}
+
+	return
+
+}
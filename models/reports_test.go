@@ -0,0 +1,201 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/config"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+)
+
+func TestReportsModelGet(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	config.Settings.Limits.PostsPerPage = 10
+
+	reported := time.Date(2024, 3, 10, 15, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT COUNT\(DISTINCT reports.post_id\) FROM reports`).
+		WithArgs(1, ReportOpen).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	mock.ExpectQuery(`SELECT threads.thread_id, thread_title, post_num, COUNT\(report_id\) AS report_count`).
+		WithArgs(1, ReportOpen, 0, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"thread_id", "thread_title", "post_num", "report_count", "reasons", "first", "last"}).
+			AddRow(2, "Test Thread", 5, 3, "spam\x1eoff topic", reported, reported))
+
+	m := &ReportsModel{
+		Ib:   1,
+		Page: 1,
+	}
+
+	err = m.Get()
+	assert.NoError(t, err, "No error should be returned")
+
+	assert.Equal(t, uint(1), m.Result.Body.Total, "Total should match")
+
+	reports, ok := m.Result.Body.Items.([]Report)
+	if assert.True(t, ok, "Items should be reports") && assert.Equal(t, 1, len(reports), "Should have one post") {
+		assert.Equal(t, uint(3), reports[0].Count, "Report count should match")
+		assert.Equal(t, []string{"spam", "off topic"}, reports[0].Reasons, "Reasons should be split")
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestReportsModelGetPageNotFound(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	config.Settings.Limits.PostsPerPage = 10
+
+	mock.ExpectQuery(`SELECT COUNT\(DISTINCT reports.post_id\) FROM reports`).
+		WithArgs(1, ReportOpen).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	m := &ReportsModel{
+		Ib:   1,
+		Page: 2,
+	}
+
+	err = m.Get()
+	assert.Equal(t, e.ErrNotFound, err, "Error should be ErrNotFound")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestReportModelStatus(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT thread_title, COUNT\(report_id\) FROM threads`).
+		WithArgs(1, 2, 5, ReportOpen).
+		WillReturnRows(sqlmock.NewRows([]string{"thread_title", "count"}).AddRow("Test Thread", 3))
+
+	m := &ReportModel{
+		Ib:     1,
+		Thread: 2,
+		ID:     5,
+		User:   2,
+	}
+
+	err = m.Status()
+	assert.NoError(t, err, "No error should be returned")
+	assert.Equal(t, "Test Thread", m.Name, "Name should match")
+	assert.Equal(t, uint(3), m.Count, "Count should match")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestReportModelStatusNotFound(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT thread_title, COUNT\(report_id\) FROM threads`).
+		WithArgs(1, 2, 5, ReportOpen).
+		WillReturnRows(sqlmock.NewRows([]string{"thread_title", "count"}))
+
+	m := &ReportModel{
+		Ib:     1,
+		Thread: 2,
+		ID:     5,
+		User:   2,
+	}
+
+	err = m.Status()
+	assert.Equal(t, e.ErrNotFound, err, "Error should be ErrNotFound")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestReportModelResolveAndDismiss(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	m := &ReportModel{
+		Ib:     1,
+		Thread: 2,
+		ID:     5,
+		User:   2,
+	}
+
+	mock.ExpectExec(`UPDATE reports\s+INNER JOIN posts`).
+		WithArgs(ReportResolved, 2, 1, 2, 5, ReportOpen).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	err = m.Resolve()
+	assert.NoError(t, err, "No error should be returned")
+
+	mock.ExpectExec(`UPDATE reports\s+INNER JOIN posts`).
+		WithArgs(ReportDismissed, 2, 1, 2, 5, ReportOpen).
+		WillReturnError(errors.New("database error"))
+
+	err = m.Dismiss()
+	assert.Error(t, err, "Error should be returned")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestReportModelInvalid(t *testing.T) {
+	m := &ReportModel{
+		Ib:     1,
+		Thread: 2,
+		ID:     5,
+		User:   1,
+	}
+
+	assert.False(t, m.IsValid(), "Anonymous user should not be valid")
+	assert.Error(t, m.Resolve(), "Invalid model should return an error")
+}
+
+func TestAddReportModelValidateInput(t *testing.T) {
+	config.Settings.Limits.CommentMinLength = 3
+	config.Settings.Limits.CommentMaxLength = 10
+
+	m := &AddReportModel{
+		Reason: "<b>spam</b>",
+	}
+
+	assert.NoError(t, m.ValidateInput(), "No error should be returned")
+	assert.Equal(t, "spam", m.Reason, "Reason should be sanitized")
+
+	m.Reason = ""
+	assert.Equal(t, e.ErrNoComment, m.ValidateInput(), "Empty reason should be rejected")
+
+	m.Reason = "this reason is too long"
+	assert.Equal(t, e.ErrCommentLong, m.ValidateInput(), "Long reason should be rejected")
+}
+
+func TestAddReportModelInvalid(t *testing.T) {
+	m := &AddReportModel{
+		Ib:     1,
+		Thread: 2,
+		ID:     5,
+		IP:     "10.0.0.1",
+		Reason: "spam",
+	}
+
+	assert.False(t, m.IsValid(), "Model without a post id should not be valid")
+	assert.Error(t, m.Post(), "Invalid model should return an error")
+}
@@ -0,0 +1,358 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"html"
+	"time"
+
+	"github.com/microcosm-cc/bluemonday"
+
+	"github.com/eirka/eirka-libs/config"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+	"github.com/eirka/eirka-libs/validate"
+)
+
+// ErrRuleNotFound is returned when a ban names a rule the board does not have
+var ErrRuleNotFound = errors.New("rule not found")
+
+// Rule is a board rule that bans are made for, the message is the public ban
+// reason and the duration is the default length of a ban in days, zero is
+// permanent and file bans never expire
+type Rule struct {
+	ID       uint   `json:"rule_id"`
+	Title    string `json:"rule_title"`
+	Duration uint   `json:"rule_duration"`
+	Message  string `json:"rule_message"`
+}
+
+// Reason returns the ban reason for the rule, a custom reason is added to the
+// public message
+func (r *Rule) Reason(custom string) string {
+
+	if custom == "" {
+		return r.Message
+	}
+
+	return r.Message + " - " + custom
+
+}
+
+// Expires returns when a ban for the rule made at now ends, nil is permanent
+func (r *Rule) Expires(now time.Time) *time.Time {
+
+	if r.Duration == 0 {
+		return nil
+	}
+
+	expires := now.AddDate(0, 0, int(r.Duration))
+
+	return &expires
+
+}
+
+// RulesModel holds request input
+type RulesModel struct {
+	Ib     uint
+	Result RulesType
+}
+
+// RulesType is container for JSON response
+type RulesType struct {
+	Body []Rule `json:"rules"`
+}
+
+// Get will return the rules of the board
+func (m *RulesModel) Get() (err error) {
+
+	if m.Ib == 0 {
+		return e.ErrNotFound
+	}
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	rows, err := dbase.Query(`SELECT rule_id, rule_title, rule_duration, rule_message
+    FROM rules WHERE ib_id = ? ORDER BY rule_id ASC`, m.Ib)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	rules := []Rule{}
+
+	for rows.Next() {
+		rule := Rule{}
+
+		err = rows.Scan(&rule.ID, &rule.Title, &rule.Duration, &rule.Message)
+		if err != nil {
+			return
+		}
+
+		rules = append(rules, rule)
+	}
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	// This is the data we will serialize
+	m.Result = RulesType{Body: rules}
+
+	return
+
+}
+
+// boardRule returns a rule of a board by id
+func boardRule(h handle, ib, id uint) (rule Rule, err error) {
+
+	err = h.QueryRow(`SELECT rule_id, rule_title, rule_duration, rule_message
+    FROM rules WHERE ib_id = ? AND rule_id = ?`, ib, id).Scan(&rule.ID, &rule.Title, &rule.Duration, &rule.Message)
+	if err == sql.ErrNoRows {
+		return rule, e.ErrNotFound
+	} else if err != nil {
+		return
+	}
+
+	return
+
+}
+
+// RuleModel holds request input
+type RuleModel struct {
+	Ib   uint
+	User uint
+	Rule
+}
+
+// IsValid will check struct validity
+func (m *RuleModel) IsValid() bool {
+
+	if m.Ib == 0 {
+		return false
+	}
+
+	if m.User == 0 || m.User == 1 {
+		return false
+	}
+
+	if m.Title == "" {
+		return false
+	}
+
+	if m.Message == "" {
+		return false
+	}
+
+	return true
+
+}
+
+// ValidateInput checks the data input for correctness, the message is shown
+// to banned users
+func (m *RuleModel) ValidateInput() (err error) {
+
+	// Initialize bluemonday
+	p := bluemonday.StrictPolicy()
+
+	// sanitize for html and xss
+	m.Title = html.UnescapeString(p.Sanitize(m.Title))
+	m.Message = html.UnescapeString(p.Sanitize(m.Message))
+
+	// Validate title input
+	title := validate.Validate{Input: m.Title, Max: config.Settings.Limits.TitleMaxLength, Min: config.Settings.Limits.TitleMinLength}
+	if title.IsEmpty() {
+		return e.ErrNoTitle
+	} else if title.MinLength() {
+		return e.ErrTitleShort
+	} else if title.MaxLength() {
+		return e.ErrTitleLong
+	}
+
+	// Validate message input
+	message := validate.Validate{Input: m.Message, Max: config.Settings.Limits.CommentMaxLength, Min: config.Settings.Limits.CommentMinLength}
+	if message.IsEmpty() {
+		return e.ErrNoComment
+	} else if message.MinLength() {
+		return e.ErrCommentShort
+	} else if message.MaxLength() {
+		return e.ErrCommentLong
+	}
+
+	return
+
+}
+
+// Status will return the saved rule
+func (m *RuleModel) Status() (err error) {
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	m.Rule, err = boardRule(dbase, m.Ib, m.ID)
+	if err != nil {
+		return
+	}
+
+	return
+
+}
+
+// Post will add the rule to the board
+func (m *RuleModel) Post() (err error) {
+
+	// check model validity
+	if !m.IsValid() {
+		return errors.New("RuleModel is not valid")
+	}
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	result, err := dbase.Exec(`INSERT INTO rules (ib_id,user_id,rule_title,rule_duration,rule_message,rule_time)
+    VALUES (?,?,?,?,?,NOW())`, m.Ib, m.User, m.Title, m.Duration, m.Message)
+	if err != nil {
+		return
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return
+	}
+
+	m.ID = uint(id)
+
+	return
+
+}
+
+// Update will change the rule, bans already made keep their reason and expiry
+func (m *RuleModel) Update() (err error) {
+
+	// check model validity
+	if !m.IsValid() || m.ID == 0 {
+		return errors.New("RuleModel is not valid")
+	}
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	_, err = dbase.Exec(`UPDATE rules SET user_id = ?, rule_title = ?, rule_duration = ?, rule_message = ?, rule_time = NOW()
+    WHERE ib_id = ? AND rule_id = ?`, m.User, m.Title, m.Duration, m.Message, m.Ib, m.ID)
+	if err != nil {
+		return
+	}
+
+	return
+
+}
+
+// Delete will remove the rule, bans made for it keep the rule id
+func (m *RuleModel) Delete() (err error) {
+
+	if m.Ib == 0 || m.ID == 0 {
+		return errors.New("RuleModel is not valid")
+	}
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	_, err = dbase.Exec("DELETE FROM rules WHERE ib_id = ? AND rule_id = ?", m.Ib, m.ID)
+	if err != nil {
+		return
+	}
+
+	return
+
+}
+
+// BanStatsModel holds request input
+type BanStatsModel struct {
+	Ib     uint
+	Result BanStatsType
+}
+
+// BanStatsType is container for JSON response
+type BanStatsType struct {
+	Total uint      `json:"total"`
+	Rules []BanRule `json:"rules"`
+}
+
+// BanRule is the ban counts of a rule, rule zero holds the bans with a custom reason
+type BanRule struct {
+	ID    uint   `json:"rule_id"`
+	Title string `json:"rule_title"`
+	IPs   uint   `json:"ip_bans"`
+	Files uint   `json:"file_bans"`
+	Users uint   `json:"user_bans"`
+	Total uint   `json:"total"`
+}
+
+// Get will count the bans of the board by the rule they were made for
+func (m *BanStatsModel) Get() (err error) {
+
+	if m.Ib == 0 {
+		return e.ErrNotFound
+	}
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	rows, err := dbase.Query(`SELECT bans.rule_id, COALESCE(rule_title, ''),
+    SUM(ban_kind = 'ip'), SUM(ban_kind = 'file'), SUM(ban_kind = 'user'), COUNT(*)
+    FROM (
+        SELECT rule_id, 'ip' AS ban_kind FROM banned_ips WHERE ib_id = ?
+        UNION ALL SELECT rule_id, 'file' FROM banned_files WHERE ib_id = ?
+        UNION ALL SELECT rule_id, 'user' FROM banned_users WHERE ib_id = ?
+    ) AS bans
+    LEFT JOIN rules ON bans.rule_id = rules.rule_id
+    GROUP BY bans.rule_id, rule_title
+    ORDER BY COUNT(*) DESC, bans.rule_id ASC`, m.Ib, m.Ib, m.Ib)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	response := BanStatsType{
+		Rules: []BanRule{},
+	}
+
+	for rows.Next() {
+		rule := BanRule{}
+
+		err = rows.Scan(&rule.ID, &rule.Title, &rule.IPs, &rule.Files, &rule.Users, &rule.Total)
+		if err != nil {
+			return
+		}
+
+		response.Total += rule.Total
+		response.Rules = append(response.Rules, rule)
+	}
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	// This is the data we will serialize
+	m.Result = response
+
+	return
+
+}
@@ -0,0 +1,190 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/config"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+)
+
+var testRuleColumns = []string{"rule_id", "rule_title", "rule_duration", "rule_message"}
+
+func TestRuleReason(t *testing.T) {
+	rule := Rule{Message: "Rule 1: no spam"}
+
+	assert.Equal(t, "Rule 1: no spam", rule.Reason(""), "Rule message should be the reason")
+	assert.Equal(t, "Rule 1: no spam - viagra links", rule.Reason("viagra links"), "Custom reason should be added")
+}
+
+func TestRuleExpires(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	rule := Rule{}
+	assert.Nil(t, rule.Expires(now), "Zero duration should be permanent")
+
+	rule = Rule{Duration: 3}
+	if assert.NotNil(t, rule.Expires(now), "Duration should expire") {
+		assert.Equal(t, now.AddDate(0, 0, 3), *rule.Expires(now), "Ban should last the duration in days")
+	}
+}
+
+func TestRuleModelValidateInput(t *testing.T) {
+	config.Settings.Limits.TitleMinLength = 3
+	config.Settings.Limits.TitleMaxLength = 40
+	config.Settings.Limits.CommentMinLength = 3
+	config.Settings.Limits.CommentMaxLength = 100
+
+	m := &RuleModel{Rule: Rule{Title: "<b>Spam</b>", Message: "No spam"}}
+	assert.NoError(t, m.ValidateInput(), "Rule should be valid")
+	assert.Equal(t, "Spam", m.Title, "Title should be sanitized")
+
+	m = &RuleModel{Rule: Rule{Message: "No spam"}}
+	assert.Equal(t, e.ErrNoTitle, m.ValidateInput(), "Empty title should be rejected")
+
+	m = &RuleModel{Rule: Rule{Title: "Spam"}}
+	assert.Equal(t, e.ErrNoComment, m.ValidateInput(), "Empty message should be rejected")
+}
+
+func TestRulesModelGet(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT rule_id, rule_title, rule_duration, rule_message\s+FROM rules WHERE ib_id = \? ORDER BY rule_id ASC`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(testRuleColumns).
+			AddRow(1, "Spam", 7, "Rule 1: no spam").
+			AddRow(2, "Illegal content", 0, "Rule 2: nothing illegal"))
+
+	m := &RulesModel{Ib: 1}
+
+	err = m.Get()
+	assert.NoError(t, err, "No error should be returned")
+	if assert.Len(t, m.Result.Body, 2, "Should have two rules") {
+		assert.Equal(t, uint(7), m.Result.Body[0].Duration, "Duration should match")
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestRuleModelStatusNotFound(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT rule_id, rule_title, rule_duration, rule_message\s+FROM rules WHERE ib_id = \? AND rule_id = \?`).
+		WithArgs(1, 3).
+		WillReturnRows(sqlmock.NewRows(testRuleColumns))
+
+	m := &RuleModel{Ib: 1, Rule: Rule{ID: 3}}
+
+	err = m.Status()
+	assert.Equal(t, e.ErrNotFound, err, "Rule of another board should not be found")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestRuleModelPost(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectExec(`INSERT INTO rules`).
+		WithArgs(1, 2, "Spam", 7, "Rule 1: no spam").
+		WillReturnResult(sqlmock.NewResult(4, 1))
+
+	m := &RuleModel{Ib: 1, User: 2, Rule: Rule{Title: "Spam", Duration: 7, Message: "Rule 1: no spam"}}
+
+	err = m.Post()
+	assert.NoError(t, err, "No error should be returned")
+	assert.Equal(t, uint(4), m.ID, "Rule id should be set")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestRuleModelPostInvalid(t *testing.T) {
+	m := &RuleModel{Ib: 1, User: 1, Rule: Rule{Title: "Spam", Message: "Rule 1: no spam"}}
+
+	assert.Error(t, m.Post(), "Anonymous user should be rejected")
+}
+
+func TestRuleModelUpdate(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectExec(`UPDATE rules SET`).
+		WithArgs(2, "Spam", 0, "Rule 1: no spam", 1, 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	m := &RuleModel{Ib: 1, User: 2, Rule: Rule{ID: 4, Title: "Spam", Message: "Rule 1: no spam"}}
+
+	err = m.Update()
+	assert.NoError(t, err, "No error should be returned")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestRuleModelDelete(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectExec(`DELETE FROM rules WHERE ib_id = \? AND rule_id = \?`).
+		WithArgs(1, 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	m := &RuleModel{Ib: 1, Rule: Rule{ID: 4}}
+
+	err = m.Delete()
+	assert.NoError(t, err, "No error should be returned")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestBanStatsModelGet(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT bans.rule_id, COALESCE\(rule_title, ''\)`).
+		WithArgs(1, 1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"rule_id", "rule_title", "ip_bans", "file_bans", "user_bans", "total"}).
+			AddRow(1, "Spam", 5, 2, 1, 8).
+			AddRow(0, "", 3, 0, 0, 3))
+
+	m := &BanStatsModel{Ib: 1}
+
+	err = m.Get()
+	assert.NoError(t, err, "No error should be returned")
+	assert.Equal(t, uint(11), m.Result.Total, "Total should add up the rules")
+	if assert.Len(t, m.Result.Rules, 2, "Should have two groups") {
+		assert.Equal(t, "Spam", m.Result.Rules[0].Title, "Title should match")
+		assert.Equal(t, uint(0), m.Result.Rules[1].ID, "Custom reasons should be rule zero")
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestBanStatsModelGetNoBoard(t *testing.T) {
+	m := &BanStatsModel{}
+
+	assert.Equal(t, e.ErrNotFound, m.Get(), "Missing board should not be found")
+}
@@ -0,0 +1,251 @@
+package models
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/eirka/eirka-libs/config"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+
+	u "github.com/eirka/eirka-admin/utils"
+)
+
+// the ways the search query can be matched
+const (
+	SearchSubstring = "substring"
+	SearchRegex     = "regex"
+)
+
+// SearchMaxLength is the longest query that can be searched for
+const SearchMaxLength = 255
+
+// ErrSearchRegex is returned when the database refuses the regex of a search
+var ErrSearchRegex = errors.New("invalid regular expression")
+
+// the mysql error numbers of broken or too expensive patterns
+const (
+	mysqlRegexFirstError = 3685
+	mysqlRegexLastError  = 3700
+)
+
+// searchError maps the regex errors of the database to ErrSearchRegex
+func searchError(err error) error {
+
+	var merr *mysql.MySQLError
+	if errors.As(err, &merr) && merr.Number >= mysqlRegexFirstError && merr.Number <= mysqlRegexLastError {
+		return ErrSearchRegex
+	}
+
+	return err
+
+}
+
+// escapes the LIKE wildcards in substring queries
+var searchLikeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchFilter selects posts by their text or thread title, a board of zero
+// searches every board and the other filters are optional
+type SearchFilter struct {
+	Ib      uint      `json:"ib_id"`
+	Query   string    `json:"q"`
+	Mode    string    `json:"mode"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	IP      string    `json:"ip"`
+	Deleted *bool     `json:"deleted"`
+}
+
+// Selective returns true if the filter narrows the posts by their text, ip or
+// time, the board and deleted state alone select too much
+func (f *SearchFilter) Selective() bool {
+	return f.Query != "" || f.IP != "" || !f.Start.IsZero() || !f.End.IsZero()
+}
+
+// ValidateInput checks the filter for correctness
+func (f *SearchFilter) ValidateInput() (err error) {
+
+	if f.Mode == "" {
+		f.Mode = SearchSubstring
+	}
+
+	if !f.Selective() || len(f.Query) > SearchMaxLength {
+		return e.ErrInvalidParam
+	}
+
+	// patterns use the mysql regex dialect and are checked when they run
+	if f.Mode != SearchSubstring && f.Mode != SearchRegex {
+		return e.ErrInvalidParam
+	}
+
+	if f.IP != "" && net.ParseIP(f.IP) == nil {
+		return e.ErrIPParse
+	}
+
+	if !f.Start.IsZero() && !f.End.IsZero() && !f.Start.Before(f.End) {
+		return e.ErrInvalidParam
+	}
+
+	return
+
+}
+
+// Where returns the conditions and arguments that select the posts of the
+// filter, the query needs threads joined to posts
+func (f *SearchFilter) Where() (where string, args []interface{}) {
+
+	conditions := []string{}
+
+	if f.Ib != 0 {
+		conditions = append(conditions, "threads.ib_id = ?")
+		args = append(args, f.Ib)
+	}
+
+	switch {
+	case f.Query == "":
+	case f.Mode == SearchRegex:
+		conditions = append(conditions, "(post_text REGEXP ? OR thread_title REGEXP ?)")
+		args = append(args, f.Query, f.Query)
+	default:
+		like := "%" + searchLikeEscaper.Replace(f.Query) + "%"
+		conditions = append(conditions, "(post_text LIKE ? OR thread_title LIKE ?)")
+		args = append(args, like, like)
+	}
+
+	if !f.Start.IsZero() {
+		conditions = append(conditions, "post_time >= ?")
+		args = append(args, f.Start)
+	}
+
+	if !f.End.IsZero() {
+		conditions = append(conditions, "post_time < ?")
+		args = append(args, f.End)
+	}
+
+	if f.IP != "" {
+		conditions = append(conditions, "post_ip = ?")
+		args = append(args, f.IP)
+	}
+
+	if f.Deleted != nil {
+		if *f.Deleted {
+			conditions = append(conditions, "post_deleted = 1")
+		} else {
+			conditions = append(conditions, "post_deleted != 1")
+		}
+	}
+
+	where = strings.Join(conditions, " AND ")
+
+	return
+
+}
+
+// SearchModel holds request input
+type SearchModel struct {
+	SearchFilter
+	Page   uint
+	Result SearchType
+}
+
+// SearchType is container for JSON response
+type SearchType struct {
+	Body u.PagedResponse `json:"posts"`
+}
+
+// SearchPost is a post that matched the search
+type SearchPost struct {
+	Ib      uint       `json:"ib_id"`
+	Thread  uint       `json:"thread_id"`
+	Title   string     `json:"thread_title"`
+	Num     uint       `json:"post_num"`
+	Time    *time.Time `json:"post_time"`
+	Deleted bool       `json:"post_deleted"`
+	IP      string     `json:"post_ip"`
+	Text    *string    `json:"post_text"`
+}
+
+// Get will return the posts that match the filter, the newest first
+func (m *SearchModel) Get() (err error) {
+
+	if !m.Selective() || m.Page == 0 {
+		return e.ErrNotFound
+	}
+
+	// Initialize response header
+	response := SearchType{}
+
+	// to hold the matched posts
+	posts := []SearchPost{}
+
+	// Initialize struct for pagination
+	paged := u.PagedResponse{}
+	// Set current page to parameter
+	paged.CurrentPage = m.Page
+	// Set threads per index page to config setting
+	paged.PerPage = config.Settings.Limits.PostsPerPage
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	where, args := m.Where()
+
+	// Get total match count and put it in pagination struct
+	err = dbase.QueryRow(`SELECT COUNT(post_id) FROM threads
+    INNER JOIN posts ON threads.thread_id = posts.thread_id
+    WHERE `+where, args...).Scan(&paged.Total)
+	if err != nil {
+		return searchError(err)
+	}
+
+	// Calculate Limit and total Pages
+	paged.Get()
+
+	// Return 404 if page requested is larger than actual pages
+	if m.Page > paged.Pages {
+		return e.ErrNotFound
+	}
+
+	rows, err := dbase.Query(`SELECT ib_id, threads.thread_id, thread_title, post_num, post_time, post_deleted, post_ip, post_text
+    FROM threads
+    INNER JOIN posts ON threads.thread_id = posts.thread_id
+    WHERE `+where+`
+    ORDER BY post_id DESC LIMIT ?,?`, append(args, paged.Limit, paged.PerPage)...)
+	if err != nil {
+		return searchError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		post := SearchPost{}
+
+		err = rows.Scan(&post.Ib, &post.Thread, &post.Title, &post.Num, &post.Time, &post.Deleted, &post.IP, &post.Text)
+		if err != nil {
+			return
+		}
+
+		posts = append(posts, post)
+	}
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	// Add posts slice to items interface
+	paged.Items = posts
+
+	// Add pagedresponse to the response struct
+	response.Body = paged
+
+	// This is the data we will serialize
+	m.Result = response
+
+	return
+
+}
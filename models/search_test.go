@@ -0,0 +1,205 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/config"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+)
+
+func TestSearchFilterValidateInput(t *testing.T) {
+	start := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		filter SearchFilter
+		err    error
+	}{
+		{"substring", SearchFilter{Query: "buy now"}, nil},
+		{"regex", SearchFilter{Query: `https?://spam\.example`, Mode: SearchRegex}, nil},
+		{"empty", SearchFilter{}, e.ErrInvalidParam},
+		{"ip only", SearchFilter{IP: "10.0.0.1"}, nil},
+		{"time only", SearchFilter{Start: start}, nil},
+		{"bad mode", SearchFilter{Query: "spam", Mode: "fuzzy"}, e.ErrInvalidParam},
+		{"bad ip", SearchFilter{Query: "spam", IP: "10.0.0"}, e.ErrIPParse},
+		{"bad range", SearchFilter{Query: "spam", Start: start, End: start}, e.ErrInvalidParam},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.err, test.filter.ValidateInput(), "Error should match")
+		})
+	}
+}
+
+func TestSearchFilterWhere(t *testing.T) {
+	start := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	deleted := false
+
+	f := SearchFilter{
+		Ib:      1,
+		Query:   "100%_off",
+		Mode:    SearchSubstring,
+		Start:   start,
+		IP:      "10.0.0.1",
+		Deleted: &deleted,
+	}
+
+	where, args := f.Where()
+
+	assert.Equal(t, "threads.ib_id = ? AND (post_text LIKE ? OR thread_title LIKE ?) AND post_time >= ? AND post_ip = ? AND post_deleted != 1", where, "Conditions should match")
+	assert.Equal(t, []interface{}{uint(1), `%100\%\_off%`, `%100\%\_off%`, start, "10.0.0.1"}, args, "Arguments should match")
+
+	site := SearchFilter{
+		Query: "spam",
+		Mode:  SearchRegex,
+	}
+
+	where, args = site.Where()
+
+	assert.Equal(t, "(post_text REGEXP ? OR thread_title REGEXP ?)", where, "Conditions should match")
+	assert.Equal(t, []interface{}{"spam", "spam"}, args, "Arguments should match")
+
+	ip := SearchFilter{
+		Ib: 1,
+		IP: "10.0.0.1",
+	}
+
+	where, args = ip.Where()
+
+	assert.Equal(t, "threads.ib_id = ? AND post_ip = ?", where, "Conditions should match")
+	assert.Equal(t, []interface{}{uint(1), "10.0.0.1"}, args, "Arguments should match")
+}
+
+func TestSearchModelGet(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	config.Settings.Limits.PostsPerPage = 10
+
+	posted := time.Date(2024, 3, 10, 15, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT COUNT\(post_id\) FROM threads`).
+		WithArgs(1, "%spam%", "%spam%").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	mock.ExpectQuery(`SELECT ib_id, threads.thread_id, thread_title, post_num, post_time, post_deleted, post_ip, post_text`).
+		WithArgs(1, "%spam%", "%spam%", 0, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id", "thread_id", "thread_title", "post_num", "post_time", "post_deleted", "post_ip", "post_text"}).
+			AddRow(1, 2, "Test Thread", 5, posted, false, "10.0.0.1", "cheap spam here"))
+
+	m := &SearchModel{
+		SearchFilter: SearchFilter{
+			Ib:    1,
+			Query: "spam",
+			Mode:  SearchSubstring,
+		},
+		Page: 1,
+	}
+
+	err = m.Get()
+	assert.NoError(t, err, "No error should be returned")
+
+	posts, ok := m.Result.Body.Items.([]SearchPost)
+	if assert.True(t, ok, "Items should be posts") && assert.Equal(t, 1, len(posts), "Should have one post") {
+		assert.Equal(t, "cheap spam here", *posts[0].Text, "Text should match")
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestSearchModelGetPageNotFound(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	config.Settings.Limits.PostsPerPage = 10
+
+	mock.ExpectQuery(`SELECT COUNT\(post_id\) FROM threads`).
+		WithArgs("spam", "spam").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	m := &SearchModel{
+		SearchFilter: SearchFilter{
+			Query: "spam",
+			Mode:  SearchRegex,
+		},
+		Page: 2,
+	}
+
+	err = m.Get()
+	assert.Equal(t, e.ErrNotFound, err, "Error should be ErrNotFound")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestSearchModelGetBadRegex(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	config.Settings.Limits.PostsPerPage = 10
+
+	// ER_REGEXP_MISMATCHED_PAREN
+	mock.ExpectQuery(`SELECT COUNT\(post_id\) FROM threads`).
+		WithArgs("spam(", "spam(").
+		WillReturnError(&mysql.MySQLError{Number: 3691, Message: "Mismatched parenthesis in regular expression."})
+
+	m := &SearchModel{
+		SearchFilter: SearchFilter{
+			Query: "spam(",
+			Mode:  SearchRegex,
+		},
+		Page: 1,
+	}
+
+	err = m.Get()
+	assert.Equal(t, ErrSearchRegex, err, "Error should be ErrSearchRegex")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestSearchModelGetIP(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	config.Settings.Limits.PostsPerPage = 10
+
+	mock.ExpectQuery(`SELECT COUNT\(post_id\) FROM threads\s+INNER JOIN posts ON threads.thread_id = posts.thread_id\s+WHERE threads.ib_id = \? AND post_ip = \?$`).
+		WithArgs(1, "10.0.0.1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	mock.ExpectQuery(`SELECT ib_id, threads.thread_id, thread_title, post_num, post_time, post_deleted, post_ip, post_text`).
+		WithArgs(1, "10.0.0.1", 0, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id", "thread_id", "thread_title", "post_num", "post_time", "post_deleted", "post_ip", "post_text"}).
+			AddRow(1, 2, "Test Thread", 5, time.Now(), false, "10.0.0.1", "image only"))
+
+	m := &SearchModel{
+		SearchFilter: SearchFilter{
+			Ib: 1,
+			IP: "10.0.0.1",
+		},
+		Page: 1,
+	}
+
+	err = m.Get()
+	assert.NoError(t, err, "No error should be returned")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
@@ -0,0 +1,179 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/eirka/eirka-libs/db"
+)
+
+// SiteStatisticsModel holds request input
+type SiteStatisticsModel struct {
+	Result SiteStatisticsType
+}
+
+// SiteStatisticsType holds the analytics metadata of every board and the site totals
+type SiteStatisticsType struct {
+	Visitors uint              `json:"visitors"`
+	Hits     uint              `json:"hits"`
+	Threads  uint              `json:"threads"`
+	Posts    uint              `json:"posts"`
+	Images   uint              `json:"images"`
+	Boards   []BoardStatistics `json:"boards"`
+	Labels   []time.Time       `json:"labels"`
+	Series   []Series          `json:"series"`
+}
+
+// BoardStatistics holds the counts of a single board
+type BoardStatistics struct {
+	ID       uint   `json:"id"`
+	Title    string `json:"title"`
+	Visitors uint   `json:"visitors"`
+	Hits     uint   `json:"hits"`
+	Threads  uint   `json:"threads"`
+	Posts    uint   `json:"posts"`
+	Images   uint   `json:"images"`
+}
+
+// Get will gather the counts of all boards and the hourly site series of the last day
+func (m *SiteStatisticsModel) Get() (err error) {
+
+	// Initialize response header
+	response := SiteStatisticsType{
+		Boards: []BoardStatistics{},
+	}
+
+	// holds visitors info
+	visitors := Series{
+		Name: "Visitors",
+	}
+
+	// holds count of hits
+	hits := Series{
+		Name: "Hits",
+	}
+
+	// the site series uses the default board chart
+	chart := StatisticsModel{Range: "24h", Bucket: "hour"}
+	buckets := chart.Buckets(time.Now())
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	// get the stats of every board at once
+	boards, err := dbase.Query(`SELECT ib_id, ib_title, ` + statisticsBoardColumns + `
+    FROM imageboards ORDER BY ib_id ASC`)
+	if err != nil {
+		return
+	}
+	defer boards.Close()
+
+	// where each board is in the response
+	index := make(map[uint]int)
+
+	for boards.Next() {
+		board := BoardStatistics{}
+
+		err = boards.Scan(&board.ID, &board.Title, &board.Threads, &board.Posts, &board.Images)
+		if err != nil {
+			return
+		}
+
+		response.Threads += board.Threads
+		response.Posts += board.Posts
+		response.Images += board.Images
+
+		index[board.ID] = len(response.Boards)
+		response.Boards = append(response.Boards, board)
+	}
+	if boards.Err() != nil {
+		return boards.Err()
+	}
+
+	// get the visitors of every board, the rollup row holds the site totals
+	// since a visitor can be on more than one board
+	rows, err := dbase.Query(`SELECT ib_id, COUNT(DISTINCT request_ip) as visitors, COUNT(request_itemkey) as hits
+    FROM analytics
+    WHERE request_time >= ?
+    GROUP BY ib_id WITH ROLLUP`, buckets[0])
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ib sql.NullInt64
+		var visitorCount, hitCount uint
+
+		err = rows.Scan(&ib, &visitorCount, &hitCount)
+		if err != nil {
+			return
+		}
+
+		// the rollup row
+		if !ib.Valid {
+			response.Visitors = visitorCount
+			response.Hits = hitCount
+			continue
+		}
+
+		i, ok := index[uint(ib.Int64)]
+		if !ok {
+			continue
+		}
+
+		response.Boards[i].Visitors = visitorCount
+		response.Boards[i].Hits = hitCount
+	}
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	// every bucket starts empty in case there were no requests
+	visitors.Data = make([]uint, len(buckets))
+	hits.Data = make([]uint, len(buckets))
+
+	series, err := dbase.Query(`SELECT FLOOR(TIMESTAMPDIFF(SECOND, ?, request_time) / ?) AS bucket,
+    COUNT(DISTINCT request_ip) as visitors, COUNT(request_itemkey) as hits
+    FROM analytics
+    WHERE request_time >= ?
+    GROUP BY bucket`, buckets[0], int64(time.Hour/time.Second), buckets[0])
+	if err != nil {
+		return
+	}
+	defer series.Close()
+
+	for series.Next() {
+		var bucket int64
+		var visitorCount, hitCount uint
+
+		err = series.Scan(&bucket, &visitorCount, &hitCount)
+		if err != nil {
+			return
+		}
+
+		// skip anything outside of the chart
+		if bucket < 0 || bucket >= int64(len(buckets)) {
+			continue
+		}
+
+		visitors.Data[bucket] = visitorCount
+		hits.Data[bucket] = hitCount
+	}
+	if series.Err() != nil {
+		return series.Err()
+	}
+
+	response.Labels = buckets
+
+	response.Series = append(response.Series, visitors, hits)
+
+	// This is the data we will serialize
+	m.Result = response
+
+	return
+
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/db"
+)
+
+// the board stats query for every board
+const testSiteBoardStatsQuery = `SELECT ib_id, ib_title, \(SELECT COUNT\(thread_id\).*FROM imageboards ORDER BY ib_id ASC`
+
+// the visitors of every board
+const testSiteVisitorStatsQuery = `SELECT ib_id, COUNT\(DISTINCT request_ip\) as visitors.*GROUP BY ib_id WITH ROLLUP`
+
+// the hourly site series
+const testSiteSeriesQuery = `SELECT FLOOR\(TIMESTAMPDIFF\(SECOND, \?, request_time\) / \?\) AS bucket,.*WHERE request_time >= \?\s+GROUP BY bucket$`
+
+func TestSiteStatisticsModelGet(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	m := &SiteStatisticsModel{}
+
+	mock.ExpectQuery(testSiteBoardStatsQuery).
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id", "ib_title", "thread_count", "post_count", "image_count"}).
+			AddRow(1, "Pictures", 10, 100, 50).
+			AddRow(2, "Music", 5, 20, 1))
+
+	mock.ExpectQuery(testSiteVisitorStatsQuery).
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id", "visitors", "hits"}).
+			AddRow(1, 30, 300).
+			AddRow(2, 20, 100).
+			AddRow(nil, 40, 400))
+
+	mock.ExpectQuery(testSiteSeriesQuery).
+		WithArgs(sqlmock.AnyArg(), 3600, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"bucket", "visitors", "hits"}).
+			AddRow(0, 10, 100).
+			AddRow(23, 30, 300))
+
+	err = m.Get()
+	assert.NoError(t, err, "No error should be returned")
+
+	// site totals
+	assert.Equal(t, uint(15), m.Result.Threads, "Threads should be summed")
+	assert.Equal(t, uint(120), m.Result.Posts, "Posts should be summed")
+	assert.Equal(t, uint(51), m.Result.Images, "Images should be summed")
+	assert.Equal(t, uint(40), m.Result.Visitors, "Visitors should come from the rollup row")
+	assert.Equal(t, uint(400), m.Result.Hits, "Hits should come from the rollup row")
+
+	if assert.Equal(t, 2, len(m.Result.Boards), "Should have every board") {
+		assert.Equal(t, BoardStatistics{ID: 1, Title: "Pictures", Visitors: 30, Hits: 300, Threads: 10, Posts: 100, Images: 50}, m.Result.Boards[0], "Board should match")
+		assert.Equal(t, uint(20), m.Result.Boards[1].Visitors, "Board visitors should match")
+	}
+
+	assert.Equal(t, 24, len(m.Result.Labels), "Should have 24 data points")
+	if assert.Equal(t, 2, len(m.Result.Series), "Should have 2 series") {
+		assert.Equal(t, uint(10), m.Result.Series[0].Data[0], "First bucket should match")
+		assert.Equal(t, uint(300), m.Result.Series[1].Data[23], "Last bucket should match")
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestSiteStatisticsModelGetError(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	m := &SiteStatisticsModel{}
+
+	mock.ExpectQuery(testSiteBoardStatsQuery).
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id", "ib_title", "thread_count", "post_count", "image_count"}).
+			AddRow(1, "Pictures", 10, 100, 50))
+
+	mock.ExpectQuery(testSiteVisitorStatsQuery).
+		WillReturnError(errors.New("database error"))
+
+	err = m.Get()
+	if assert.Error(t, err, "Error should be returned") {
+		assert.Contains(t, err.Error(), "database error", "Error should contain the expected error message")
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
@@ -1,14 +1,51 @@
 package models
 
 import (
+	"database/sql"
 	"time"
 
 	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
 )
 
+// StatisticsRanges are the time windows that can be requested
+var StatisticsRanges = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+	"90d": 90 * 24 * time.Hour,
+	"1y":  365 * 24 * time.Hour,
+}
+
+// StatisticsRawRetention is how long the raw analytics are kept, longer ranges
+// are read from the daily rollups
+const StatisticsRawRetention = 30 * 24 * time.Hour
+
+// the thread, post and image counts of the board in the imageboards row
+const statisticsBoardColumns = `(SELECT COUNT(thread_id)
+    FROM threads
+    WHERE threads.ib_id=imageboards.ib_id AND thread_deleted != 1) AS thread_count,
+    (SELECT COUNT(post_id)
+    FROM threads
+    LEFT JOIN posts ON posts.thread_id = threads.thread_id
+    WHERE threads.ib_id=imageboards.ib_id AND post_deleted != 1) AS post_count,
+    (SELECT COUNT(image_id)
+    FROM threads
+    LEFT JOIN posts ON posts.thread_id = threads.thread_id
+    LEFT JOIN images ON images.post_id = posts.post_id
+    WHERE threads.ib_id=imageboards.ib_id AND post_deleted != 1) AS image_count`
+
+// StatisticsBuckets are the bucket sizes that can be requested
+var StatisticsBuckets = map[string]time.Duration{
+	"hour": time.Hour,
+	"day":  24 * time.Hour,
+}
+
 // StatisticsModel holds request input
 type StatisticsModel struct {
 	Ib     uint
+	Range  string
+	Bucket string
 	Result StatisticsType
 }
 
@@ -29,9 +66,77 @@ type Series struct {
 	Data []uint `json:"data"`
 }
 
+// IsValid will check struct validity
+func (m *StatisticsModel) IsValid() bool {
+
+	if m.Ib == 0 {
+		return false
+	}
+
+	period, ok := StatisticsRanges[m.Range]
+	if !ok {
+		return false
+	}
+
+	bucket, ok := StatisticsBuckets[m.Bucket]
+	if !ok {
+		return false
+	}
+
+	// the range has to hold at least one bucket
+	if bucket > period {
+		return false
+	}
+
+	// rollups only have daily totals
+	if m.Rollup() && bucket != StatisticsBuckets["day"] {
+		return false
+	}
+
+	return true
+
+}
+
+// Rollup returns true if the range is read from the daily rollups
+func (m *StatisticsModel) Rollup() bool {
+	return StatisticsRanges[m.Range] > StatisticsRawRetention
+}
+
+// Buckets returns the start of every bucket in the range, the last one is the
+// bucket that now falls in, starts are aligned to the hour or day in UTC. Rollup
+// ranges end with yesterday since today has not been rolled up yet
+func (m *StatisticsModel) Buckets(now time.Time) (buckets []time.Time) {
+
+	size := StatisticsBuckets[m.Bucket]
+	count := int(StatisticsRanges[m.Range] / size)
+
+	now = now.UTC()
+
+	current := now.Truncate(time.Hour)
+	if size == StatisticsBuckets["day"] {
+		current = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	if m.Rollup() {
+		current = current.AddDate(0, 0, -1)
+	}
+
+	for i := count - 1; i >= 0; i-- {
+		buckets = append(buckets, current.Add(-time.Duration(i)*size))
+	}
+
+	return
+
+}
+
 // Get will gather the information from the database and return it as JSON serialized data
 func (m *StatisticsModel) Get() (err error) {
 
+	// check model validity
+	if !m.IsValid() {
+		return e.ErrInvalidParam
+	}
+
 	// Initialize response header
 	response := StatisticsType{}
 
@@ -52,68 +157,119 @@ func (m *StatisticsModel) Get() (err error) {
 	}
 
 	// get board stats
-	err = dbase.QueryRow(`SELECT (SELECT COUNT(thread_id)
-    FROM threads
-    WHERE threads.ib_id=imageboards.ib_id AND thread_deleted != 1) AS thread_count,
-    (SELECT COUNT(post_id)
-    FROM threads
-    LEFT JOIN posts ON posts.thread_id = threads.thread_id
-    WHERE threads.ib_id=imageboards.ib_id AND post_deleted != 1) AS post_count,
-    (SELECT COUNT(image_id)
-    FROM threads
-    LEFT JOIN posts ON posts.thread_id = threads.thread_id
-    LEFT JOIN images ON images.post_id = posts.post_id
-    WHERE threads.ib_id=imageboards.ib_id AND post_deleted != 1) AS image_count
+	err = dbase.QueryRow(`SELECT `+statisticsBoardColumns+`
     FROM imageboards WHERE ib_id = ?`, m.Ib).Scan(&response.Threads, &response.Posts, &response.Images)
 	if err != nil {
 		return
 	}
 
-	// get visitor stats
-	err = dbase.QueryRow(`SELECT COUNT(DISTINCT request_ip) as visitors, COUNT(request_itemkey) as hits
-    FROM analytics
-    WHERE request_time BETWEEN (CURDATE() - INTERVAL 1 DAY) AND now() AND ib_id = ?`, m.Ib).Scan(&response.Visitors, &response.Hits)
+	// the aligned start of every bucket in the chart
+	buckets := m.Buckets(time.Now())
+
+	// every bucket starts empty in case there were no requests
+	visitors.Data = make([]uint, len(buckets))
+	hits.Data = make([]uint, len(buckets))
+
+	if m.Rollup() {
+		err = m.rollupStats(dbase, buckets, &response, visitors.Data, hits.Data)
+	} else {
+		err = m.rawStats(dbase, buckets, &response, visitors.Data, hits.Data)
+	}
 	if err != nil {
 		return
 	}
 
-	// get visitor period stats for chart
-	ps1, err := dbase.Prepare(`SELECT (now() - interval ? hour) as time,
+	response.Labels = buckets
+
+	response.Series = append(response.Series, visitors, hits)
+
+	// This is the data we will serialize
+	m.Result = response
+
+	return
+
+}
+
+// rawStats fills the buckets from the analytics table with one grouped query,
+// the rollup row holds the totals for the range
+func (m *StatisticsModel) rawStats(dbase *sql.DB, buckets []time.Time, response *StatisticsType, visitors, hits []uint) (err error) {
+
+	size := StatisticsBuckets[m.Bucket]
+
+	rows, err := dbase.Query(`SELECT FLOOR(TIMESTAMPDIFF(SECOND, ?, request_time) / ?) AS bucket,
     COUNT(DISTINCT request_ip) as visitors, COUNT(request_itemkey) as hits
     FROM analytics
-    WHERE request_time BETWEEN (now() - interval ? hour) AND (now() - interval ? hour) AND ib_id = ?`)
+    WHERE request_time >= ? AND ib_id = ?
+    GROUP BY bucket WITH ROLLUP`, buckets[0], int64(size/time.Second), buckets[0], m.Ib)
 	if err != nil {
 		return
 	}
-	defer ps1.Close()
+	defer rows.Close()
 
-	// loop through every four hours
-	for hour := 24; hour >= 4; hour-- {
-		if hour%4 == 0 {
+	for rows.Next() {
+		var bucket sql.NullInt64
+		var visitorCount, hitCount uint
 
-			var label time.Time
-			var visitorCount, hitCount uint
+		err = rows.Scan(&bucket, &visitorCount, &hitCount)
+		if err != nil {
+			return
+		}
 
-			// period minus two hours
-			previous := (hour - 4)
+		// the rollup row
+		if !bucket.Valid {
+			response.Visitors = visitorCount
+			response.Hits = hitCount
+			continue
+		}
 
-			err := ps1.QueryRow(hour, hour, previous, m.Ib).Scan(&label, &visitorCount, &hitCount)
-			if err != nil {
-				return err
-			}
+		// skip anything outside of the chart
+		if bucket.Int64 < 0 || bucket.Int64 >= int64(len(buckets)) {
+			continue
+		}
 
-			response.Labels = append(response.Labels, label)
-			visitors.Data = append(visitors.Data, visitorCount)
-			hits.Data = append(hits.Data, hitCount)
+		visitors[bucket.Int64] = visitorCount
+		hits[bucket.Int64] = hitCount
+	}
 
-		}
+	return rows.Err()
+
+}
+
+// rollupStats fills the daily buckets from the analytics rollups, the visitor
+// total is the sum of the daily unique visitors
+func (m *StatisticsModel) rollupStats(dbase *sql.DB, buckets []time.Time, response *StatisticsType, visitors, hits []uint) (err error) {
+
+	end := buckets[len(buckets)-1].AddDate(0, 0, 1)
+
+	rows, err := dbase.Query(`SELECT DATEDIFF(rollup_date, ?) AS bucket, visitors, hits
+    FROM analytics_daily
+    WHERE ib_id = ? AND rollup_date >= ? AND rollup_date < ?`, buckets[0], m.Ib, buckets[0], end)
+	if err != nil {
+		return
 	}
+	defer rows.Close()
 
-	response.Series = append(response.Series, visitors, hits)
+	for rows.Next() {
+		var bucket int64
+		var visitorCount, hitCount uint
 
-	// This is the data we will serialize
-	m.Result = response
+		err = rows.Scan(&bucket, &visitorCount, &hitCount)
+		if err != nil {
+			return
+		}
 
-	return
+		// skip anything outside of the chart
+		if bucket < 0 || bucket >= int64(len(buckets)) {
+			continue
+		}
+
+		visitors[bucket] = visitorCount
+		hits[bucket] = hitCount
+
+		response.Visitors += visitorCount
+		response.Hits += hitCount
+	}
+
+	return rows.Err()
 
 }
@@ -9,8 +9,18 @@ import (
 	"gopkg.in/DATA-DOG/go-sqlmock.v1"
 
 	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
 )
 
+// the board stats query
+const testBoardStatsQuery = `SELECT \(SELECT COUNT\(thread_id\).*FROM imageboards WHERE ib_id = \?`
+
+// the grouped visitor stats query
+const testVisitorStatsQuery = `SELECT FLOOR\(TIMESTAMPDIFF\(SECOND, \?, request_time\) / \?\) AS bucket,.*WHERE request_time >= \? AND ib_id = \?\s+GROUP BY bucket WITH ROLLUP`
+
+// the daily rollup stats query
+const testRollupStatsQuery = `SELECT DATEDIFF\(rollup_date, \?\) AS bucket, visitors, hits\s+FROM analytics_daily`
+
 func TestStatisticsModelGet(t *testing.T) {
 	var err error
 
@@ -20,32 +30,24 @@ func TestStatisticsModelGet(t *testing.T) {
 
 	// Initialize model with parameters
 	m := &StatisticsModel{
-		Ib: 1,
+		Ib:     1,
+		Range:  "24h",
+		Bucket: "hour",
 	}
 
 	// Mock the board stats query
-	mock.ExpectQuery(`SELECT \(SELECT COUNT\(thread_id\).*FROM imageboards WHERE ib_id = \?`).
+	mock.ExpectQuery(testBoardStatsQuery).
 		WithArgs(m.Ib).
 		WillReturnRows(sqlmock.NewRows([]string{"thread_count", "post_count", "image_count"}).
 			AddRow(10, 100, 50))
 
-	// Mock the visitor stats query
-	mock.ExpectQuery(`SELECT COUNT\(DISTINCT request_ip\) as visitors, COUNT\(request_itemkey\) as hits.*WHERE request_time BETWEEN \(CURDATE\(\) - INTERVAL 1 DAY\) AND now\(\) AND ib_id = \?`).
-		WithArgs(m.Ib).
-		WillReturnRows(sqlmock.NewRows([]string{"visitors", "hits"}).
-			AddRow(200, 500))
-
-	// Mock the chart data prepare
-	mock.ExpectPrepare(`SELECT \(now\(\) - interval \? hour\) as time.*AND ib_id = \?`)
-
-	// Mock period data (6 periods at every 4 hours)
-	timestamp := time.Now()
-	for hour := 24; hour >= 4; hour -= 4 {
-		mock.ExpectQuery(`SELECT \(now\(\) - interval \? hour\) as time.*AND ib_id = \?`).
-			WithArgs(hour, hour, hour-4, m.Ib).
-			WillReturnRows(sqlmock.NewRows([]string{"time", "visitors", "hits"}).
-				AddRow(timestamp.Add(-time.Hour*time.Duration(hour)), 20, 50))
-	}
+	// Mock the grouped visitor stats, empty buckets are not returned
+	mock.ExpectQuery(testVisitorStatsQuery).
+		WithArgs(sqlmock.AnyArg(), 3600, sqlmock.AnyArg(), m.Ib).
+		WillReturnRows(sqlmock.NewRows([]string{"bucket", "visitors", "hits"}).
+			AddRow(0, 20, 50).
+			AddRow(23, 30, 60).
+			AddRow(nil, 45, 110))
 
 	// Get the statistics
 	err = m.Get()
@@ -55,13 +57,57 @@ func TestStatisticsModelGet(t *testing.T) {
 	assert.Equal(t, uint(10), m.Result.Threads, "Threads count should match")
 	assert.Equal(t, uint(100), m.Result.Posts, "Posts count should match")
 	assert.Equal(t, uint(50), m.Result.Images, "Images count should match")
-	assert.Equal(t, uint(200), m.Result.Visitors, "Visitors count should match")
-	assert.Equal(t, uint(500), m.Result.Hits, "Hits count should match")
-	assert.Equal(t, 6, len(m.Result.Labels), "Should have 6 data points")
+	assert.Equal(t, uint(45), m.Result.Visitors, "Visitors should come from the rollup row")
+	assert.Equal(t, uint(110), m.Result.Hits, "Hits should come from the rollup row")
+	assert.Equal(t, 24, len(m.Result.Labels), "Should have 24 data points")
 	assert.Equal(t, 2, len(m.Result.Series), "Should have 2 series")
 	assert.Equal(t, "Visitors", m.Result.Series[0].Name, "First series should be visitors")
 	assert.Equal(t, "Hits", m.Result.Series[1].Name, "Second series should be hits")
 
+	// Verify the buckets were filled
+	assert.Equal(t, 24, len(m.Result.Series[0].Data), "Every bucket should have a value")
+	assert.Equal(t, uint(20), m.Result.Series[0].Data[0], "First bucket should match")
+	assert.Equal(t, uint(0), m.Result.Series[0].Data[1], "Empty buckets should be zero")
+	assert.Equal(t, uint(60), m.Result.Series[1].Data[23], "Last bucket should match")
+
+	// Verify all expectations were met
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestStatisticsModelGetOutOfRange(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	// Initialize model with parameters
+	m := &StatisticsModel{
+		Ib:     1,
+		Range:  "7d",
+		Bucket: "day",
+	}
+
+	// Mock the board stats query
+	mock.ExpectQuery(testBoardStatsQuery).
+		WithArgs(m.Ib).
+		WillReturnRows(sqlmock.NewRows([]string{"thread_count", "post_count", "image_count"}).
+			AddRow(10, 100, 50))
+
+	// Mock a bucket past the current day
+	mock.ExpectQuery(testVisitorStatsQuery).
+		WithArgs(sqlmock.AnyArg(), 86400, sqlmock.AnyArg(), m.Ib).
+		WillReturnRows(sqlmock.NewRows([]string{"bucket", "visitors", "hits"}).
+			AddRow(7, 20, 50).
+			AddRow(nil, 20, 50))
+
+	// Get the statistics
+	err = m.Get()
+	assert.NoError(t, err, "No error should be returned")
+
+	assert.Equal(t, 7, len(m.Result.Labels), "Should have 7 data points")
+	assert.Equal(t, []uint{0, 0, 0, 0, 0, 0, 0}, m.Result.Series[0].Data, "Out of range buckets should be skipped")
+
 	// Verify all expectations were met
 	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
 }
@@ -72,7 +118,9 @@ func TestStatisticsModelGetDbError(t *testing.T) {
 
 	// Initialize model with parameters
 	m := &StatisticsModel{
-		Ib: 1,
+		Ib:     1,
+		Range:  "24h",
+		Bucket: "hour",
 	}
 
 	// Get the statistics - should encounter GetDb error
@@ -90,11 +138,13 @@ func TestStatisticsModelBoardStatsError(t *testing.T) {
 
 	// Initialize model with parameters
 	m := &StatisticsModel{
-		Ib: 1,
+		Ib:     1,
+		Range:  "24h",
+		Bucket: "hour",
 	}
 
 	// Mock the board stats query with error
-	mock.ExpectQuery(`SELECT \(SELECT COUNT\(thread_id\).*FROM imageboards WHERE ib_id = \?`).
+	mock.ExpectQuery(testBoardStatsQuery).
 		WithArgs(m.Ib).
 		WillReturnError(errors.New("database error"))
 
@@ -116,18 +166,20 @@ func TestStatisticsModelVisitorStatsError(t *testing.T) {
 
 	// Initialize model with parameters
 	m := &StatisticsModel{
-		Ib: 1,
+		Ib:     1,
+		Range:  "24h",
+		Bucket: "hour",
 	}
 
 	// Mock the board stats query
-	mock.ExpectQuery(`SELECT \(SELECT COUNT\(thread_id\).*FROM imageboards WHERE ib_id = \?`).
+	mock.ExpectQuery(testBoardStatsQuery).
 		WithArgs(m.Ib).
 		WillReturnRows(sqlmock.NewRows([]string{"thread_count", "post_count", "image_count"}).
 			AddRow(10, 100, 50))
 
 	// Mock the visitor stats query with error
-	mock.ExpectQuery(`SELECT COUNT\(DISTINCT request_ip\) as visitors, COUNT\(request_itemkey\) as hits.*WHERE request_time BETWEEN \(CURDATE\(\) - INTERVAL 1 DAY\) AND now\(\) AND ib_id = \?`).
-		WithArgs(m.Ib).
+	mock.ExpectQuery(testVisitorStatsQuery).
+		WithArgs(sqlmock.AnyArg(), 3600, sqlmock.AnyArg(), m.Ib).
 		WillReturnError(errors.New("database error"))
 
 	// Get the statistics
@@ -139,7 +191,7 @@ func TestStatisticsModelVisitorStatsError(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
 }
 
-func TestStatisticsModelPrepareError(t *testing.T) {
+func TestStatisticsModelVisitorStatsScanError(t *testing.T) {
 	var err error
 
 	mock, err := db.NewTestDb()
@@ -148,35 +200,84 @@ func TestStatisticsModelPrepareError(t *testing.T) {
 
 	// Initialize model with parameters
 	m := &StatisticsModel{
-		Ib: 1,
+		Ib:     1,
+		Range:  "24h",
+		Bucket: "hour",
 	}
 
 	// Mock the board stats query
-	mock.ExpectQuery(`SELECT \(SELECT COUNT\(thread_id\).*FROM imageboards WHERE ib_id = \?`).
+	mock.ExpectQuery(testBoardStatsQuery).
 		WithArgs(m.Ib).
 		WillReturnRows(sqlmock.NewRows([]string{"thread_count", "post_count", "image_count"}).
 			AddRow(10, 100, 50))
 
-	// Mock the visitor stats query
-	mock.ExpectQuery(`SELECT COUNT\(DISTINCT request_ip\) as visitors, COUNT\(request_itemkey\) as hits.*WHERE request_time BETWEEN \(CURDATE\(\) - INTERVAL 1 DAY\) AND now\(\) AND ib_id = \?`).
-		WithArgs(m.Ib).
-		WillReturnRows(sqlmock.NewRows([]string{"visitors", "hits"}).
-			AddRow(200, 500))
-
-	// Mock the chart data prepare with error
-	mock.ExpectPrepare(`SELECT \(now\(\) - interval \? hour\) as time.*AND ib_id = \?`).
-		WillReturnError(errors.New("prepare error"))
+	// Mock a row that cannot be scanned
+	mock.ExpectQuery(testVisitorStatsQuery).
+		WithArgs(sqlmock.AnyArg(), 3600, sqlmock.AnyArg(), m.Ib).
+		WillReturnRows(sqlmock.NewRows([]string{"bucket", "visitors", "hits"}).
+			AddRow("bad", 20, 50))
 
 	// Get the statistics
 	err = m.Get()
 	assert.Error(t, err, "Error should be returned")
-	assert.Contains(t, err.Error(), "prepare error", "Error should contain the expected error message")
 
 	// Verify all expectations were met
 	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
 }
 
-func TestStatisticsModelPeriodDataError(t *testing.T) {
+func TestStatisticsIsValid(t *testing.T) {
+	tests := []struct {
+		name  string
+		model *StatisticsModel
+		valid bool
+	}{
+		{"hourly day", &StatisticsModel{Ib: 1, Range: "24h", Bucket: "hour"}, true},
+		{"daily week", &StatisticsModel{Ib: 1, Range: "7d", Bucket: "day"}, true},
+		{"hourly month", &StatisticsModel{Ib: 1, Range: "30d", Bucket: "hour"}, true},
+		{"missing ib", &StatisticsModel{Ib: 0, Range: "24h", Bucket: "hour"}, false},
+		{"daily year", &StatisticsModel{Ib: 1, Range: "1y", Bucket: "day"}, true},
+		{"hourly year", &StatisticsModel{Ib: 1, Range: "1y", Bucket: "hour"}, false},
+		{"hourly quarter", &StatisticsModel{Ib: 1, Range: "90d", Bucket: "hour"}, false},
+		{"unknown range", &StatisticsModel{Ib: 1, Range: "2y", Bucket: "day"}, false},
+		{"unknown bucket", &StatisticsModel{Ib: 1, Range: "24h", Bucket: "minute"}, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.valid, tc.model.IsValid(), "IsValid result should match expected value")
+		})
+	}
+}
+
+func TestStatisticsBuckets(t *testing.T) {
+	now := time.Date(2024, 3, 10, 15, 42, 17, 0, time.UTC)
+
+	// hourly buckets end with the current hour
+	m := &StatisticsModel{Ib: 1, Range: "24h", Bucket: "hour"}
+	buckets := m.Buckets(now)
+	if assert.Equal(t, 24, len(buckets), "Should have 24 buckets") {
+		assert.Equal(t, time.Date(2024, 3, 9, 16, 0, 0, 0, time.UTC), buckets[0], "First bucket should start on the hour")
+		assert.Equal(t, time.Date(2024, 3, 10, 15, 0, 0, 0, time.UTC), buckets[23], "Last bucket should be the current hour")
+	}
+
+	// daily buckets end with the current day
+	m = &StatisticsModel{Ib: 1, Range: "7d", Bucket: "day"}
+	buckets = m.Buckets(now)
+	if assert.Equal(t, 7, len(buckets), "Should have 7 buckets") {
+		assert.Equal(t, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), buckets[0], "First bucket should start at midnight")
+		assert.Equal(t, time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), buckets[6], "Last bucket should be today")
+	}
+
+	// rollup buckets end with yesterday
+	m = &StatisticsModel{Ib: 1, Range: "90d", Bucket: "day"}
+	buckets = m.Buckets(now)
+	if assert.Equal(t, 90, len(buckets), "Should have 90 buckets") {
+		assert.Equal(t, time.Date(2023, 12, 11, 0, 0, 0, 0, time.UTC), buckets[0], "First bucket should start at midnight")
+		assert.Equal(t, time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC), buckets[89], "Last bucket should be yesterday")
+	}
+}
+
+func TestStatisticsModelGetRollup(t *testing.T) {
 	var err error
 
 	mock, err := db.NewTestDb()
@@ -185,34 +286,106 @@ func TestStatisticsModelPeriodDataError(t *testing.T) {
 
 	// Initialize model with parameters
 	m := &StatisticsModel{
-		Ib: 1,
+		Ib:     1,
+		Range:  "1y",
+		Bucket: "day",
 	}
 
 	// Mock the board stats query
-	mock.ExpectQuery(`SELECT \(SELECT COUNT\(thread_id\).*FROM imageboards WHERE ib_id = \?`).
+	mock.ExpectQuery(testBoardStatsQuery).
 		WithArgs(m.Ib).
 		WillReturnRows(sqlmock.NewRows([]string{"thread_count", "post_count", "image_count"}).
 			AddRow(10, 100, 50))
 
-	// Mock the visitor stats query
-	mock.ExpectQuery(`SELECT COUNT\(DISTINCT request_ip\) as visitors, COUNT\(request_itemkey\) as hits.*WHERE request_time BETWEEN \(CURDATE\(\) - INTERVAL 1 DAY\) AND now\(\) AND ib_id = \?`).
-		WithArgs(m.Ib).
-		WillReturnRows(sqlmock.NewRows([]string{"visitors", "hits"}).
-			AddRow(200, 500))
-
-	// Mock the chart data prepare
-	mock.ExpectPrepare(`SELECT \(now\(\) - interval \? hour\) as time.*AND ib_id = \?`)
-
-	// Mock first period data with error
-	mock.ExpectQuery(`SELECT \(now\(\) - interval \? hour\) as time.*AND ib_id = \?`).
-		WithArgs(24, 24, 20, m.Ib).
-		WillReturnError(errors.New("query error"))
+	// Mock the daily rollups, the raw analytics are not read
+	mock.ExpectQuery(testRollupStatsQuery).
+		WithArgs(sqlmock.AnyArg(), m.Ib, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"bucket", "visitors", "hits"}).
+			AddRow(0, 20, 50).
+			AddRow(364, 30, 60).
+			AddRow(365, 40, 70))
 
 	// Get the statistics
 	err = m.Get()
-	assert.Error(t, err, "Error should be returned")
-	assert.Contains(t, err.Error(), "query error", "Error should contain the expected error message")
+	assert.NoError(t, err, "No error should be returned")
+
+	assert.Equal(t, 365, len(m.Result.Labels), "Should have 365 data points")
+	assert.Equal(t, uint(20), m.Result.Series[0].Data[0], "First bucket should match")
+	assert.Equal(t, uint(60), m.Result.Series[1].Data[364], "Last bucket should match")
+	assert.Equal(t, uint(50), m.Result.Visitors, "Visitors should be the sum of the daily visitors in range")
+	assert.Equal(t, uint(110), m.Result.Hits, "Hits should be the sum of the daily hits in range")
 
 	// Verify all expectations were met
 	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
 }
+
+func TestStatisticsModelGetInvalid(t *testing.T) {
+	m := &StatisticsModel{
+		Ib:     1,
+		Range:  "1y",
+		Bucket: "hour",
+	}
+
+	err := m.Get()
+	assert.Equal(t, e.ErrInvalidParam, err, "Error should be ErrInvalidParam")
+}
+
+// BenchmarkStatisticsModelGet shows the query count stays the same no matter
+// how many buckets are in the chart, it used to be three plus one per bucket.
+// Each op only expects two queries, so an extra query fails Get and a missing
+// one fails the expectations
+func BenchmarkStatisticsModelGet(b *testing.B) {
+	ranges := []struct {
+		Range  string
+		Bucket string
+	}{
+		{"24h", "hour"},
+		{"7d", "hour"},
+		{"30d", "hour"},
+		{"30d", "day"},
+	}
+
+	for _, r := range ranges {
+		b.Run(r.Range+"-"+r.Bucket, func(b *testing.B) {
+			mock, err := db.NewTestDb()
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer db.CloseDb()
+
+			m := &StatisticsModel{
+				Ib:     1,
+				Range:  r.Range,
+				Bucket: r.Bucket,
+			}
+
+			buckets := len(m.Buckets(time.Now()))
+
+			for i := 0; i < b.N; i++ {
+				mock.ExpectQuery(testBoardStatsQuery).
+					WillReturnRows(sqlmock.NewRows([]string{"thread_count", "post_count", "image_count"}).
+						AddRow(10, 100, 50))
+
+				rows := sqlmock.NewRows([]string{"bucket", "visitors", "hits"})
+				for bucket := 0; bucket < buckets; bucket++ {
+					rows.AddRow(bucket, 20, 50)
+				}
+				rows.AddRow(nil, 20, 50)
+
+				mock.ExpectQuery(testVisitorStatsQuery).
+					WillReturnRows(rows)
+
+				err = m.Get()
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				b.Fatal(err)
+			}
+
+			b.ReportMetric(float64(buckets), "buckets/op")
+		})
+	}
+}
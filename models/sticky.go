@@ -44,8 +44,15 @@ func (m *StickyModel) Status() (err error) {
 		return
 	}
 
+	return m.status(dbase)
+
+}
+
+// status is Status with the handle, so it can run in a batch
+func (m *StickyModel) status(h handle) (err error) {
+
 	// Check if favorite is already there
-	err = dbase.QueryRow("SELECT thread_title, thread_sticky FROM threads WHERE thread_id = ? AND ib_id = ? LIMIT 1", m.ID, m.Ib).Scan(&m.Name, &m.Sticky)
+	err = h.QueryRow("SELECT thread_title, thread_sticky FROM threads WHERE thread_id = ? AND ib_id = ? LIMIT 1", m.ID, m.Ib).Scan(&m.Name, &m.Sticky)
 	if err == sql.ErrNoRows {
 		return e.ErrNotFound
 	} else if err != nil {
@@ -59,18 +66,25 @@ func (m *StickyModel) Status() (err error) {
 // Toggle will change the thread status
 func (m *StickyModel) Toggle() (err error) {
 
-	// check model validity
-	if !m.IsValid() {
-		return errors.New("StickyModel is not valid")
-	}
-
 	// Get Database handle
 	dbase, err := db.GetDb()
 	if err != nil {
 		return
 	}
 
-	ps1, err := dbase.Prepare("UPDATE threads SET thread_sticky = ? WHERE thread_id = ? AND ib_id = ?")
+	return m.toggle(dbase)
+
+}
+
+// toggle is Toggle with the handle, so it can run in a batch
+func (m *StickyModel) toggle(h handle) (err error) {
+
+	// check model validity
+	if !m.IsValid() {
+		return errors.New("StickyModel is not valid")
+	}
+
+	ps1, err := h.Prepare("UPDATE threads SET thread_sticky = ? WHERE thread_id = ? AND ib_id = ?")
 	if err != nil {
 		return
 	}
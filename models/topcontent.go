@@ -0,0 +1,193 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+)
+
+// TopContentLimit is how many items of each kind are returned
+const TopContentLimit = 10
+
+// item keys are the cache keys of the requested pages, they start with the
+// kind, board and id like thread:1:2:1, image:1:3 and tag:1:4:1
+const (
+	topContentRawSource = `SELECT request_itemkey, COUNT(*) AS hits
+    FROM analytics
+    WHERE ib_id = ? AND request_time >= ? AND request_itemkey LIKE ?
+    GROUP BY request_itemkey`
+	topContentRollupSource = `SELECT request_itemkey, SUM(hits) AS hits
+    FROM analytics_item_daily
+    WHERE ib_id = ? AND rollup_date >= ? AND request_itemkey LIKE ?
+    GROUP BY request_itemkey`
+	topContentItemID = `SUBSTRING_INDEX(SUBSTRING_INDEX(items.request_itemkey, ':', 3), ':', -1)`
+)
+
+// TopContentModel holds request input
+type TopContentModel struct {
+	Ib     uint
+	Range  string
+	Result TopContentType
+}
+
+// TopContentType holds the most requested items of a board
+type TopContentType struct {
+	Threads []TopThread `json:"threads"`
+	Images  []TopImage  `json:"images"`
+	Tags    []TopTag    `json:"tags"`
+}
+
+// TopThread is a thread and its hits
+type TopThread struct {
+	ID    uint   `json:"id"`
+	Title string `json:"title"`
+	Hits  uint   `json:"hits"`
+}
+
+// TopImage is an image with the thread it was posted in and its hits
+type TopImage struct {
+	ID     uint   `json:"id"`
+	Thread uint   `json:"thread"`
+	Title  string `json:"title"`
+	Post   uint   `json:"post"`
+	Hits   uint   `json:"hits"`
+}
+
+// TopTag is a tag and its hits
+type TopTag struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	Hits uint   `json:"hits"`
+}
+
+// IsValid will check struct validity
+func (m *TopContentModel) IsValid() bool {
+
+	if m.Ib == 0 {
+		return false
+	}
+
+	_, ok := StatisticsRanges[m.Range]
+	if !ok {
+		return false
+	}
+
+	return true
+
+}
+
+// Get will gather the most requested threads, images and tags in the range
+func (m *TopContentModel) Get() (err error) {
+
+	// check model validity
+	if !m.IsValid() {
+		return e.ErrInvalidParam
+	}
+
+	// Initialize response header
+	response := TopContentType{
+		Threads: []TopThread{},
+		Images:  []TopImage{},
+		Tags:    []TopTag{},
+	}
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	start := time.Now().UTC().Add(-StatisticsRanges[m.Range])
+
+	// ranges past the raw retention are read from the rollups
+	source := topContentRawSource
+	if StatisticsRanges[m.Range] > StatisticsRawRetention {
+		source = topContentRollupSource
+		start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	threads, err := dbase.Query(fmt.Sprintf(`SELECT thread_id, thread_title, SUM(items.hits) AS hits
+    FROM (%s) AS items
+    INNER JOIN threads ON threads.thread_id = %s
+    WHERE threads.ib_id = ? AND thread_deleted != 1
+    GROUP BY thread_id, thread_title
+    ORDER BY hits DESC LIMIT ?`, source, topContentItemID), m.Ib, start, "thread:%", m.Ib, TopContentLimit)
+	if err != nil {
+		return
+	}
+	defer threads.Close()
+
+	for threads.Next() {
+		thread := TopThread{}
+
+		err = threads.Scan(&thread.ID, &thread.Title, &thread.Hits)
+		if err != nil {
+			return
+		}
+
+		response.Threads = append(response.Threads, thread)
+	}
+	if threads.Err() != nil {
+		return threads.Err()
+	}
+
+	images, err := dbase.Query(fmt.Sprintf(`SELECT image_id, threads.thread_id, thread_title, post_num, SUM(items.hits) AS hits
+    FROM (%s) AS items
+    INNER JOIN images ON images.image_id = %s
+    INNER JOIN posts ON images.post_id = posts.post_id
+    INNER JOIN threads ON posts.thread_id = threads.thread_id
+    WHERE threads.ib_id = ? AND thread_deleted != 1 AND post_deleted != 1
+    GROUP BY image_id, threads.thread_id, thread_title, post_num
+    ORDER BY hits DESC LIMIT ?`, source, topContentItemID), m.Ib, start, "image:%", m.Ib, TopContentLimit)
+	if err != nil {
+		return
+	}
+	defer images.Close()
+
+	for images.Next() {
+		image := TopImage{}
+
+		err = images.Scan(&image.ID, &image.Thread, &image.Title, &image.Post, &image.Hits)
+		if err != nil {
+			return
+		}
+
+		response.Images = append(response.Images, image)
+	}
+	if images.Err() != nil {
+		return images.Err()
+	}
+
+	tags, err := dbase.Query(fmt.Sprintf(`SELECT tag_id, tag_name, SUM(items.hits) AS hits
+    FROM (%s) AS items
+    INNER JOIN tags ON tags.tag_id = %s
+    WHERE tags.ib_id = ?
+    GROUP BY tag_id, tag_name
+    ORDER BY hits DESC LIMIT ?`, source, topContentItemID), m.Ib, start, "tag:%", m.Ib, TopContentLimit)
+	if err != nil {
+		return
+	}
+	defer tags.Close()
+
+	for tags.Next() {
+		tag := TopTag{}
+
+		err = tags.Scan(&tag.ID, &tag.Name, &tag.Hits)
+		if err != nil {
+			return
+		}
+
+		response.Tags = append(response.Tags, tag)
+	}
+	if tags.Err() != nil {
+		return tags.Err()
+	}
+
+	// This is the data we will serialize
+	m.Result = response
+
+	return
+
+}
@@ -0,0 +1,140 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+)
+
+func TestTopContentIsValid(t *testing.T) {
+	tests := []struct {
+		name  string
+		model *TopContentModel
+		valid bool
+	}{
+		{"week", &TopContentModel{Ib: 1, Range: "7d"}, true},
+		{"year", &TopContentModel{Ib: 1, Range: "1y"}, true},
+		{"missing ib", &TopContentModel{Ib: 0, Range: "7d"}, false},
+		{"unknown range", &TopContentModel{Ib: 1, Range: "2y"}, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.valid, tc.model.IsValid(), "IsValid result should match expected value")
+		})
+	}
+}
+
+func TestTopContentModelGet(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	m := &TopContentModel{
+		Ib:    1,
+		Range: "7d",
+	}
+
+	mock.ExpectQuery(`SELECT thread_id, thread_title, SUM\(items.hits\) AS hits\s+FROM \(SELECT request_itemkey, COUNT\(\*\) AS hits\s+FROM analytics\s`).
+		WithArgs(1, sqlmock.AnyArg(), "thread:%", 1, TopContentLimit).
+		WillReturnRows(sqlmock.NewRows([]string{"thread_id", "thread_title", "hits"}).
+			AddRow(2, "Popular", 300).
+			AddRow(5, "Less popular", 20))
+
+	mock.ExpectQuery(`SELECT image_id, threads.thread_id, thread_title, post_num, SUM\(items.hits\) AS hits`).
+		WithArgs(1, sqlmock.AnyArg(), "image:%", 1, TopContentLimit).
+		WillReturnRows(sqlmock.NewRows([]string{"image_id", "thread_id", "thread_title", "post_num", "hits"}).
+			AddRow(7, 2, "Popular", 3, 40))
+
+	mock.ExpectQuery(`SELECT tag_id, tag_name, SUM\(items.hits\) AS hits`).
+		WithArgs(1, sqlmock.AnyArg(), "tag:%", 1, TopContentLimit).
+		WillReturnRows(sqlmock.NewRows([]string{"tag_id", "tag_name", "hits"}))
+
+	err = m.Get()
+	assert.NoError(t, err, "No error should be returned")
+
+	if assert.Equal(t, 2, len(m.Result.Threads), "Should have two threads") {
+		assert.Equal(t, TopThread{ID: 2, Title: "Popular", Hits: 300}, m.Result.Threads[0], "Thread should match")
+	}
+	if assert.Equal(t, 1, len(m.Result.Images), "Should have one image") {
+		assert.Equal(t, TopImage{ID: 7, Thread: 2, Title: "Popular", Post: 3, Hits: 40}, m.Result.Images[0], "Image should match")
+	}
+	assert.NotNil(t, m.Result.Tags, "Empty tags should not be null")
+	assert.Empty(t, m.Result.Tags, "Should have no tags")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestTopContentModelGetRollup(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	m := &TopContentModel{
+		Ib:    1,
+		Range: "1y",
+	}
+
+	// long ranges are read from the item rollups
+	mock.ExpectQuery(`SELECT thread_id, thread_title, SUM\(items.hits\) AS hits\s+FROM \(SELECT request_itemkey, SUM\(hits\) AS hits\s+FROM analytics_item_daily`).
+		WithArgs(1, sqlmock.AnyArg(), "thread:%", 1, TopContentLimit).
+		WillReturnRows(sqlmock.NewRows([]string{"thread_id", "thread_title", "hits"}))
+
+	mock.ExpectQuery(`FROM analytics_item_daily.*INNER JOIN images`).
+		WithArgs(1, sqlmock.AnyArg(), "image:%", 1, TopContentLimit).
+		WillReturnRows(sqlmock.NewRows([]string{"image_id", "thread_id", "thread_title", "post_num", "hits"}))
+
+	mock.ExpectQuery(`FROM analytics_item_daily.*INNER JOIN tags`).
+		WithArgs(1, sqlmock.AnyArg(), "tag:%", 1, TopContentLimit).
+		WillReturnRows(sqlmock.NewRows([]string{"tag_id", "tag_name", "hits"}).
+			AddRow(4, "cats", 90))
+
+	err = m.Get()
+	assert.NoError(t, err, "No error should be returned")
+
+	assert.Equal(t, []TopTag{{ID: 4, Name: "cats", Hits: 90}}, m.Result.Tags, "Tags should match")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestTopContentModelGetError(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	m := &TopContentModel{
+		Ib:    1,
+		Range: "7d",
+	}
+
+	mock.ExpectQuery(`SELECT thread_id, thread_title`).
+		WillReturnError(errors.New("database error"))
+
+	err = m.Get()
+	if assert.Error(t, err, "Error should be returned") {
+		assert.Contains(t, err.Error(), "database error", "Error should contain the expected error message")
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestTopContentModelGetInvalid(t *testing.T) {
+	m := &TopContentModel{
+		Ib:    1,
+		Range: "2y",
+	}
+
+	err := m.Get()
+	assert.Equal(t, e.ErrInvalidParam, err, "Error should be ErrInvalidParam")
+}
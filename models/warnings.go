@@ -0,0 +1,502 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"time"
+
+	"github.com/microcosm-cc/bluemonday"
+
+	"github.com/eirka/eirka-libs/config"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+	"github.com/eirka/eirka-libs/validate"
+
+	local "github.com/eirka/eirka-admin/config"
+	u "github.com/eirka/eirka-admin/utils"
+)
+
+// WarnModel holds request input, warnings are counted on the account of the
+// post or on its ip if it was made anonymously
+type WarnModel struct {
+	Ib         uint
+	Thread     uint
+	ID         uint
+	User       uint
+	Reason     string
+	Account    uint
+	Name       string
+	IP         string
+	Escalation local.Warnings
+	Warning    uint
+	Count      uint
+	// the ban the warning escalated to
+	UserBan *BanUserModel
+	IPBan   *BanIPModel
+}
+
+// IsValid will check struct validity
+func (m *WarnModel) IsValid() bool {
+
+	if m.Ib == 0 {
+		return false
+	}
+
+	if m.Thread == 0 {
+		return false
+	}
+
+	if m.ID == 0 {
+		return false
+	}
+
+	if m.User == 0 || m.User == 1 {
+		return false
+	}
+
+	if m.Reason == "" {
+		return false
+	}
+
+	if m.Account == 0 {
+		return false
+	}
+
+	if m.IP == "" {
+		return false
+	}
+
+	return true
+
+}
+
+// ValidateInput checks the data input for correctness, the reason is shown
+// to the user
+func (m *WarnModel) ValidateInput() (err error) {
+
+	// Initialize bluemonday
+	p := bluemonday.StrictPolicy()
+
+	// sanitize for html and xss
+	m.Reason = html.UnescapeString(p.Sanitize(m.Reason))
+
+	// Validate reason input
+	reason := validate.Validate{Input: m.Reason, Max: config.Settings.Limits.CommentMaxLength, Min: config.Settings.Limits.CommentMinLength}
+	if reason.IsEmpty() {
+		return e.ErrNoComment
+	} else if reason.MinLength() {
+		return e.ErrCommentShort
+	} else if reason.MaxLength() {
+		return e.ErrCommentLong
+	}
+
+	return
+
+}
+
+// Status will return the account and ip of the post
+func (m *WarnModel) Status() (err error) {
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	err = dbase.QueryRow(`SELECT posts.user_id, user_name, post_ip FROM threads
+    INNER JOIN posts ON threads.thread_id = posts.thread_id
+    INNER JOIN users ON posts.user_id = users.user_id
+    WHERE ib_id = ? AND threads.thread_id = ? AND post_num = ? LIMIT 1`, m.Ib, m.Thread, m.ID).Scan(&m.Account, &m.Name, &m.IP)
+	if err == sql.ErrNoRows {
+		return e.ErrNotFound
+	} else if err != nil {
+		return
+	}
+
+	return
+
+}
+
+// Post will add the warning and ban the user or ip for a while if it has
+// reached the escalation limit
+func (m *WarnModel) Post() (err error) {
+
+	// check model validity
+	if !m.IsValid() {
+		return errors.New("WarnModel is not valid")
+	}
+
+	// Get transaction handle
+	tx, err := db.GetTransaction()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`INSERT INTO warnings (ib_id,user_id,thread_id,post_num,warning_user_id,warning_ip,warning_reason,warning_time,warning_seen)
+    VALUES (?,?,?,?,?,?,?,NOW(),0)`, m.Ib, m.User, m.Thread, m.ID, m.Account, m.IP, m.Reason)
+	if err != nil {
+		return
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return
+	}
+
+	m.Warning = uint(id)
+
+	if m.Escalation.Limit != 0 && m.Escalation.BanDays != 0 {
+		err = m.escalate(tx)
+		if err != nil {
+			return
+		}
+	}
+
+	// Commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return
+	}
+
+	return
+
+}
+
+// poster returns the condition that selects the warnings of the poster
+func (m *WarnModel) poster() (string, interface{}) {
+
+	// anonymous posts share the account
+	if m.Account == 1 {
+		return "warning_ip = ?", m.IP
+	}
+
+	return "warning_user_id = ?", m.Account
+
+}
+
+// escalate counts the recent warnings and bans with the regular ban models
+func (m *WarnModel) escalate(h handle) (err error) {
+
+	target, value := m.poster()
+
+	query := `SELECT COUNT(warning_id) FROM warnings WHERE ib_id = ? AND ` + target
+	args := []interface{}{m.Ib, value}
+
+	// zero days counts every warning
+	if m.Escalation.Days != 0 {
+		query += " AND warning_time > ?"
+		args = append(args, time.Now().AddDate(0, 0, -int(m.Escalation.Days)))
+	}
+
+	err = h.QueryRow(query, args...).Scan(&m.Count)
+	if err != nil {
+		return
+	}
+
+	if m.Count < m.Escalation.Limit {
+		return
+	}
+
+	expires := time.Now().AddDate(0, 0, int(m.Escalation.BanDays))
+
+	reason := fmt.Sprintf("Warned %d times", m.Count)
+	if m.Escalation.Days != 0 {
+		reason += fmt.Sprintf(" in %d days", m.Escalation.Days)
+	}
+
+	// anonymous posts only have the ip
+	if m.Account == 1 {
+		m.IPBan = &BanIPModel{
+			Ib:      m.Ib,
+			Thread:  m.Thread,
+			ID:      m.ID,
+			User:    m.User,
+			Reason:  reason,
+			IP:      m.IP,
+			Expires: &expires,
+		}
+
+		return m.IPBan.post(h)
+	}
+
+	m.UserBan = &BanUserModel{
+		Ib:      m.Ib,
+		Thread:  m.Thread,
+		ID:      m.ID,
+		User:    m.User,
+		Reason:  reason,
+		Expires: &expires,
+		Account: m.Account,
+		Name:    m.Name,
+	}
+
+	return m.UserBan.post(h)
+
+}
+
+// Warning is a warning given for a post
+type Warning struct {
+	ID      uint       `json:"warning_id"`
+	Thread  uint       `json:"thread_id"`
+	Num     uint       `json:"post_num"`
+	Account uint       `json:"warning_user_id"`
+	Name    string     `json:"warning_user_name"`
+	IP      string     `json:"warning_ip"`
+	Reason  string     `json:"warning_reason"`
+	Time    *time.Time `json:"warning_time"`
+	Seen    bool       `json:"warning_seen"`
+	UID     uint       `json:"user_id"`
+	User    string     `json:"user_name"`
+}
+
+// the columns and joins of a warning
+const warningSelect = `SELECT warning_id, thread_id, post_num, warning_user_id, warned.user_name, warning_ip,
+    warning_reason, warning_time, warning_seen, warnings.user_id, moderators.user_name
+    FROM warnings
+    INNER JOIN users AS warned ON warnings.warning_user_id = warned.user_id
+    INNER JOIN users AS moderators ON warnings.user_id = moderators.user_id`
+
+// scanWarnings reads the rows of a warning query
+func scanWarnings(rows *sql.Rows) (warnings []Warning, err error) {
+	defer rows.Close()
+
+	warnings = []Warning{}
+
+	for rows.Next() {
+		warning := Warning{}
+
+		err = rows.Scan(&warning.ID, &warning.Thread, &warning.Num, &warning.Account, &warning.Name, &warning.IP,
+			&warning.Reason, &warning.Time, &warning.Seen, &warning.UID, &warning.User)
+		if err != nil {
+			return
+		}
+
+		warnings = append(warnings, warning)
+	}
+
+	return warnings, rows.Err()
+
+}
+
+// WarningsModel holds request input
+type WarningsModel struct {
+	Ib     uint
+	Page   uint
+	Result WarningsType
+}
+
+// WarningsType is container for JSON response
+type WarningsType struct {
+	Body u.PagedResponse `json:"warnings"`
+}
+
+// Get will return the warnings of the board, the newest first
+func (m *WarningsModel) Get() (err error) {
+
+	if m.Ib == 0 || m.Page == 0 {
+		return e.ErrNotFound
+	}
+
+	// Initialize response header
+	response := WarningsType{}
+
+	// Initialize struct for pagination
+	paged := u.PagedResponse{}
+	// Set current page to parameter
+	paged.CurrentPage = m.Page
+	// Set threads per index page to config setting
+	paged.PerPage = config.Settings.Limits.PostsPerPage
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	// Get total warning count and put it in pagination struct
+	err = dbase.QueryRow("SELECT COUNT(warning_id) FROM warnings WHERE ib_id = ?", m.Ib).Scan(&paged.Total)
+	if err != nil {
+		return
+	}
+
+	// Calculate Limit and total Pages
+	paged.Get()
+
+	// Return 404 if page requested is larger than actual pages
+	if m.Page > paged.Pages {
+		return e.ErrNotFound
+	}
+
+	rows, err := dbase.Query(warningSelect+`
+    WHERE ib_id = ?
+    ORDER BY warning_id DESC LIMIT ?,?`, m.Ib, paged.Limit, paged.PerPage)
+	if err != nil {
+		return
+	}
+
+	warnings, err := scanWarnings(rows)
+	if err != nil {
+		return
+	}
+
+	// Add warnings slice to items interface
+	paged.Items = warnings
+
+	// Add pagedresponse to the response struct
+	response.Body = paged
+
+	// This is the data we will serialize
+	m.Result = response
+
+	return
+
+}
+
+// PosterWarningsModel holds request input
+type PosterWarningsModel struct {
+	Ib     uint
+	Thread uint
+	ID     uint
+	Result PosterWarningsType
+}
+
+// PosterWarningsType is container for JSON response
+type PosterWarningsType struct {
+	Body []Warning `json:"warnings"`
+}
+
+// Get will return the warnings on the board of the account of the post, or
+// of its ip if it was made anonymously
+func (m *PosterWarningsModel) Get() (err error) {
+
+	if m.Ib == 0 || m.Thread == 0 || m.ID == 0 {
+		return e.ErrNotFound
+	}
+
+	// get the poster
+	poster := &WarnModel{
+		Ib:     m.Ib,
+		Thread: m.Thread,
+		ID:     m.ID,
+	}
+
+	err = poster.Status()
+	if err != nil {
+		return
+	}
+
+	target, value := poster.poster()
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	rows, err := dbase.Query(warningSelect+`
+    WHERE ib_id = ? AND `+target+`
+    ORDER BY warning_id DESC`, m.Ib, value)
+	if err != nil {
+		return
+	}
+
+	warnings, err := scanWarnings(rows)
+	if err != nil {
+		return
+	}
+
+	// This is the data we will serialize
+	m.Result = PosterWarningsType{Body: warnings}
+
+	return
+
+}
+
+// UnseenWarningsModel holds request input, anonymous users get the warnings
+// of their ip
+type UnseenWarningsModel struct {
+	Ib      uint
+	Account uint
+	IP      string
+	Result  UnseenWarningsType
+}
+
+// UnseenWarningsType is container for JSON response
+type UnseenWarningsType struct {
+	Body []UserWarning `json:"warnings"`
+}
+
+// UserWarning is a warning as it is shown to the warned user
+type UserWarning struct {
+	ID     uint       `json:"warning_id"`
+	Thread uint       `json:"thread_id"`
+	Num    uint       `json:"post_num"`
+	Reason string     `json:"warning_reason"`
+	Time   *time.Time `json:"warning_time"`
+}
+
+// Get will return the warnings the user has not seen yet on the board and
+// mark them seen
+func (m *UnseenWarningsModel) Get() (err error) {
+
+	if m.Ib == 0 || m.Account == 0 || m.IP == "" {
+		return e.ErrNotFound
+	}
+
+	target, value := (&WarnModel{Account: m.Account, IP: m.IP}).poster()
+
+	// Get transaction handle
+	tx, err := db.GetTransaction()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT warning_id, thread_id, post_num, warning_reason, warning_time FROM warnings
+    WHERE ib_id = ? AND `+target+` AND warning_seen = 0
+    ORDER BY warning_id ASC FOR UPDATE`, m.Ib, value)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	warnings := []UserWarning{}
+
+	for rows.Next() {
+		warning := UserWarning{}
+
+		err = rows.Scan(&warning.ID, &warning.Thread, &warning.Num, &warning.Reason, &warning.Time)
+		if err != nil {
+			return
+		}
+
+		warnings = append(warnings, warning)
+	}
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	// only the warnings that are shown are marked, newer ones wait for the next request
+	if len(warnings) != 0 {
+		_, err = tx.Exec(`UPDATE warnings SET warning_seen = 1
+    WHERE ib_id = ? AND `+target+` AND warning_seen = 0 AND warning_id <= ?`, m.Ib, value, warnings[len(warnings)-1].ID)
+		if err != nil {
+			return
+		}
+	}
+
+	// Commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return
+	}
+
+	// This is the data we will serialize
+	m.Result = UnseenWarningsType{Body: warnings}
+
+	return
+
+}
@@ -0,0 +1,339 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/config"
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+
+	local "github.com/eirka/eirka-admin/config"
+)
+
+// the warning post lookup
+const testWarnQuery = `SELECT posts.user_id, user_name, post_ip FROM threads`
+
+// the columns of a warning list
+var testWarningColumns = []string{"warning_id", "thread_id", "post_num", "warning_user_id", "user_name", "warning_ip",
+	"warning_reason", "warning_time", "warning_seen", "user_id", "user_name"}
+
+func TestWarnModelValidateInput(t *testing.T) {
+	config.Settings.Limits.CommentMinLength = 3
+	config.Settings.Limits.CommentMaxLength = 100
+
+	m := &WarnModel{Reason: "<b>stop spamming</b>"}
+	assert.NoError(t, m.ValidateInput(), "Reason should be valid")
+	assert.Equal(t, "stop spamming", m.Reason, "Reason should be sanitized")
+
+	m = &WarnModel{}
+	assert.Equal(t, e.ErrNoComment, m.ValidateInput(), "Empty reason should be rejected")
+
+	m = &WarnModel{Reason: "no"}
+	assert.Equal(t, e.ErrCommentShort, m.ValidateInput(), "Short reason should be rejected")
+}
+
+func TestWarnModelStatusNotFound(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(testWarnQuery).
+		WithArgs(1, 2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "user_name", "post_ip"}))
+
+	m := &WarnModel{Ib: 1, Thread: 2, ID: 3}
+
+	err = m.Status()
+	assert.Equal(t, e.ErrNotFound, err, "Missing post should not be found")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestWarnModelPost(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO warnings`).
+		WithArgs(1, 2, 2, 3, 5, "10.0.0.1", "stop spamming").
+		WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectCommit()
+
+	m := &WarnModel{Ib: 1, Thread: 2, ID: 3, User: 2, Reason: "stop spamming", Account: 5, IP: "10.0.0.1"}
+
+	err = m.Post()
+	assert.NoError(t, err, "No error should be returned")
+	assert.Equal(t, uint(7), m.Warning, "Warning id should be set")
+	assert.Nil(t, m.UserBan, "Escalation is disabled")
+	assert.Nil(t, m.IPBan, "Escalation is disabled")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestWarnModelPostBelowLimit(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO warnings`).
+		WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectQuery(`SELECT COUNT\(warning_id\) FROM warnings WHERE ib_id = \? AND warning_user_id = \?$`).
+		WithArgs(1, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectCommit()
+
+	m := &WarnModel{Ib: 1, Thread: 2, ID: 3, User: 2, Reason: "stop spamming", Account: 5, IP: "10.0.0.1",
+		Escalation: local.Warnings{Limit: 3, BanDays: 7}}
+
+	err = m.Post()
+	assert.NoError(t, err, "No error should be returned")
+	assert.Equal(t, uint(2), m.Count, "Count should match")
+	assert.Nil(t, m.UserBan, "No ban below the limit")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestWarnModelPostEscalateUser(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO warnings`).
+		WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectQuery(`SELECT COUNT\(warning_id\) FROM warnings WHERE ib_id = \? AND warning_user_id = \? AND warning_time > \?`).
+		WithArgs(1, 5, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectExec(`INSERT INTO banned_users`).
+		WithArgs(2, 1, 5, false, "Warned 3 times in 30 days", sqlmock.AnyArg(), 0).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	m := &WarnModel{Ib: 1, Thread: 2, ID: 3, User: 2, Reason: "stop spamming", Account: 5, Name: "troll", IP: "10.0.0.1",
+		Escalation: local.Warnings{Limit: 3, Days: 30, BanDays: 7}}
+
+	err = m.Post()
+	assert.NoError(t, err, "No error should be returned")
+	assert.Nil(t, m.IPBan, "Accounts are banned by account")
+	if assert.NotNil(t, m.UserBan, "Warning should escalate to a ban") {
+		assert.Equal(t, "troll", m.UserBan.Name, "Name should match")
+		assert.WithinDuration(t, time.Now().AddDate(0, 0, 7), *m.UserBan.Expires, time.Minute, "Ban should last BanDays")
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestWarnModelPostEscalateKeepsLongerBan(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO warnings`).
+		WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectQuery(`SELECT COUNT\(warning_id\) FROM warnings`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	// an account that is already banned for good must stay banned for good,
+	// the reason only changes when the escalated ban lasts longer
+	mock.ExpectExec(`INSERT INTO banned_users .* ON DUPLICATE KEY UPDATE .*ban_reason = IF\(VALUES\(ban_expires\) IS NULL OR \(ban_expires IS NOT NULL AND VALUES\(ban_expires\) >= ban_expires\), VALUES\(ban_reason\), ban_reason\)`+
+		`.*ban_expires = IF\(ban_expires IS NULL OR VALUES\(ban_expires\) IS NULL, NULL, GREATEST\(ban_expires, VALUES\(ban_expires\)\)\)`).
+		WithArgs(2, 1, 5, false, "Warned 3 times in 30 days", sqlmock.AnyArg(), 0).
+		WillReturnResult(sqlmock.NewResult(1, 2))
+	mock.ExpectCommit()
+
+	m := &WarnModel{Ib: 1, Thread: 2, ID: 3, User: 2, Reason: "stop spamming", Account: 5, Name: "troll", IP: "10.0.0.1",
+		Escalation: local.Warnings{Limit: 3, Days: 30, BanDays: 7}}
+
+	err = m.Post()
+	assert.NoError(t, err, "No error should be returned")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestWarnModelPostEscalateIP(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO warnings`).
+		WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectQuery(`SELECT COUNT\(warning_id\) FROM warnings WHERE ib_id = \? AND warning_ip = \?`).
+		WithArgs(1, "10.0.0.1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+	mock.ExpectExec(`INSERT INTO banned_ips`).
+		WithArgs(2, 1, "10.0.0.1", "Warned 4 times", sqlmock.AnyArg(), 0).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	m := &WarnModel{Ib: 1, Thread: 2, ID: 3, User: 2, Reason: "stop spamming", Account: 1, IP: "10.0.0.1",
+		Escalation: local.Warnings{Limit: 3, BanDays: 1}}
+
+	err = m.Post()
+	assert.NoError(t, err, "No error should be returned")
+	assert.Nil(t, m.UserBan, "Anonymous posts have no account")
+	assert.NotNil(t, m.IPBan, "Warning should escalate to an ip ban")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestWarnModelPostInvalid(t *testing.T) {
+	m := &WarnModel{Ib: 1, Thread: 2, ID: 3, User: 2, Reason: "stop spamming"}
+
+	assert.Error(t, m.Post(), "Missing poster should be rejected")
+}
+
+func TestWarningsModelGet(t *testing.T) {
+	var err error
+
+	config.Settings.Limits.PostsPerPage = 10
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT COUNT\(warning_id\) FROM warnings WHERE ib_id = \?`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	mock.ExpectQuery(`SELECT warning_id, thread_id, post_num`).
+		WithArgs(1, 0, 10).
+		WillReturnRows(sqlmock.NewRows(testWarningColumns).
+			AddRow(7, 2, 3, 5, "troll", "10.0.0.1", "stop spamming", time.Now(), false, 2, "mod"))
+
+	m := &WarningsModel{Ib: 1, Page: 1}
+
+	err = m.Get()
+	assert.NoError(t, err, "No error should be returned")
+
+	warnings := m.Result.Body.Items.([]Warning)
+	if assert.Len(t, warnings, 1, "Should have one warning") {
+		assert.Equal(t, uint(7), warnings[0].ID, "Warning id should match")
+		assert.Equal(t, "troll", warnings[0].Name, "Warned user should match")
+		assert.Equal(t, "mod", warnings[0].User, "Moderator should match")
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestWarningsModelGetNotFound(t *testing.T) {
+	var err error
+
+	config.Settings.Limits.PostsPerPage = 10
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(`SELECT COUNT\(warning_id\) FROM warnings WHERE ib_id = \?`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	m := &WarningsModel{Ib: 1, Page: 2}
+
+	err = m.Get()
+	assert.Equal(t, e.ErrNotFound, err, "Page past the end should not be found")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestPosterWarningsModelGetAnonymous(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectQuery(testWarnQuery).
+		WithArgs(1, 2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "user_name", "post_ip"}).AddRow(1, "Anonymous", "10.0.0.1"))
+
+	mock.ExpectQuery(`SELECT warning_id, thread_id, post_num.* WHERE ib_id = \? AND warning_ip = \?`).
+		WithArgs(1, "10.0.0.1").
+		WillReturnRows(sqlmock.NewRows(testWarningColumns).
+			AddRow(7, 2, 3, 1, "Anonymous", "10.0.0.1", "stop spamming", time.Now(), true, 2, "mod"))
+
+	m := &PosterWarningsModel{Ib: 1, Thread: 2, ID: 3}
+
+	err = m.Get()
+	assert.NoError(t, err, "No error should be returned")
+	assert.Len(t, m.Result.Body, 1, "Should have one warning")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestUnseenWarningsModelGet(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT warning_id, thread_id, post_num, warning_reason, warning_time FROM warnings\s+WHERE ib_id = \? AND warning_user_id = \? AND warning_seen = 0`).
+		WithArgs(1, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"warning_id", "thread_id", "post_num", "warning_reason", "warning_time"}).
+			AddRow(7, 2, 3, "stop spamming", time.Now()).
+			AddRow(9, 2, 4, "stop it", time.Now()))
+	mock.ExpectExec(`UPDATE warnings SET warning_seen = 1\s+WHERE ib_id = \? AND warning_user_id = \? AND warning_seen = 0 AND warning_id <= \?`).
+		WithArgs(1, 5, 9).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	m := &UnseenWarningsModel{Ib: 1, Account: 5, IP: "10.0.0.1"}
+
+	err = m.Get()
+	assert.NoError(t, err, "No error should be returned")
+	if assert.Len(t, m.Result.Body, 2, "Should have both warnings") {
+		assert.Equal(t, "stop spamming", m.Result.Body[0].Reason, "Reason should match")
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestUnseenWarningsModelGetAnonymous(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	// nothing to mark when every warning was seen
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT warning_id, thread_id, post_num, warning_reason, warning_time FROM warnings\s+WHERE ib_id = \? AND warning_ip = \? AND warning_seen = 0`).
+		WithArgs(1, "10.0.0.1").
+		WillReturnRows(sqlmock.NewRows([]string{"warning_id", "thread_id", "post_num", "warning_reason", "warning_time"}))
+	mock.ExpectCommit()
+
+	m := &UnseenWarningsModel{Ib: 1, Account: 1, IP: "10.0.0.1"}
+
+	err = m.Get()
+	assert.NoError(t, err, "No error should be returned")
+	assert.Empty(t, m.Result.Body, "Should have no warnings")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "All expectations should be met")
+}
+
+func TestUnseenWarningsModelGetInvalid(t *testing.T) {
+	m := &UnseenWarningsModel{Ib: 0, Account: 5, IP: "10.0.0.1"}
+
+	assert.Equal(t, e.ErrNotFound, m.Get(), "Error should be ErrNotFound")
+}
@@ -0,0 +1,462 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	redigo "github.com/gomodule/redigo/redis"
+
+	"github.com/eirka/eirka-libs/audit"
+	"github.com/eirka/eirka-libs/db"
+	"github.com/eirka/eirka-libs/redis"
+
+	local "github.com/eirka/eirka-admin/config"
+)
+
+// redis keys of the audit outbox
+const (
+	// AuditOutboxKey is the redis list that holds undelivered audit entries
+	AuditOutboxKey = "audit:outbox"
+	// AuditOutboxDeadKey is the redis list of entries the database refused
+	AuditOutboxDeadKey = "audit:outbox:dead"
+	// AuditOutboxLockKey is held by the instance that is retrying the outbox
+	AuditOutboxLockKey = "audit:outbox:lock"
+)
+
+// audit actions for the admin features that the shared audit package does not have
+const (
+	// AuditResolveReport is for report resolving events
+	AuditResolveReport = "Report Resolved"
+	// AuditDismissReport is for report dismissal events
+	AuditDismissReport = "Report Dismissed"
+	// AuditAddNote is for moderator note creation events
+	AuditAddNote = "Note Added"
+	// AuditUpdateNote is for moderator note editing events
+	AuditUpdateNote = "Note Updated"
+	// AuditDeleteNote is for moderator note deletion events
+	AuditDeleteNote = "Note Deleted"
+	// AuditBulkDelete is for bulk post deletion events
+	AuditBulkDelete = "Bulk Deleted Posts"
+	// AuditAddFilter is for word filter creation events
+	AuditAddFilter = "Filter Added"
+	// AuditUpdateFilter is for word filter editing events
+	AuditUpdateFilter = "Filter Updated"
+	// AuditDeleteFilter is for word filter deletion events
+	AuditDeleteFilter = "Filter Deleted"
+	// AuditBanUser is for account ban events
+	AuditBanUser = "Account Banned"
+	// AuditUnbanUser is for account unban events
+	AuditUnbanUser = "Account Unbanned"
+	// AuditWarn is for warning events
+	AuditWarn = "User Warned"
+	// AuditAddRule is for board rule creation events
+	AuditAddRule = "Rule Added"
+	// AuditUpdateRule is for board rule editing events
+	AuditUpdateRule = "Rule Updated"
+	// AuditDeleteRule is for board rule deletion events
+	AuditDeleteRule = "Rule Deleted"
+)
+
+// AuditEntry is an audit log entry with the time the action happened and the
+// thread and post it was taken on, a post of zero is the whole thread
+type AuditEntry struct {
+	audit.Audit
+	Thread uint
+	Post   uint
+	Time   time.Time
+	Hash   string
+}
+
+// auditChainLink is the content of an entry that is covered by its hash
+type auditChainLink struct {
+	Previous string
+	User     uint
+	Ib       uint
+	Type     audit.LogType
+	IP       string
+	Time     string
+	Action   string
+	Info     string
+	Thread   uint
+	Post     uint
+}
+
+// ErrAuditChainKey is returned when there is no key to check the chain with
+var ErrAuditChainKey = errors.New("audit chain key is not set")
+
+// ChainHash returns the hash of the entry content chained to the previous hash,
+// it is keyed with the chain key from the config so an entry can not be
+// rewritten with only access to the database
+func (m *AuditEntry) ChainHash(previous string) string {
+
+	link, _ := json.Marshal(auditChainLink{
+		Previous: previous,
+		User:     m.User,
+		Ib:       m.Ib,
+		Type:     m.Type,
+		IP:       m.IP,
+		Time:     m.Time.UTC().Format(time.RFC3339),
+		Action:   m.Action,
+		Info:     m.Info,
+		Thread:   m.Thread,
+		Post:     m.Post,
+	})
+
+	mac := hmac.New(sha256.New, []byte(local.Settings.Audit.ChainKey))
+	mac.Write(link)
+
+	return hex.EncodeToString(mac.Sum(nil))
+
+}
+
+// SubmitAudit will write an audit entry to the database, if this fails the
+// entry is put in the outbox so the retry job can deliver it later
+func SubmitAudit(a audit.Audit) (err error) {
+	return SubmitPostAudit(a, 0, 0)
+}
+
+// SubmitPostAudit is SubmitAudit for an action on a thread or a post, the
+// entry is kept with them so their history can find it
+func SubmitPostAudit(a audit.Audit, thread, post uint) (err error) {
+
+	if !a.IsValid() {
+		return errors.New("Audit not valid")
+	}
+
+	auditActions.WithLabelValues(a.Action).Inc()
+
+	entry := AuditEntry{
+		Audit:  a,
+		Thread: thread,
+		Post:   post,
+		// the audit table only stores seconds
+		Time: time.Now().UTC().Truncate(time.Second),
+	}
+
+	err = entry.Submit()
+	if err != nil {
+		// keep the entry so the action is not left without a trace
+		if oerr := entry.Store(); oerr != nil {
+			return errors.Join(err, oerr)
+		}
+		return
+	}
+
+	// let the other moderators know, the entry is already saved
+	err = entry.Publish()
+	if err != nil {
+		return
+	}
+
+	return
+
+}
+
+// Submit will insert the entry into the audit log, every entry is chained to the
+// last entry of the same board and log type
+func (m *AuditEntry) Submit() (err error) {
+
+	// Get transaction handle
+	tx, err := db.GetTransaction()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	// lock the board so only one entry is chained at a time
+	var ib uint
+	err = tx.QueryRow("SELECT ib_id FROM imageboards WHERE ib_id = ? FOR UPDATE", m.Ib).Scan(&ib)
+	if err != nil {
+		return
+	}
+
+	// get the hash of the last chained entry
+	var previous string
+	err = tx.QueryRow(`SELECT audit_hash FROM audit
+		WHERE ib_id = ? AND audit_type = ? AND audit_hash IS NOT NULL
+		ORDER BY audit_id DESC LIMIT 1`, m.Ib, m.Type).Scan(&previous)
+	if err == sql.ErrNoRows {
+		// the log is empty, the chain goes on from the last archived entry if there is one
+		_, previous, err = AuditAnchor(tx, m.Ib, m.Type)
+	}
+	if err != nil {
+		return
+	}
+
+	m.Hash = m.ChainHash(previous)
+
+	ps, err := tx.Exec(`INSERT INTO audit (user_id,ib_id,audit_type,audit_ip,audit_time,audit_action,audit_info,thread_id,post_num,audit_hash)
+    VALUES (?,?,?,?,?,?,?,?,?,?)`,
+		m.User, m.Ib, m.Type, m.IP, m.Time, m.Action, m.Info, m.Thread, m.Post, m.Hash)
+	if err != nil {
+		return
+	}
+
+	id, err := ps.LastInsertId()
+	if err != nil {
+		return
+	}
+
+	// Commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return
+	}
+
+	// the entry is saved, a head that is behind only checks less of the chain
+	m.storeHead(uint(id))
+
+	return
+
+}
+
+// auditHeadKey is the redis key of the newest entry of a board and log type
+func auditHeadKey(ib uint, logtype audit.LogType) string {
+	return fmt.Sprintf("audit:head:%d:%d", ib, logtype)
+}
+
+// auditHeadStore only moves the head forward, entries of the same board can
+// be committed in one order and stored in another
+var auditHeadStore = redigo.NewScript(1, `local id = redis.call("HGET", KEYS[1], "id")
+if not id or tonumber(id) < tonumber(ARGV[1]) then
+	redis.call("HSET", KEYS[1], "id", ARGV[1], "hash", ARGV[2])
+end
+return 0`)
+
+// storeHead keeps the id and hash of the entry outside the database, so
+// removing the newest entries of the log can be found
+func (m *AuditEntry) storeHead(id uint) (err error) {
+
+	if redis.Cache.Pool == nil {
+		return redis.ErrCacheNotInitialized
+	}
+
+	conn := redis.Cache.Pool.Get()
+	defer conn.Close()
+
+	_, err = auditHeadStore.Do(conn, auditHeadKey(m.Ib, m.Type), id, m.Hash)
+
+	return
+
+}
+
+// AuditHead returns the id and hash of the newest entry of a board and log
+// type, ok is false if no head was stored
+func AuditHead(ib uint, logtype audit.LogType) (id uint, hash string, ok bool, err error) {
+
+	if redis.Cache.Pool == nil {
+		return 0, "", false, redis.ErrCacheNotInitialized
+	}
+
+	conn := redis.Cache.Pool.Get()
+	defer conn.Close()
+
+	head, err := redigo.StringMap(conn.Do("HGETALL", auditHeadKey(ib, logtype)))
+	if err != nil || len(head) == 0 {
+		return
+	}
+
+	headid, err := strconv.ParseUint(head["id"], 10, 64)
+	if err != nil {
+		return
+	}
+
+	return uint(headid), head["hash"], true, nil
+
+}
+
+// querier is a database or a transaction
+type querier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// AuditAnchor returns the id and hash of the last archived entry of a board and
+// log type, the chain of the remaining entries starts from it
+func AuditAnchor(h querier, ib uint, logtype audit.LogType) (id uint, hash string, err error) {
+
+	err = h.QueryRow("SELECT audit_id, audit_hash FROM audit_anchors WHERE ib_id = ? AND audit_type = ?",
+		ib, logtype).Scan(&id, &hash)
+	if err == sql.ErrNoRows {
+		// nothing was archived so the chain starts from nothing
+		return 0, "", nil
+	}
+
+	return
+
+}
+
+// Store will add the entry to the end of the outbox
+func (m *AuditEntry) Store() (err error) {
+
+	if redis.Cache.Pool == nil {
+		return redis.ErrCacheNotInitialized
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return
+	}
+
+	conn := redis.Cache.Pool.Get()
+	defer conn.Close()
+
+	_, err = conn.Do("RPUSH", AuditOutboxKey, data)
+
+	return
+
+}
+
+// AuditOutboxCount returns the amount of undelivered and refused audit entries of a board
+func AuditOutboxCount(ib uint) (undelivered, dead uint, err error) {
+
+	if redis.Cache.Pool == nil {
+		return 0, 0, redis.ErrCacheNotInitialized
+	}
+
+	conn := redis.Cache.Pool.Get()
+	defer conn.Close()
+
+	undelivered, err = auditListCount(conn, AuditOutboxKey, ib)
+	if err != nil {
+		return
+	}
+
+	dead, err = auditListCount(conn, AuditOutboxDeadKey, ib)
+	if err != nil {
+		return
+	}
+
+	return
+
+}
+
+// auditListCount counts the entries of a board in an outbox list, the lists
+// are shared by every board
+func auditListCount(conn redigo.Conn, key string, ib uint) (count uint, err error) {
+
+	entries, err := redigo.ByteSlices(conn.Do("LRANGE", key, 0, -1))
+	if err != nil {
+		return
+	}
+
+	for _, data := range entries {
+		entry := AuditEntry{}
+
+		if json.Unmarshal(data, &entry) == nil && entry.Ib == ib {
+			count++
+		}
+	}
+
+	return
+
+}
+
+// the mysql errors of rows the database will never accept
+var auditRefusedErrors = map[uint16]bool{
+	1048: true, // column cannot be null
+	1264: true, // out of range value
+	1292: true, // incorrect value
+	1366: true, // incorrect string value
+	1406: true, // data too long
+	1452: true, // foreign key fails
+}
+
+// auditRefused is true when retrying an entry can never work, its board is
+// gone or the database refuses the row
+func auditRefused(err error) bool {
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return true
+	}
+
+	var merr *mysql.MySQLError
+
+	return errors.As(err, &merr) && auditRefusedErrors[merr.Number]
+
+}
+
+// how long a retry run may hold the outbox, the job runs every minute
+const auditOutboxLockTime = 50 * time.Second
+
+// auditOutboxUnlock only releases the outbox lock if the run still holds it
+var auditOutboxUnlock = redigo.NewScript(1, `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) end return 0`)
+
+// RetryAuditOutbox will try to deliver the entries in the outbox in order, an
+// entry is only removed once it is delivered so a crash can not lose it.
+// Entries the database refuses are moved to the dead letter list so they do
+// not hold up the rest
+func RetryAuditOutbox() {
+
+	if redis.Cache.Pool == nil {
+		return
+	}
+
+	conn := redis.Cache.Pool.Get()
+	defer conn.Close()
+
+	token := make([]byte, 16)
+
+	_, err := rand.Read(token)
+	if err != nil {
+		return
+	}
+
+	// only one instance retries at a time so no entry is delivered twice
+	_, err = redigo.String(conn.Do("SET", AuditOutboxLockKey, token, "NX", "PX", auditOutboxLockTime.Milliseconds()))
+	if err != nil {
+		return
+	}
+	defer auditOutboxUnlock.Do(conn, AuditOutboxLockKey, token)
+
+	// stop before the lock runs out
+	deadline := time.Now().Add(auditOutboxLockTime)
+
+	for time.Now().Before(deadline) {
+		data, err := redigo.Bytes(conn.Do("LINDEX", AuditOutboxKey, 0))
+		if err != nil {
+			// the outbox is empty or redis is unavailable
+			return
+		}
+
+		entry := AuditEntry{}
+
+		dead := json.Unmarshal(data, &entry) != nil || !entry.IsValid()
+		if !dead {
+			err = entry.Submit()
+			if err != nil && !auditRefused(err) {
+				// leave it at the front and wait for the next run
+				auditOutbox.WithLabelValues("failed").Inc()
+				return
+			}
+			dead = err != nil
+		}
+
+		if dead {
+			// keep it for a person to look at
+			_, err = conn.Do("RPUSH", AuditOutboxDeadKey, data)
+			if err != nil {
+				return
+			}
+			auditOutbox.WithLabelValues("dead").Inc()
+		} else {
+			auditOutbox.WithLabelValues("delivered").Inc()
+			entry.Publish()
+		}
+
+		// the first copy of the entry is the one that was handled
+		_, err = conn.Do("LREM", AuditOutboxKey, 1, data)
+		if err != nil {
+			return
+		}
+	}
+
+}
@@ -0,0 +1,415 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/rafaeljusto/redigomock"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/audit"
+	"github.com/eirka/eirka-libs/db"
+	"github.com/eirka/eirka-libs/redis"
+)
+
+func testAuditEntry() AuditEntry {
+	return AuditEntry{
+		Audit: audit.Audit{
+			User:   2,
+			Ib:     1,
+			Type:   audit.ModLog,
+			IP:     "10.0.0.1",
+			Action: audit.AuditDeletePost,
+			Info:   "Thread/1",
+		},
+		Thread: 1,
+		Post:   1,
+		Time:   time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+func TestAuditEntrySubmit(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	redis.NewRedisMock()
+
+	entry := testAuditEntry()
+
+	mock.ExpectBegin()
+
+	mock.ExpectQuery(`SELECT ib_id FROM imageboards WHERE ib_id = \? FOR UPDATE`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id"}).AddRow(1))
+
+	mock.ExpectQuery(`SELECT audit_hash FROM audit`).
+		WithArgs(1, audit.ModLog).
+		WillReturnRows(sqlmock.NewRows([]string{"audit_hash"}).AddRow("previous"))
+
+	mock.ExpectExec("INSERT INTO audit").
+		WithArgs(2, 1, audit.ModLog, "10.0.0.1", entry.Time, audit.AuditDeletePost, "Thread/1", 1, 1, entry.ChainHash("previous")).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectCommit()
+
+	head := redis.Cache.Mock.Command("EVALSHA", auditHeadStore.Hash(), 1, "audit:head:1:2", uint(1), entry.ChainHash("previous")).Expect(int64(0))
+
+	err = entry.Submit()
+	assert.NoError(t, err, "An error was not expected")
+
+	assert.Equal(t, entry.ChainHash("previous"), entry.Hash, "Entry should be chained to the last entry")
+	assert.Equal(t, 1, redis.Cache.Mock.Stats(head), "Entry should be stored as the head")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "An error was not expected")
+}
+
+func TestAuditEntrySubmitAnchor(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	entry := testAuditEntry()
+
+	mock.ExpectBegin()
+
+	mock.ExpectQuery(`SELECT ib_id FROM imageboards WHERE ib_id = \? FOR UPDATE`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id"}).AddRow(1))
+
+	// every entry was archived
+	mock.ExpectQuery(`SELECT audit_hash FROM audit`).
+		WithArgs(1, audit.ModLog).
+		WillReturnRows(sqlmock.NewRows([]string{"audit_hash"}))
+
+	mock.ExpectQuery(`SELECT audit_id, audit_hash FROM audit_anchors WHERE ib_id = \? AND audit_type = \?`).
+		WithArgs(1, audit.ModLog).
+		WillReturnRows(sqlmock.NewRows([]string{"audit_id", "audit_hash"}).AddRow(10, "archived"))
+
+	mock.ExpectExec("INSERT INTO audit").
+		WithArgs(2, 1, audit.ModLog, "10.0.0.1", entry.Time, audit.AuditDeletePost, "Thread/1", 1, 1, entry.ChainHash("archived")).
+		WillReturnResult(sqlmock.NewResult(11, 1))
+
+	mock.ExpectCommit()
+
+	err = entry.Submit()
+	assert.NoError(t, err, "An error was not expected")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "An error was not expected")
+}
+
+func TestAuditEntrySubmitEmpty(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	entry := testAuditEntry()
+
+	mock.ExpectBegin()
+
+	mock.ExpectQuery(`SELECT ib_id FROM imageboards WHERE ib_id = \? FOR UPDATE`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id"}).AddRow(1))
+
+	mock.ExpectQuery(`SELECT audit_hash FROM audit`).
+		WithArgs(1, audit.ModLog).
+		WillReturnRows(sqlmock.NewRows([]string{"audit_hash"}))
+
+	mock.ExpectQuery(`SELECT audit_id, audit_hash FROM audit_anchors`).
+		WithArgs(1, audit.ModLog).
+		WillReturnRows(sqlmock.NewRows([]string{"audit_id", "audit_hash"}))
+
+	// a new chain starts from nothing
+	mock.ExpectExec("INSERT INTO audit").
+		WithArgs(2, 1, audit.ModLog, "10.0.0.1", entry.Time, audit.AuditDeletePost, "Thread/1", 1, 1, entry.ChainHash("")).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectCommit()
+
+	err = entry.Submit()
+	assert.NoError(t, err, "An error was not expected")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "An error was not expected")
+}
+
+// testAuditInsert expects the chained insert of the test entry
+func testAuditInsert(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+
+	mock.ExpectQuery(`SELECT ib_id FROM imageboards WHERE ib_id = \? FOR UPDATE`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id"}).AddRow(1))
+
+	mock.ExpectQuery(`SELECT audit_hash FROM audit`).
+		WithArgs(1, audit.ModLog).
+		WillReturnRows(sqlmock.NewRows([]string{"audit_hash"}).AddRow("previous"))
+
+	mock.ExpectExec("INSERT INTO audit").
+		WithArgs(2, 1, audit.ModLog, "10.0.0.1", sqlmock.AnyArg(), audit.AuditDeletePost, "Thread/1", 1, 1, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectCommit()
+}
+
+func TestSubmitAudit(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	redis.NewRedisMock()
+
+	testAuditInsert(mock)
+
+	publish := redis.Cache.Mock.GenericCommand("PUBLISH")
+	store := redis.Cache.Mock.GenericCommand("RPUSH")
+
+	entry := testAuditEntry()
+
+	err = SubmitPostAudit(entry.Audit, 1, 1)
+	assert.NoError(t, err, "An error was not expected")
+
+	assert.Equal(t, 1, redis.Cache.Mock.Stats(publish), "Entry should be published")
+	assert.Equal(t, 0, redis.Cache.Mock.Stats(store), "Entry should not be put in the outbox")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "An error was not expected")
+}
+
+func TestSubmitAuditOutbox(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	redis.NewRedisMock()
+
+	mock.ExpectBegin().WillReturnError(errors.New("database down"))
+
+	publish := redis.Cache.Mock.GenericCommand("PUBLISH")
+	store := redis.Cache.Mock.GenericCommand("RPUSH")
+
+	entry := testAuditEntry()
+
+	err = SubmitAudit(entry.Audit)
+	assert.Error(t, err, "The insert error should be returned")
+
+	assert.Equal(t, 1, redis.Cache.Mock.Stats(store), "Entry should be put in the outbox")
+	assert.Equal(t, 0, redis.Cache.Mock.Stats(publish), "Entry should not be published")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "An error was not expected")
+}
+
+func TestSubmitAuditInvalid(t *testing.T) {
+	err := SubmitAudit(audit.Audit{Ib: 1})
+	assert.Error(t, err, "An error was expected")
+}
+
+// testOutboxLock lets the retry job take the outbox lock
+func testOutboxLock() (unlock *redigomock.Cmd) {
+	redis.Cache.Mock.GenericCommand("SET").Expect("OK")
+	return redis.Cache.Mock.Command("EVALSHA", auditOutboxUnlock.Hash(), 1, AuditOutboxLockKey, redigomock.NewAnyData()).Expect(int64(1))
+}
+
+func TestRetryAuditOutbox(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	redis.NewRedisMock()
+
+	unlock := testOutboxLock()
+
+	entry := testAuditEntry()
+
+	data, err := json.Marshal(entry)
+	assert.NoError(t, err, "An error was not expected")
+
+	// one entry and then an empty outbox
+	redis.Cache.Mock.Command("LINDEX", AuditOutboxKey, 0).Expect(data).Expect(nil)
+
+	testAuditInsert(mock)
+
+	publish := redis.Cache.Mock.GenericCommand("PUBLISH")
+	remove := redis.Cache.Mock.Command("LREM", AuditOutboxKey, 1, data).Expect(int64(1))
+
+	RetryAuditOutbox()
+
+	assert.Equal(t, 1, redis.Cache.Mock.Stats(publish), "Entry should be published")
+	assert.Equal(t, 1, redis.Cache.Mock.Stats(remove), "Entry should be removed once delivered")
+	assert.Equal(t, 1, redis.Cache.Mock.Stats(unlock), "Lock should be released")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "An error was not expected")
+}
+
+func TestRetryAuditOutboxFailed(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	redis.NewRedisMock()
+
+	unlock := testOutboxLock()
+
+	data, err := json.Marshal(testAuditEntry())
+	assert.NoError(t, err, "An error was not expected")
+
+	lindex := redis.Cache.Mock.Command("LINDEX", AuditOutboxKey, 0).Expect(data)
+
+	mock.ExpectBegin().WillReturnError(errors.New("database down"))
+
+	remove := redis.Cache.Mock.Command("LREM", AuditOutboxKey, 1, data).Expect(int64(1))
+
+	RetryAuditOutbox()
+
+	assert.Equal(t, 1, redis.Cache.Mock.Stats(lindex), "Retry should stop at the undelivered entry")
+	assert.Equal(t, 0, redis.Cache.Mock.Stats(remove), "Entry should stay in the outbox")
+	assert.Equal(t, 1, redis.Cache.Mock.Stats(unlock), "Lock should be released")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "An error was not expected")
+}
+
+func TestRetryAuditOutboxInvalid(t *testing.T) {
+	redis.NewRedisMock()
+
+	unlock := testOutboxLock()
+
+	data := []byte(`{"Ib":1}`)
+
+	redis.Cache.Mock.Command("LINDEX", AuditOutboxKey, 0).Expect(data).Expect(nil)
+
+	dead := redis.Cache.Mock.Command("RPUSH", AuditOutboxDeadKey, data).Expect(int64(1))
+	remove := redis.Cache.Mock.Command("LREM", AuditOutboxKey, 1, data).Expect(int64(1))
+
+	RetryAuditOutbox()
+
+	assert.Equal(t, 1, redis.Cache.Mock.Stats(dead), "Invalid entry should be moved aside")
+	assert.Equal(t, 1, redis.Cache.Mock.Stats(remove), "Invalid entry should leave the outbox")
+	assert.Equal(t, 1, redis.Cache.Mock.Stats(unlock), "Lock should be released")
+}
+
+func TestRetryAuditOutboxRefused(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	redis.NewRedisMock()
+
+	unlock := testOutboxLock()
+
+	refused, err := json.Marshal(testAuditEntry())
+	assert.NoError(t, err, "An error was not expected")
+
+	next := testAuditEntry()
+	next.Info = "Thread/2"
+
+	delivered, err := json.Marshal(next)
+	assert.NoError(t, err, "An error was not expected")
+
+	// the first entry is refused and the one behind it goes through
+	redis.Cache.Mock.Command("LINDEX", AuditOutboxKey, 0).Expect(refused).Expect(delivered).Expect(nil)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT ib_id FROM imageboards WHERE ib_id = \? FOR UPDATE`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id"}).AddRow(1))
+	mock.ExpectQuery(`SELECT audit_hash FROM audit`).
+		WithArgs(1, audit.ModLog).
+		WillReturnRows(sqlmock.NewRows([]string{"audit_hash"}).AddRow("previous"))
+	mock.ExpectExec("INSERT INTO audit").
+		WillReturnError(&mysql.MySQLError{Number: 1406, Message: "Data too long for column 'audit_info'"})
+	mock.ExpectRollback()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT ib_id FROM imageboards WHERE ib_id = \? FOR UPDATE`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id"}).AddRow(1))
+	mock.ExpectQuery(`SELECT audit_hash FROM audit`).
+		WithArgs(1, audit.ModLog).
+		WillReturnRows(sqlmock.NewRows([]string{"audit_hash"}).AddRow("previous"))
+	mock.ExpectExec("INSERT INTO audit").
+		WithArgs(2, 1, audit.ModLog, "10.0.0.1", sqlmock.AnyArg(), audit.AuditDeletePost, "Thread/2", 1, 1, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+
+	redis.Cache.Mock.GenericCommand("PUBLISH")
+
+	dead := redis.Cache.Mock.Command("RPUSH", AuditOutboxDeadKey, refused).Expect(int64(1))
+	removeRefused := redis.Cache.Mock.Command("LREM", AuditOutboxKey, 1, refused).Expect(int64(1))
+	removeDelivered := redis.Cache.Mock.Command("LREM", AuditOutboxKey, 1, delivered).Expect(int64(1))
+
+	RetryAuditOutbox()
+
+	assert.Equal(t, 1, redis.Cache.Mock.Stats(dead), "Refused entry should be moved aside")
+	assert.Equal(t, 1, redis.Cache.Mock.Stats(removeRefused), "Refused entry should leave the outbox")
+	assert.Equal(t, 1, redis.Cache.Mock.Stats(removeDelivered), "Entry behind it should be delivered")
+	assert.Equal(t, 1, redis.Cache.Mock.Stats(unlock), "Lock should be released")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "An error was not expected")
+}
+
+func TestRetryAuditOutboxBoardGone(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	redis.NewRedisMock()
+
+	testOutboxLock()
+
+	data, err := json.Marshal(testAuditEntry())
+	assert.NoError(t, err, "An error was not expected")
+
+	redis.Cache.Mock.Command("LINDEX", AuditOutboxKey, 0).Expect(data).Expect(nil)
+
+	// the board was deleted
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT ib_id FROM imageboards WHERE ib_id = \? FOR UPDATE`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"ib_id"}))
+	mock.ExpectRollback()
+
+	dead := redis.Cache.Mock.Command("RPUSH", AuditOutboxDeadKey, data).Expect(int64(1))
+	remove := redis.Cache.Mock.Command("LREM", AuditOutboxKey, 1, data).Expect(int64(1))
+
+	RetryAuditOutbox()
+
+	assert.Equal(t, 1, redis.Cache.Mock.Stats(dead), "Entry should be moved aside")
+	assert.Equal(t, 1, redis.Cache.Mock.Stats(remove), "Entry should leave the outbox")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "An error was not expected")
+}
+
+func TestRetryAuditOutboxLocked(t *testing.T) {
+	redis.NewRedisMock()
+
+	// another instance holds the lock
+	redis.Cache.Mock.GenericCommand("SET").Expect(nil)
+
+	lindex := redis.Cache.Mock.Command("LINDEX", AuditOutboxKey, 0).Expect(nil)
+	unlock := redis.Cache.Mock.GenericCommand("EVALSHA")
+
+	RetryAuditOutbox()
+
+	assert.Equal(t, 0, redis.Cache.Mock.Stats(lindex), "Outbox should be left to the lock holder")
+	assert.Equal(t, 0, redis.Cache.Mock.Stats(unlock), "Lock of the other instance should be kept")
+}
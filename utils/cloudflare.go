@@ -27,6 +27,7 @@ func CloudFlareBanIP(ip, reason string) (err error) {
 
 	// noop if cloudflare is not configured
 	if !config.Settings.CloudFlare.Configured {
+		cloudflareRequests.WithLabelValues("disabled").Inc()
 		return
 	}
 
@@ -71,10 +72,19 @@ func CloudFlareBanIP(ip, reason string) (err error) {
 
 	// do the request
 	// TODO: add errors here to a system log
-	_, err = netClient.Do(req)
+	resp, err := netClient.Do(req)
 	if err != nil {
+		cloudflareRequests.WithLabelValues("error").Inc()
 		return errors.New("error reaching cloudflare")
 	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		cloudflareRequests.WithLabelValues("rejected").Inc()
+		return errors.New("cloudflare rejected the request")
+	}
+
+	cloudflareRequests.WithLabelValues("success").Inc()
 
 	return
 }
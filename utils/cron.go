@@ -16,11 +16,29 @@ func init() {
 		panic("Could not add prune analytics cron job")
 	}
 
+	// archive expired audit entries
+	err = c.AddFunc("@midnight", PruneAudit)
+	if err != nil {
+		panic("Could not add prune audit cron job")
+	}
+
+	// remove expired bans
+	err = c.AddFunc("@hourly", PruneBans)
+	if err != nil {
+		panic("Could not add prune bans cron job")
+	}
+
+	// retry undelivered audit entries
+	err = c.AddFunc("@every 1m", RetryAuditOutbox)
+	if err != nil {
+		panic("Could not add audit outbox cron job")
+	}
+
 	c.Start()
 
 }
 
-// PruneAnalytics will remove old entries from the analytics table
+// PruneAnalytics will roll up and then remove old entries from the analytics table
 func PruneAnalytics() {
 
 	// Get Database handle
@@ -29,9 +47,37 @@ func PruneAnalytics() {
 		return
 	}
 
+	// keep the history before the raw entries are gone
+	err = RollupAnalytics()
+	if err != nil {
+		return
+	}
+
 	_, err = dbase.Exec("DELETE FROM analytics WHERE request_time < (now() - interval 1 month)")
 	if err != nil {
 		return
 	}
 
 }
+
+// PruneBans will remove the ip and account bans that have expired, bans
+// without an expiry are kept
+func PruneBans() {
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	_, err = dbase.Exec("DELETE FROM banned_ips WHERE ban_expires IS NOT NULL AND ban_expires < NOW()")
+	if err != nil {
+		return
+	}
+
+	_, err = dbase.Exec("DELETE FROM banned_users WHERE ban_expires IS NOT NULL AND ban_expires < NOW()")
+	if err != nil {
+		return
+	}
+
+}
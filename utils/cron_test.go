@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/db"
+)
+
+func TestPruneBans(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	mock.ExpectExec(`DELETE FROM banned_ips WHERE ban_expires IS NOT NULL AND ban_expires < NOW\(\)`).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	mock.ExpectExec(`DELETE FROM banned_users WHERE ban_expires IS NOT NULL AND ban_expires < NOW\(\)`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	PruneBans()
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "An error was not expected")
+}
+
+func TestPruneBansError(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	// the account bans are left for the next run
+	mock.ExpectExec(`DELETE FROM banned_ips`).
+		WillReturnError(errors.New("SQL error"))
+
+	PruneBans()
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "An error was not expected")
+}
@@ -0,0 +1,203 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	redigo "github.com/gomodule/redigo/redis"
+
+	"github.com/eirka/eirka-libs/audit"
+	"github.com/eirka/eirka-libs/redis"
+)
+
+// EventChannel returns the pub/sub channel for the moderation events of a board
+func EventChannel(ib uint) string {
+	return fmt.Sprintf("%s:%d", "events", ib)
+}
+
+// Publish will send a mod log entry to every admin instance subscribed to the board
+func (m *AuditEntry) Publish() (err error) {
+
+	// only moderation actions are streamed
+	if m.Type != audit.ModLog {
+		return
+	}
+
+	if redis.Cache.Pool == nil {
+		return redis.ErrCacheNotInitialized
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return
+	}
+
+	conn := redis.Cache.Pool.Get()
+	defer conn.Close()
+
+	_, err = conn.Do("PUBLISH", EventChannel(m.Ib), data)
+
+	return
+
+}
+
+// how many entries a stream can fall behind before it misses entries
+const subscriptionBuffer = 32
+
+// Subscription receives the mod log entries published for a board
+type Subscription struct {
+	Entries chan AuditEntry
+	ib      uint
+}
+
+// eventHub shares one redis subscription between every event stream of the
+// process and hands the entries of a board to its streams
+type eventHub struct {
+	mu      sync.Mutex
+	conn    *redigo.PubSubConn
+	clients map[*Subscription]struct{}
+}
+
+var events = &eventHub{}
+
+// SubscribeEvents will subscribe to the moderation events of a board, the
+// redis connection is shared with the other subscriptions of the process
+func SubscribeEvents(ib uint) (s *Subscription, err error) {
+
+	events.mu.Lock()
+	defer events.mu.Unlock()
+
+	s = &Subscription{
+		Entries: make(chan AuditEntry, subscriptionBuffer),
+		ib:      ib,
+	}
+
+	// the first stream opens the shared connection
+	if events.conn == nil {
+		if redis.Cache.Pool == nil {
+			return nil, redis.ErrCacheNotInitialized
+		}
+
+		conn := &redigo.PubSubConn{Conn: redis.Cache.Pool.Get()}
+
+		err = conn.Subscribe(EventChannel(ib))
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+
+		events.conn = conn
+		events.clients = map[*Subscription]struct{}{s: {}}
+
+		go events.receive(conn)
+
+		return
+	}
+
+	// the first stream of a board subscribes to its channel
+	if events.count(ib) == 0 {
+		err = events.conn.Subscribe(EventChannel(ib))
+		if err != nil {
+			events.reset(events.conn)
+			return nil, err
+		}
+	}
+
+	events.clients[s] = struct{}{}
+
+	return
+
+}
+
+// count returns the amount of streams of a board, the lock must be held
+func (h *eventHub) count(ib uint) (count int) {
+
+	for client := range h.clients {
+		if client.ib == ib {
+			count++
+		}
+	}
+
+	return
+
+}
+
+// reset ends every stream and closes the connection if it is still the
+// current one, the next stream opens a new connection. The lock must be held.
+func (h *eventHub) reset(conn *redigo.PubSubConn) {
+
+	if h.conn != conn {
+		return
+	}
+
+	for client := range h.clients {
+		close(client.Entries)
+	}
+
+	h.clients = nil
+	h.conn = nil
+
+	conn.Close()
+
+}
+
+// receive hands the published entries to the streams of their board until
+// the connection fails
+func (h *eventHub) receive(conn *redigo.PubSubConn) {
+
+	for {
+		switch v := conn.Receive().(type) {
+		case redigo.Message:
+			entry := AuditEntry{}
+
+			err := json.Unmarshal(v.Data, &entry)
+			if err != nil {
+				continue
+			}
+
+			h.mu.Lock()
+			for client := range h.clients {
+				if client.ib != entry.Ib {
+					continue
+				}
+
+				// a stream that is not keeping up misses the entry instead of
+				// holding up the others
+				select {
+				case client.Entries <- entry:
+				default:
+				}
+			}
+			h.mu.Unlock()
+		case error:
+			h.mu.Lock()
+			h.reset(conn)
+			h.mu.Unlock()
+			return
+		}
+	}
+
+}
+
+// Close will end the stream, the board is unsubscribed when its last stream ends
+func (s *Subscription) Close() (err error) {
+
+	events.mu.Lock()
+	defer events.mu.Unlock()
+
+	// the connection failed and the stream was already ended
+	if _, ok := events.clients[s]; !ok {
+		return
+	}
+
+	delete(events.clients, s)
+	close(s.Entries)
+
+	if events.count(s.ib) == 0 {
+		err = events.conn.Unsubscribe(EventChannel(s.ib))
+	}
+
+	return
+
+}
@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/eirka/eirka-libs/audit"
+	"github.com/eirka/eirka-libs/redis"
+)
+
+// testNextEntry waits for an entry of a subscription
+func testNextEntry(s *Subscription) (entry AuditEntry, ok bool) {
+	select {
+	case entry, ok = <-s.Entries:
+	case <-time.After(time.Second):
+	}
+	return
+}
+
+func TestSubscribeEventsShared(t *testing.T) {
+	redis.NewRedisMock()
+
+	// every receive waits for the test
+	redis.Cache.Mock.ReceiveWait = true
+
+	subscribe := redis.Cache.Mock.Command("SUBSCRIBE", "events:1").Expect([]interface{}{
+		[]byte("subscribe"), []byte("events:1"), int64(1),
+	})
+	redis.Cache.Mock.Command("SUBSCRIBE", "events:2").Expect([]interface{}{
+		[]byte("subscribe"), []byte("events:2"), int64(2),
+	})
+	unsubscribe := redis.Cache.Mock.Command("UNSUBSCRIBE", "events:1").Expect([]interface{}{
+		[]byte("unsubscribe"), []byte("events:1"), int64(1),
+	})
+
+	first, err := SubscribeEvents(1)
+	assert.NoError(t, err, "An error was not expected")
+	second, err := SubscribeEvents(1)
+	assert.NoError(t, err, "An error was not expected")
+	other, err := SubscribeEvents(2)
+	assert.NoError(t, err, "An error was not expected")
+
+	assert.Equal(t, 1, redis.Cache.Mock.Stats(subscribe), "A board should only be subscribed once")
+
+	data, _ := json.Marshal(AuditEntry{
+		Audit: audit.Audit{
+			User:   2,
+			Ib:     1,
+			Type:   audit.ModLog,
+			IP:     "10.0.0.1",
+			Action: audit.AuditDeletePost,
+			Info:   "Thread/1",
+		},
+	})
+
+	redis.Cache.Mock.AddSubscriptionMessage([]interface{}{
+		[]byte("message"), []byte("events:1"), data,
+	})
+
+	// the two subscribe replies and the message
+	for i := 0; i < 3; i++ {
+		redis.Cache.Mock.ReceiveNow <- true
+	}
+
+	entry, ok := testNextEntry(first)
+	if assert.True(t, ok, "First stream should get the entry") {
+		assert.Equal(t, audit.AuditDeletePost, entry.Action, "Entry should match")
+	}
+
+	_, ok = testNextEntry(second)
+	assert.True(t, ok, "Second stream should get the entry")
+
+	select {
+	case <-other.Entries:
+		t.Error("Streams of other boards should not get the entry")
+	default:
+	}
+
+	// the board stays subscribed while it has a stream
+	assert.NoError(t, first.Close(), "An error was not expected")
+	assert.Equal(t, 0, redis.Cache.Mock.Stats(unsubscribe), "Board should still be subscribed")
+
+	assert.NoError(t, second.Close(), "An error was not expected")
+	assert.Equal(t, 1, redis.Cache.Mock.Stats(unsubscribe), "Board should be unsubscribed")
+
+	// a failed connection ends the remaining streams
+	close(redis.Cache.Mock.ReceiveNow)
+
+	_, ok = testNextEntry(other)
+	assert.False(t, ok, "Stream should end with the connection")
+
+	assert.NoError(t, other.Close(), "Closing an ended stream should not fail")
+}
+
+func TestSubscribeEventsNoRedis(t *testing.T) {
+	redis.Cache.Pool = nil
+
+	_, err := SubscribeEvents(1)
+	assert.Equal(t, redis.ErrCacheNotInitialized, err, "Error should match")
+}
@@ -0,0 +1,192 @@
+package utils
+
+import (
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	redigo "github.com/gomodule/redigo/redis"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+	"github.com/eirka/eirka-libs/redis"
+
+	local "github.com/eirka/eirka-admin/config"
+)
+
+// the prefix of all our metrics
+const metricsNamespace = "eirka_admin"
+
+// MetricsRegistry holds everything that is served on the metrics endpoint
+var MetricsRegistry = prometheus.NewRegistry()
+
+var (
+	requestCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latencies by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	auditActions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "moderation_actions_total",
+		Help:      "Audited moderation actions by action.",
+	}, []string{"action"})
+
+	auditOutbox = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "audit_outbox_retries_total",
+		Help:      "Audit outbox entries handled by the retry job by outcome.",
+	}, []string{"outcome"})
+
+	cloudflareRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "cloudflare_requests_total",
+		Help:      "CloudFlare API calls by outcome.",
+	}, []string{"outcome"})
+)
+
+func init() {
+
+	MetricsRegistry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requestCount,
+		requestDuration,
+		auditActions,
+		auditOutbox,
+		cloudflareRequests,
+		poolCollector{},
+	)
+
+}
+
+// Metrics will count every request and its latency by route
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		start := time.Now()
+
+		c.Next()
+
+		// use the route pattern so ids do not create new series
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		requestCount.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		requestDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+
+	}
+}
+
+// MetricsController will serve the metrics in the prometheus text format
+func MetricsController() gin.HandlerFunc {
+	return gin.WrapH(promhttp.HandlerFor(MetricsRegistry, promhttp.HandlerOpts{}))
+}
+
+// MetricsAllow will only let the listed addresses or networks through, an
+// empty list lets nothing through
+func MetricsAllow(allow []string) gin.HandlerFunc {
+
+	var networks []*net.IPNet
+
+	for _, entry := range allow {
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				panic("Invalid metrics allow entry " + entry)
+			}
+			network = &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)}
+		}
+		networks = append(networks, network)
+	}
+
+	return func(c *gin.Context) {
+
+		// the client ip can be set with headers so only the peer is trusted
+		ip := net.ParseIP(c.RemoteIP())
+
+		for _, network := range networks {
+			if ip != nil && network.Contains(ip) {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(e.ErrorMessage(e.ErrForbidden))
+		c.Error(e.ErrForbidden).SetMeta("utils.MetricsAllow")
+		c.Abort()
+
+	}
+}
+
+// poolCollector reads the database and redis pool usage on every scrape
+type poolCollector struct{}
+
+var (
+	dbConnectionsDesc = prometheus.NewDesc(metricsNamespace+"_db_connections",
+		"Database connections by state.", []string{"state"}, nil)
+	dbWaitDesc = prometheus.NewDesc(metricsNamespace+"_db_wait_total",
+		"Database connections that had to be waited for.", nil, nil)
+	dbMaxIdleDesc = prometheus.NewDesc(metricsNamespace+"_db_max_idle",
+		"The DatabaseMaxIdle setting.", nil, nil)
+	dbMaxConnectionsDesc = prometheus.NewDesc(metricsNamespace+"_db_max_connections",
+		"The DatabaseMaxConnections setting.", nil, nil)
+	redisConnectionsDesc = prometheus.NewDesc(metricsNamespace+"_redis_connections",
+		"Redis connections by state.", []string{"state"}, nil)
+	redisMaxIdleDesc = prometheus.NewDesc(metricsNamespace+"_redis_max_idle",
+		"The RedisMaxIdle setting.", nil, nil)
+	redisMaxConnectionsDesc = prometheus.NewDesc(metricsNamespace+"_redis_max_connections",
+		"The RedisMaxConnections setting.", nil, nil)
+)
+
+// Describe sends the descriptions of the pool metrics
+func (poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- dbConnectionsDesc
+	ch <- dbWaitDesc
+	ch <- dbMaxIdleDesc
+	ch <- dbMaxConnectionsDesc
+	ch <- redisConnectionsDesc
+	ch <- redisMaxIdleDesc
+	ch <- redisMaxConnectionsDesc
+}
+
+// Collect sends the current pool usage and limits
+func (poolCollector) Collect(ch chan<- prometheus.Metric) {
+
+	ch <- prometheus.MustNewConstMetric(dbMaxIdleDesc, prometheus.GaugeValue, float64(local.Settings.Admin.DatabaseMaxIdle))
+	ch <- prometheus.MustNewConstMetric(dbMaxConnectionsDesc, prometheus.GaugeValue, float64(local.Settings.Admin.DatabaseMaxConnections))
+	ch <- prometheus.MustNewConstMetric(redisMaxIdleDesc, prometheus.GaugeValue, float64(local.Settings.Admin.RedisMaxIdle))
+	ch <- prometheus.MustNewConstMetric(redisMaxConnectionsDesc, prometheus.GaugeValue, float64(local.Settings.Admin.RedisMaxConnections))
+
+	dbase, err := db.GetDb()
+	if err == nil {
+		stats := dbase.Stats()
+		ch <- prometheus.MustNewConstMetric(dbConnectionsDesc, prometheus.GaugeValue, float64(stats.InUse), "in_use")
+		ch <- prometheus.MustNewConstMetric(dbConnectionsDesc, prometheus.GaugeValue, float64(stats.Idle), "idle")
+		ch <- prometheus.MustNewConstMetric(dbWaitDesc, prometheus.CounterValue, float64(stats.WaitCount))
+	}
+
+	// the mock pool in tests has no stats
+	pool, ok := redis.Cache.Pool.(*redigo.Pool)
+	if ok {
+		stats := pool.Stats()
+		ch <- prometheus.MustNewConstMetric(redisConnectionsDesc, prometheus.GaugeValue, float64(stats.ActiveCount-stats.IdleCount), "in_use")
+		ch <- prometheus.MustNewConstMetric(redisConnectionsDesc, prometheus.GaugeValue, float64(stats.IdleCount), "idle")
+	}
+
+}
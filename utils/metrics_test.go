@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// testMetricsRequest requests the metrics endpoint from a client address
+func testMetricsRequest(allow []string, remote string, headers map[string]string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.GET("/metrics", MetricsAllow(allow), func(c *gin.Context) {
+		c.String(http.StatusOK, "metrics")
+	})
+
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	req.RemoteAddr = remote
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestMetricsAllowCIDR(t *testing.T) {
+	response := testMetricsRequest([]string{"10.0.0.0/8"}, "10.20.30.40:1234", nil)
+
+	assert.Equal(t, http.StatusOK, response.Code, "Client in the network should be allowed")
+}
+
+func TestMetricsAllowIP(t *testing.T) {
+	response := testMetricsRequest([]string{"192.168.1.5"}, "192.168.1.5:1234", nil)
+
+	assert.Equal(t, http.StatusOK, response.Code, "Listed client should be allowed")
+
+	response = testMetricsRequest([]string{"192.168.1.5"}, "192.168.1.6:1234", nil)
+
+	assert.Equal(t, http.StatusForbidden, response.Code, "Neighbouring client should be denied")
+}
+
+func TestMetricsAllowDenied(t *testing.T) {
+	response := testMetricsRequest([]string{"10.0.0.0/8", "192.168.1.5"}, "172.16.0.1:1234", nil)
+
+	assert.Equal(t, http.StatusForbidden, response.Code, "Unlisted client should be denied")
+	assert.NotContains(t, response.Body.String(), "metrics", "Metrics should not be served")
+}
+
+func TestMetricsAllowForwardedHeader(t *testing.T) {
+	response := testMetricsRequest([]string{"10.0.0.0/8"}, "172.16.0.1:1234", map[string]string{
+		"X-Forwarded-For": "10.0.0.1",
+		"X-Real-IP":       "10.0.0.1",
+	})
+
+	assert.Equal(t, http.StatusForbidden, response.Code, "Forwarded headers should not be trusted")
+}
+
+func TestMetricsAllowLoopback(t *testing.T) {
+	response := testMetricsRequest(nil, "127.0.0.1:1234", nil)
+
+	assert.Equal(t, http.StatusForbidden, response.Code, "Loopback should be denied without a list")
+
+	response = testMetricsRequest([]string{"127.0.0.1"}, "127.0.0.1:1234", nil)
+
+	assert.Equal(t, http.StatusOK, response.Code, "Loopback should be allowed when it is listed")
+
+	response = testMetricsRequest([]string{"10.0.0.0/8"}, "127.0.0.1:1234", nil)
+
+	assert.Equal(t, http.StatusForbidden, response.Code, "Loopback should be denied when it is not listed")
+}
+
+func TestMetricsAllowInvalidEntry(t *testing.T) {
+	assert.Panics(t, func() {
+		MetricsAllow([]string{"not an address"})
+	}, "An invalid entry should panic")
+}
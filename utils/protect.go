@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"database/sql"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/eirka/eirka-libs/db"
+	e "github.com/eirka/eirka-libs/errors"
+	"github.com/eirka/eirka-libs/user"
+)
+
+// SiteAdminRole is the global role of site administrators
+const SiteAdminRole = 4
+
+// SiteProtect will only let site administrators through, it is used instead of
+// user.Protect for routes that are not about a single board
+func SiteProtect() gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// get userdata from session middleware
+		userdata := c.MustGet("userdata").(user.User)
+
+		admin, err := IsSiteAdmin(userdata)
+		if err != nil {
+			c.JSON(e.ErrorMessage(e.ErrInternalError))
+			c.Error(err).SetMeta("utils.SiteProtect.IsSiteAdmin")
+			c.Abort()
+			return
+		}
+
+		if !admin {
+			c.JSON(e.ErrorMessage(e.ErrForbidden))
+			c.Error(e.ErrForbidden).SetMeta("utils.SiteProtect.IsSiteAdmin")
+			c.Abort()
+			return
+		}
+
+		// this route was protected
+		c.Set("protected", true)
+
+		c.Next()
+
+	}
+}
+
+// IsSiteAdmin will check the global role of the user
+func IsSiteAdmin(u user.User) (admin bool, err error) {
+
+	if !u.IsValid() {
+		return
+	}
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	var role uint
+
+	err = dbase.QueryRow("SELECT role_id FROM user_role_map WHERE user_id = ?", u.ID).Scan(&role)
+	if err == sql.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return
+	}
+
+	return role == SiteAdminRole, nil
+
+}
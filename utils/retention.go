@@ -0,0 +1,195 @@
+package utils
+
+import (
+	"compress/gzip"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/eirka/eirka-libs/audit"
+	"github.com/eirka/eirka-libs/db"
+
+	local "github.com/eirka/eirka-admin/config"
+)
+
+// ArchivedAudit is an audit log row as written to the archive
+type ArchivedAudit struct {
+	ID     uint      `json:"audit_id"`
+	User   uint      `json:"user_id"`
+	Ib     uint      `json:"ib_id"`
+	Type   uint      `json:"audit_type"`
+	IP     string    `json:"audit_ip"`
+	Time   time.Time `json:"audit_time"`
+	Action string    `json:"audit_action"`
+	Info   string    `json:"audit_info"`
+	Thread uint      `json:"thread_id"`
+	Post   uint      `json:"post_num"`
+	Hash   *string   `json:"audit_hash"`
+}
+
+// PruneAudit will archive and remove audit log entries that are past the retention of their log type
+func PruneAudit() {
+
+	retention := map[audit.LogType]uint{
+		audit.BoardLog: local.Settings.Audit.BoardLogRetention,
+		audit.ModLog:   local.Settings.Audit.ModLogRetention,
+		audit.UserLog:  local.Settings.Audit.UserLogRetention,
+	}
+
+	for logtype, days := range retention {
+		// zero keeps the entries forever
+		if days == 0 {
+			continue
+		}
+
+		cutoff := time.Now().UTC().AddDate(0, 0, -int(days))
+
+		// a failed type is tried again on the next run
+		err := ArchiveAudit(logtype, cutoff, local.Settings.Directories.ArchiveDir)
+		if err != nil {
+			continue
+		}
+	}
+
+}
+
+// ArchiveAudit will write the entries of a log type older than the cutoff to a
+// compressed NDJSON file and delete them once the file is safely on disk. An
+// entry can be written after a newer one, so every board archives all of its
+// entries up to its newest one older than the cutoff, the chain of what is left
+// then always starts right after the anchor
+func ArchiveAudit(logtype audit.LogType, cutoff time.Time, dir string) (err error) {
+
+	// Get Database handle
+	dbase, err := db.GetDb()
+	if err != nil {
+		return
+	}
+
+	rows, err := dbase.Query(`SELECT audit.audit_id,user_id,audit.ib_id,audit_type,audit_ip,audit_time,audit_action,audit_info,thread_id,post_num,audit_hash
+    FROM audit
+    INNER JOIN (SELECT ib_id, MAX(audit_id) AS audit_id FROM audit
+    WHERE audit_type = ? AND audit_time < ?
+    GROUP BY ib_id) AS prefix ON audit.ib_id = prefix.ib_id
+    WHERE audit_type = ? AND audit.audit_id <= prefix.audit_id
+    ORDER BY audit.audit_id ASC`, logtype, cutoff, logtype)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	var file *os.File
+	var archive *gzip.Writer
+	var encoder *json.Encoder
+
+	// the newest archived entry of every board
+	last := make(map[uint]uint)
+	boards := []uint{}
+
+	// the newest archived chained entry of every board, the chain of what is
+	// left starts from it
+	anchors := make(map[uint]ArchivedAudit)
+
+	for rows.Next() {
+		entry := ArchivedAudit{}
+		var hash sql.NullString
+
+		err = rows.Scan(&entry.ID, &entry.User, &entry.Ib, &entry.Type, &entry.IP, &entry.Time, &entry.Action, &entry.Info, &entry.Thread, &entry.Post, &hash)
+		if err != nil {
+			return
+		}
+
+		if hash.Valid {
+			entry.Hash = &hash.String
+		}
+
+		// only create a file when there is something to archive
+		if file == nil {
+			err = os.MkdirAll(dir, 0750)
+			if err != nil {
+				return
+			}
+
+			name := fmt.Sprintf("audit-%d-%s.ndjson.gz", logtype, time.Now().UTC().Format("20060102T150405"))
+
+			file, err = os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0640)
+			if err != nil {
+				return
+			}
+			defer file.Close()
+
+			archive = gzip.NewWriter(file)
+			encoder = json.NewEncoder(archive)
+		}
+
+		err = encoder.Encode(entry)
+		if err != nil {
+			return
+		}
+
+		if _, ok := last[entry.Ib]; !ok {
+			boards = append(boards, entry.Ib)
+		}
+		last[entry.Ib] = entry.ID
+
+		if entry.Hash != nil {
+			anchors[entry.Ib] = entry
+		}
+	}
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	// nothing was old enough
+	if file == nil {
+		return
+	}
+
+	err = archive.Close()
+	if err != nil {
+		return
+	}
+
+	// make sure the archive is on disk before the rows are gone
+	err = file.Sync()
+	if err != nil {
+		return
+	}
+
+	// Get transaction handle
+	tx, err := db.GetTransaction()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	// the anchors are saved with the delete so the chain is never left without a start
+	for _, ib := range boards {
+		anchor, ok := anchors[ib]
+		if ok {
+			_, err = tx.Exec(`INSERT INTO audit_anchors (ib_id,audit_type,audit_id,audit_hash) VALUES (?,?,?,?)
+    ON DUPLICATE KEY UPDATE audit_id = VALUES(audit_id), audit_hash = VALUES(audit_hash)`,
+				anchor.Ib, logtype, anchor.ID, *anchor.Hash)
+			if err != nil {
+				return
+			}
+		}
+
+		_, err = tx.Exec("DELETE FROM audit WHERE ib_id = ? AND audit_type = ? AND audit_id <= ?", ib, logtype, last[ib])
+		if err != nil {
+			return
+		}
+	}
+
+	// Commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return
+	}
+
+	return
+
+}
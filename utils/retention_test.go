@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/eirka/eirka-libs/audit"
+	"github.com/eirka/eirka-libs/db"
+)
+
+func testArchiveRows(now time.Time) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"audit_id", "user_id", "ib_id", "audit_type", "audit_ip", "audit_time", "audit_action", "audit_info", "thread_id", "post_num", "audit_hash"}).
+		AddRow(1, 2, 1, audit.ModLog, "10.0.0.1", now, audit.AuditDeletePost, "Thread/1", 1, 1, "hash1").
+		AddRow(2, 2, 2, audit.ModLog, "10.0.0.1", now, audit.AuditDeletePost, "Thread/1", 1, 2, "hash2").
+		AddRow(3, 2, 1, audit.ModLog, "10.0.0.1", now, audit.AuditDeletePost, "Thread/2", 2, 1, "hash3").
+		AddRow(4, 2, 1, audit.ModLog, "10.0.0.1", now, audit.AuditDeletePost, "Thread/3", 3, 0, nil).
+		// delivered from the outbox after newer entries, it is before the cutoff
+		AddRow(5, 2, 1, audit.ModLog, "10.0.0.1", now.Add(2*time.Hour), audit.AuditDeletePost, "Thread/4", 4, 1, "hash5").
+		AddRow(6, 2, 1, audit.ModLog, "10.0.0.1", now.Add(-time.Hour), audit.AuditDeletePost, "Thread/4", 4, 2, "hash6")
+}
+
+func TestArchiveAudit(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	dir := t.TempDir()
+	now := time.Now().UTC().Truncate(time.Second)
+	cutoff := now.Add(time.Hour)
+
+	// every board archives up to its newest entry before the cutoff
+	mock.ExpectQuery(`SELECT audit.audit_id,(.+)FROM audit
+    INNER JOIN \(SELECT ib_id, MAX\(audit_id\) AS audit_id FROM audit`).
+		WithArgs(audit.ModLog, cutoff, audit.ModLog).
+		WillReturnRows(testArchiveRows(now))
+
+	mock.ExpectBegin()
+
+	// the last chained entry of every board is kept as the anchor
+	mock.ExpectExec("INSERT INTO audit_anchors").
+		WithArgs(1, audit.ModLog, 6, "hash6").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec("DELETE FROM audit").
+		WithArgs(1, audit.ModLog, 6).
+		WillReturnResult(sqlmock.NewResult(0, 5))
+
+	mock.ExpectExec("INSERT INTO audit_anchors").
+		WithArgs(2, audit.ModLog, 2, "hash2").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+
+	mock.ExpectExec("DELETE FROM audit").
+		WithArgs(2, audit.ModLog, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectCommit()
+
+	err = ArchiveAudit(audit.ModLog, cutoff, dir)
+	assert.NoError(t, err, "An error was not expected")
+
+	files, err := filepath.Glob(filepath.Join(dir, "audit-2-*.ndjson.gz"))
+	assert.NoError(t, err, "An error was not expected")
+
+	if assert.Len(t, files, 1, "There should be one archive") {
+		file, err := os.Open(files[0])
+		assert.NoError(t, err, "An error was not expected")
+		defer file.Close()
+
+		archive, err := gzip.NewReader(file)
+		assert.NoError(t, err, "An error was not expected")
+
+		var entries []ArchivedAudit
+
+		scanner := bufio.NewScanner(archive)
+		for scanner.Scan() {
+			entry := ArchivedAudit{}
+			assert.NoError(t, json.Unmarshal(scanner.Bytes(), &entry), "An error was not expected")
+			entries = append(entries, entry)
+		}
+
+		if assert.Len(t, entries, 6, "Every entry should be archived") {
+			assert.Equal(t, uint(1), entries[0].ID, "Entries should be in order")
+			assert.Nil(t, entries[3].Hash, "Unchained entries should have no hash")
+		}
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "An error was not expected")
+}
+
+func TestArchiveAuditNothingOld(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	dir := t.TempDir()
+	cutoff := time.Now().UTC()
+
+	mock.ExpectQuery(`SELECT audit.audit_id,(.+)FROM audit`).
+		WithArgs(audit.BoardLog, cutoff, audit.BoardLog).
+		WillReturnRows(sqlmock.NewRows([]string{"audit_id", "user_id", "ib_id", "audit_type", "audit_ip", "audit_time", "audit_action", "audit_info", "thread_id", "post_num", "audit_hash"}))
+
+	err = ArchiveAudit(audit.BoardLog, cutoff, dir)
+	assert.NoError(t, err, "An error was not expected")
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	assert.Empty(t, files, "No archive should be written")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "An error was not expected")
+}
+
+func TestArchiveAuditAnchorError(t *testing.T) {
+	var err error
+
+	mock, err := db.NewTestDb()
+	assert.NoError(t, err, "An error was not expected")
+	defer db.CloseDb()
+
+	dir := t.TempDir()
+	now := time.Now().UTC().Truncate(time.Second)
+	cutoff := now.Add(time.Hour)
+
+	mock.ExpectQuery(`SELECT audit.audit_id,(.+)FROM audit`).
+		WithArgs(audit.ModLog, cutoff, audit.ModLog).
+		WillReturnRows(testArchiveRows(now))
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec("INSERT INTO audit_anchors").
+		WillReturnError(errors.New("SQL error"))
+
+	// the entries must not be deleted without their anchor
+	mock.ExpectRollback()
+
+	err = ArchiveAudit(audit.ModLog, cutoff, dir)
+	assert.Error(t, err, "An error was expected")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "An error was not expected")
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/eirka/eirka-libs/db"
+)
+
+// how many complete days are rolled up on every run, this covers the month of
+// raw analytics that is kept so the first run backfills everything and a missed
+// run is caught up
+const rollupDays = 31
+
+// RollupAnalytics will add the daily visitor, hit and per item hit counts of
+// the analytics table to the rollup tables which are never pruned
+func RollupAnalytics() (err error) {
+
+	// only complete days are rolled up
+	now := time.Now().UTC()
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	start := end.AddDate(0, 0, -rollupDays)
+
+	// Get transaction handle
+	tx, err := db.GetTransaction()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`INSERT INTO analytics_daily (ib_id,rollup_date,visitors,hits)
+    SELECT ib_id, DATE(request_time), COUNT(DISTINCT request_ip), COUNT(request_itemkey)
+    FROM analytics
+    WHERE request_time >= ? AND request_time < ?
+    GROUP BY ib_id, DATE(request_time)
+    ON DUPLICATE KEY UPDATE visitors = VALUES(visitors), hits = VALUES(hits)`, start, end)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`INSERT INTO analytics_item_daily (ib_id,rollup_date,request_itemkey,hits)
+    SELECT ib_id, DATE(request_time), request_itemkey, COUNT(*)
+    FROM analytics
+    WHERE request_time >= ? AND request_time < ?
+    GROUP BY ib_id, DATE(request_time), request_itemkey
+    ON DUPLICATE KEY UPDATE hits = VALUES(hits)`, start, end)
+	if err != nil {
+		return
+	}
+
+	// Commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return
+	}
+
+	return
+
+}